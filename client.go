@@ -1,6 +1,7 @@
 package robomaster
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"reflect"
@@ -22,6 +23,16 @@ import (
 	"github.com/brunoga/robomaster/unitybridge/wrapper"
 )
 
+// moduleWaitTimeout is how long Start waits for each module connection to be
+// established.
+const moduleWaitTimeout = 10 * time.Second
+
+// contextStarter is implemented by modules that support being started with a
+// context.
+type contextStarter interface {
+	StartContext(ctx context.Context) error
+}
+
 type Client struct {
 	l *logger.Logger
 
@@ -49,29 +60,46 @@ type Client struct {
 // To get a robot to broadcast a given appID, use a QRCode to configure it (see
 // https://github.com/brunoga/robomaster/unitybridge/blob/main/support/qrcode/qrcode.go).
 func New(l *logger.Logger, appID uint64) (*Client, error) {
-	return new(l, appID, connection.TypeRouter, module.TypeAll)
+	return new(l, appID, connection.TypeRouter, module.TypeAll, nil)
 }
 
 // NewWithModules is like New but allows selecting which mkodules to enable.
 // The Connection and Robot modules are required.
 func NewWithModules(l *logger.Logger, appID uint64,
 	modules module.Type) (*Client, error) {
-	return new(l, appID, connection.TypeRouter, modules)
+	return new(l, appID, connection.TypeRouter, modules, nil)
 }
 
 // NewWifiDirect creates a new Client instance with the given logger. This
 // client will connect to the robot using WiFi Direct.
 func NewWifiDirect(l *logger.Logger) (*Client, error) {
-	return new(l, 0, connection.TypeWiFiDirect, module.TypeAllButGamePad)
+	return new(l, 0, connection.TypeWiFiDirect, module.TypeAllButGamePad, nil)
 }
 
 func NewWifiDirectWithModules(l *logger.Logger,
 	modules module.Type) (*Client, error) {
-	return new(l, 0, connection.TypeWiFiDirect, modules)
+	return new(l, 0, connection.TypeWiFiDirect, modules, nil)
+}
+
+// NewWithWrapper is like NewWithModules but allows selecting the connection
+// type and the Unity Bridge wrapper to use. This is mostly useful to run a
+// client against an alternative wrapper implementation (for example, the
+// simulator in unitybridge/wrapper/simulator). If uw is nil, the default
+// wrapper for the current platform is used.
+func NewWithWrapper(l *logger.Logger, appID uint64, typ connection.Type,
+	modules module.Type, uw wrapper.UnityBridge) (*Client, error) {
+	return new(l, appID, typ, modules, uw)
 }
 
 // Start starts the client and all associated modules.
 func (c *Client) Start() error {
+	return c.StartContext(context.Background())
+}
+
+// StartContext is like Start but gives up when the given context is done.
+// Independently of the context, each module waits at most moduleWaitTimeout
+// for its connection to be established.
+func (c *Client) StartContext(ctx context.Context) error {
 	c.m.Lock()
 	defer c.m.Unlock()
 
@@ -86,58 +114,61 @@ func (c *Client) Start() error {
 
 	// Start modules.
 
-	waitTimeout := 10 * time.Second
-
 	// Connection.
-	err = c.changeStateIfNonNil(c.connectionModule, waitTimeout, true)
+	err = c.startIfNonNil(ctx, c.connectionModule)
 	if err != nil {
 		return err
 	}
 
 	// Robot.
-	err = c.changeStateIfNonNil(c.robotModule, waitTimeout, true)
+	err = c.startIfNonNil(ctx, c.robotModule)
 	if err != nil {
 		return err
 	}
 
 	// Wait for devices to be available.
+	waitTimeout, err := moduleWaitTimeoutFor(ctx)
+	if err != nil {
+		return err
+	}
+
 	if !c.robotModule.WaitForDevices(waitTimeout) {
 		return fmt.Errorf("robot working devices unexpectedly not established")
 	}
 
 	// Controller.
-	err = c.changeStateIfNonNil(c.controllerModule, waitTimeout, true)
+	err = c.startIfNonNil(ctx, c.controllerModule)
 	if err != nil {
 		return err
 	}
 
 	// Camera.
-	err = c.changeStateIfNonNil(c.cameraModule, waitTimeout, true)
+	err = c.startIfNonNil(ctx, c.cameraModule)
 	if err != nil {
 		return err
 	}
 
 	// SDCard.
-	err = c.changeStateIfNonNil(c.sdCardModule, waitTimeout, true)
+	err = c.startIfNonNil(ctx, c.sdCardModule)
 	if err != nil {
 		return err
 	}
 
 	// Chassis.
-	err = c.changeStateIfNonNil(c.chassisModule, waitTimeout, true)
+	err = c.startIfNonNil(ctx, c.chassisModule)
 	if err != nil {
 		return err
 	}
 
 	// Gimbal.
-	err = c.changeStateIfNonNil(c.gimbalModule, waitTimeout, true)
+	err = c.startIfNonNil(ctx, c.gimbalModule)
 	if err != nil {
 		return err
 	}
 
 	// Gun.
 	go func() {
-		err = c.changeStateIfNonNil(c.gunModule, waitTimeout, true)
+		err := c.changeStateIfNonNil(c.gunModule, moduleWaitTimeout, true)
 		if err != nil {
 			if err.Error() == "Gun connection not established" {
 				// Gun is optional so it is fine it did not connect.
@@ -150,7 +181,7 @@ func (c *Client) Start() error {
 
 	// GamePad.
 	go func() {
-		err = c.changeStateIfNonNil(c.gamePadModule, waitTimeout, true)
+		err := c.changeStateIfNonNil(c.gamePadModule, moduleWaitTimeout, true)
 		if err != nil {
 			if err.Error() == "GamePad connection not established" {
 				// GamePad is optional so it is fine it did not connect.
@@ -284,7 +315,7 @@ func (c *Client) Stop() error {
 }
 
 func new(l *logger.Logger, appID uint64, typ connection.Type,
-	modules module.Type) (*Client, error) {
+	modules module.Type, uw wrapper.UnityBridge) (*Client, error) {
 	if l == nil {
 		l = logger.New(slog.LevelError)
 	}
@@ -300,7 +331,11 @@ func new(l *logger.Logger, appID uint64, typ connection.Type,
 	// Enable Unity Bridge debug logging if the logger level is trace.
 	unityBridgeDebugEnabled := l.Level() <= slog.LevelDebug
 
-	ub := unitybridge.Get(wrapper.Get(l), unityBridgeDebugEnabled, l)
+	if uw == nil {
+		uw = wrapper.Get(l)
+	}
+
+	ub := unitybridge.Get(uw, unityBridgeDebugEnabled, l)
 
 	connectionModule, err := connection.New(ub, l, appID, typ)
 	if err != nil {
@@ -338,7 +373,7 @@ func new(l *logger.Logger, appID uint64, typ connection.Type,
 
 	var gimbalModule *gimbal.Gimbal
 	if modules&module.TypeGimbal != 0 {
-		gimbalModule, err = gimbal.New(ub, l, connectionModule)
+		gimbalModule, err = gimbal.NewWithRobot(ub, l, connectionModule, robotModule)
 		if err != nil {
 			return nil, err
 		}
@@ -383,12 +418,33 @@ func new(l *logger.Logger, appID uint64, typ connection.Type,
 	}, nil
 }
 
+// startIfNonNil starts the given module (if it is not nil) and waits for its
+// connection to be established, honoring the given context.
+func (c *Client) startIfNonNil(ctx context.Context, m module.Module) error {
+	waitTimeout, err := moduleWaitTimeoutFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.changeStateIfNonNilContext(ctx, m, waitTimeout, true)
+}
+
 func (c *Client) changeStateIfNonNil(m module.Module, waitTime time.Duration,
 	start bool) error {
+	return c.changeStateIfNonNilContext(context.Background(), m, waitTime,
+		start)
+}
+
+func (c *Client) changeStateIfNonNilContext(ctx context.Context,
+	m module.Module, waitTime time.Duration, start bool) error {
 	var err error
 	if m != nil && !reflect.ValueOf(m).IsNil() {
 		if start {
-			err = m.Start()
+			if cs, ok := m.(contextStarter); ok {
+				err = cs.StartContext(ctx)
+			} else {
+				err = m.Start()
+			}
 		} else {
 			err = m.Stop()
 		}
@@ -406,3 +462,21 @@ func (c *Client) changeStateIfNonNil(m module.Module, waitTime time.Duration,
 
 	return nil
 }
+
+// moduleWaitTimeoutFor returns how long to wait for a module connection to be
+// established, taking the deadline of the given context (if any) into account.
+// It returns a non-nil error if the context is already done.
+func moduleWaitTimeoutFor(ctx context.Context) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	timeout := moduleWaitTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if d := time.Until(deadline); d < timeout {
+			timeout = d
+		}
+	}
+
+	return timeout, nil
+}
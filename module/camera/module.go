@@ -1,6 +1,7 @@
 package camera
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -12,11 +13,13 @@ import (
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/connection"
 	"github.com/brunoga/robomaster/module/internal"
+	"github.com/brunoga/robomaster/support/dispatcher"
 	"github.com/brunoga/robomaster/support/logger"
 	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge"
 	"github.com/brunoga/robomaster/unitybridge/unity/event"
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/key/typed"
 	"github.com/brunoga/robomaster/unitybridge/unity/result"
 	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
 )
@@ -39,6 +42,14 @@ type Module struct {
 
 	m         sync.RWMutex
 	callbacks map[token.Token]VideoCallback
+
+	videoFormat       typed.ReadWriter[value.Uint64]
+	videoTransRate    typed.Writer[value.Float64]
+	mode              typed.ReadWriter[value.Uint64]
+	digitalZoomFactor typed.ReadWriter[value.Uint64]
+	isRecording       typed.Reader[value.Bool]
+	startRecordVideo  typed.Performer[value.Void]
+	stopRecordVideo   typed.Performer[value.Void]
 }
 
 var _ module.Module = (*Module)(nil)
@@ -79,6 +90,49 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 			}
 		}, cm)
 
+	var err error
+
+	m.videoFormat, err = typed.NewReadWriter[value.Uint64](ub,
+		key.KeyCameraVideoFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	m.videoTransRate, err = typed.NewWriter[value.Float64](ub,
+		key.KeyCameraVideoTransRate)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mode, err = typed.NewReadWriter[value.Uint64](ub, key.KeyCameraMode)
+	if err != nil {
+		return nil, err
+	}
+
+	m.digitalZoomFactor, err = typed.NewReadWriter[value.Uint64](ub,
+		key.KeyCameraDigitalZoomFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	m.isRecording, err = typed.NewReader[value.Bool](ub,
+		key.KeyCameraIsRecording)
+	if err != nil {
+		return nil, err
+	}
+
+	m.startRecordVideo, err = typed.NewPerformer[value.Void](ub,
+		key.KeyCameraStartRecordVideo)
+	if err != nil {
+		return nil, err
+	}
+
+	m.stopRecordVideo, err = typed.NewPerformer[value.Void](ub,
+		key.KeyCameraStopRecordVideo)
+	if err != nil {
+		return nil, err
+	}
+
 	return m, nil
 }
 
@@ -98,8 +152,13 @@ func (m *Module) Start() error {
 		return err
 	}
 
-	m.vdrToken, err = m.UB().AddEventTypeListener(event.TypeVideoDataRecv,
-		m.onVideoDataRecv)
+	// Frames are big and only the latest one matters so, if we can not keep
+	// up, drop the older ones.
+	m.vdrToken, err = m.UB().AddEventTypeListenerWithOptions(
+		event.TypeVideoDataRecv, m.onVideoDataRecv, &dispatcher.Options{
+			QueueSize:      1,
+			OverflowPolicy: dispatcher.OverflowPolicyCoalesceLatest,
+		})
 	if err != nil {
 		return err
 	}
@@ -160,12 +219,17 @@ func (m *Module) RemoveVideoCallback(t token.Token) error {
 
 // VideoFormat returns the currently set video format.
 func (m *Module) VideoFormat() (VideoFormat, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraVideoFormat, true)
+	return m.VideoFormatContext(context.Background())
+}
+
+// VideoFormatContext is like VideoFormat but honors the given context.
+func (m *Module) VideoFormatContext(ctx context.Context) (VideoFormat, error) {
+	v, err := m.videoFormat.Get(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	return VideoFormat(r.Value().(*value.Uint64).Value), nil
+	return VideoFormat(v.Value), nil
 }
 
 // SetVideoFormat sets the video resolution.
@@ -176,63 +240,102 @@ func (m *Module) VideoFormat() (VideoFormat, error) {
 // that this is only for the video recorded in the robot and not for the
 // video being streamed from it.
 func (m *Module) SetVideoFormat(format VideoFormat) error {
-	return m.UB().SetKeyValueSync(key.KeyCameraVideoFormat, &value.Uint64{Value: uint64(format)})
+	return m.SetVideoFormatContext(context.Background(), format)
+}
+
+// SetVideoFormatContext is like SetVideoFormat but honors the given context.
+func (m *Module) SetVideoFormatContext(ctx context.Context,
+	format VideoFormat) error {
+	return m.videoFormat.Set(ctx, value.Uint64{Value: uint64(format)})
 }
 
 // SetVideoQuality sets the video quality.
 func (m *Module) SetVideoQuality(quality VideoQuality) error {
-	return m.UB().SetKeyValueSync(key.KeyCameraVideoTransRate, &value.Float64{Value: float64(quality)})
+	return m.SetVideoQualityContext(context.Background(), quality)
+}
+
+// SetVideoQualityContext is like SetVideoQuality but honors the given context.
+func (m *Module) SetVideoQualityContext(ctx context.Context,
+	quality VideoQuality) error {
+	return m.videoTransRate.Set(ctx, value.Float64{Value: float64(quality)})
 }
 
 // Mode returns the current camera mode.
 func (m *Module) Mode() (Mode, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraMode, true)
+	return m.ModeContext(context.Background())
+}
+
+// ModeContext is like Mode but honors the given context.
+func (m *Module) ModeContext(ctx context.Context) (Mode, error) {
+	v, err := m.mode.Get(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	return Mode(r.Value().(*value.Uint64).Value), nil
+	return Mode(v.Value), nil
 }
 
 // SetMode sets the camera mode.
 func (m *Module) SetMode(mode Mode) error {
-	return m.UB().SetKeyValueSync(key.KeyCameraMode,
-		&value.Uint64{Value: uint64(mode)})
+	return m.SetModeContext(context.Background(), mode)
+}
+
+// SetModeContext is like SetMode but honors the given context.
+func (m *Module) SetModeContext(ctx context.Context, mode Mode) error {
+	return m.mode.Set(ctx, value.Uint64{Value: uint64(mode)})
 }
 
 // ExposureMode returns the current digital zoom factor.
 func (m *Module) DigitalZoomFactor() (uint64, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraDigitalZoomFactor,
-		true)
+	return m.DigitalZoomFactorContext(context.Background())
+}
+
+// DigitalZoomFactorContext is like DigitalZoomFactor but honors the given
+// context.
+func (m *Module) DigitalZoomFactorContext(ctx context.Context) (uint64, error) {
+	v, err := m.digitalZoomFactor.Get(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	return r.Value().(*value.Uint64).Value, nil
+	return v.Value, nil
 }
 
 // SetDigitalZoomFactor sets the digital zoom factor.
 func (m *Module) SetDigitalZoomFactor(factor uint64) error {
-	return m.UB().SetKeyValueSync(key.KeyCameraDigitalZoomFactor, &value.Uint64{Value: factor})
+	return m.SetDigitalZoomFactorContext(context.Background(), factor)
+}
+
+// SetDigitalZoomFactorContext is like SetDigitalZoomFactor but honors the given
+// context.
+func (m *Module) SetDigitalZoomFactorContext(ctx context.Context,
+	factor uint64) error {
+	return m.digitalZoomFactor.Set(ctx, value.Uint64{Value: factor})
 }
 
 // StartRecordingVideo starts recording video to the robot's internal storage.
 func (m *Module) StartRecordingVideo() error {
+	return m.StartRecordingVideoContext(context.Background())
+}
+
+// StartRecordingVideoContext is like StartRecordingVideo but honors the given
+// context.
+func (m *Module) StartRecordingVideoContext(ctx context.Context) error {
 	var err error
 
-	currentMode, err := m.Mode()
+	currentMode, err := m.ModeContext(ctx)
 	if err != nil {
 		return err
 	}
 
 	if currentMode != ModeVideo {
-		err = m.SetMode(ModeVideo)
+		err = m.SetModeContext(ctx, ModeVideo)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = m.UB().PerformActionForKeySync(key.KeyCameraStartRecordVideo, nil)
+	err = m.startRecordVideo.Perform(ctx, value.Void{})
 	if err != nil {
 		return err
 	}
@@ -256,12 +359,18 @@ func (m *Module) StartRecordingVideo() error {
 // IsRecordingVideo returns whether the robot is currently recording video to
 // its internal storage.
 func (m *Module) IsRecordingVideo() (bool, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraIsRecording, true)
+	return m.IsRecordingVideoContext(context.Background())
+}
+
+// IsRecordingVideoContext is like IsRecordingVideo but honors the given
+// context.
+func (m *Module) IsRecordingVideoContext(ctx context.Context) (bool, error) {
+	v, err := m.isRecording.Get(ctx)
 	if err != nil {
 		return false, err
 	}
 
-	return r.Value().(*value.Bool).Value, nil
+	return v.Value, nil
 }
 
 // RecordingTime returns the current recording time in seconds.
@@ -271,7 +380,13 @@ func (m *Module) RecordingTime() time.Duration {
 
 // StopRecordingVideo stops recording video to the robot's internal storage.
 func (m *Module) StopRecordingVideo() error {
-	err := m.UB().PerformActionForKeySync(key.KeyCameraStopRecordVideo, nil)
+	return m.StopRecordingVideoContext(context.Background())
+}
+
+// StopRecordingVideoContext is like StopRecordingVideo but honors the given
+// context.
+func (m *Module) StopRecordingVideoContext(ctx context.Context) error {
+	err := m.stopRecordVideo.Perform(ctx, value.Void{})
 	if err != nil {
 		return err
 	}
@@ -1,17 +1,24 @@
 package chassis
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/connection"
 	"github.com/brunoga/robomaster/module/internal"
 	"github.com/brunoga/robomaster/module/robot"
+	"github.com/brunoga/robomaster/support/dispatcher"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge"
+	"github.com/brunoga/robomaster/unitybridge/unity/control"
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/key/typed"
 	"github.com/brunoga/robomaster/unitybridge/unity/result"
 	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
 	"github.com/brunoga/robomaster/unitybridge/unity/task"
@@ -21,11 +28,55 @@ import (
 // controller interface.
 type Chassis struct {
 	*internal.BaseModule
+
+	rm *robot.Robot
+	tg *token.Generator
+
+	m         sync.RWMutex
+	telemetry Telemetry
+	listeners map[token.Token]*dispatcher.Dispatcher[Telemetry]
+
+	motors         [WheelCount]MotorInfo
+	motorListeners map[token.Token]*dispatcher.Dispatcher[MotorInfo]
+
+	wheelSpeed  typed.Writer[value.WheelSpeed]
+	escFirmware [WheelCount]typed.Reader[value.String]
+	limits      []typed.ReadWriter[value.Float64]
+
+	recordState       typed.ReadWriter[value.Uint64]
+	recordSetting     typed.Reader[value.RecordSetting]
+	playRecordAttr    typed.ReadWriter[value.PlayRecordAttr]
+	playRecordSetting typed.Reader[value.PlayRecordSetting]
+	setPlayRecord     typed.Writer[value.PlayRecordSetting]
+
+	commandListeners *internal.CommandListeners[MotionCommand]
+
+	speedToken    token.Token
+	positionToken token.Token
+	attitudeToken token.Token
+	motorTokens   [WheelCount]token.Token
+}
+
+// escMotorInfoKeys are the motor information keys for each wheel.
+var escMotorInfoKeys = [WheelCount]*key.Key{
+	key.KeyESCMotorInfomation1,
+	key.KeyESCMotorInfomation2,
+	key.KeyESCMotorInfomation3,
+	key.KeyESCMotorInfomation4,
+}
+
+// escFirmwareVersionKeys are the ESC firmware version keys for each wheel.
+var escFirmwareVersionKeys = [WheelCount]*key.Key{
+	key.KeyESCFirmwareVersion1,
+	key.KeyESCFirmwareVersion2,
+	key.KeyESCFirmwareVersion3,
+	key.KeyESCFirmwareVersion4,
 }
 
 var _ module.Module = (*Chassis)(nil)
 
-// New creates a new Chassis instance.
+// New creates a new Chassis instance. Position moves (SetPosition and its
+// variants) are only available if rm is not nil.
 func New(ub unitybridge.UnityBridge, l *logger.Logger,
 	cm *connection.Connection, rm *robot.Robot) (*Chassis, error) {
 	if l == nil {
@@ -34,28 +85,651 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 
 	l = l.WithGroup("chassis_module")
 
-	c := &Chassis{}
+	c := &Chassis{
+		rm:        rm,
+		tg:        token.NewGenerator(),
+		listeners: make(map[token.Token]*dispatcher.Dispatcher[Telemetry]),
+		motorListeners: make(
+			map[token.Token]*dispatcher.Dispatcher[MotorInfo]),
+		commandListeners: internal.NewCommandListeners[MotionCommand](),
+	}
+
+	for w := range c.motors {
+		c.motors[w].Wheel = Wheel(w)
+	}
+
+	c.BaseModule = internal.NewBaseModule(ub, l, "Chassis",
+		key.KeyMainControllerConnection, func(r *result.Result) {
+			if !r.Succeeded() {
+				c.Logger().Error("Connection: Unsuccessfull result.", "result", r)
+				return
+			}
+
+			connectedValue, ok := r.Value().(*value.Bool)
+			if !ok {
+				c.Logger().Error("Connection: Unexpected value.", "value", r.Value())
+				return
+			}
+
+			if connectedValue.Value {
+				c.Logger().Debug("Connected.")
+
+				err := c.UB().PerformActionForKeySync(
+					key.KeyRobomasterOpenChassisSpeedUpdates, nil)
+				if err != nil {
+					c.Logger().Error("Error opening chassis speed updates",
+						"error", err)
+				}
+			} else {
+				c.Logger().Debug("Disconnected.")
+
+				err := c.UB().PerformActionForKeySync(
+					key.KeyRobomasterCloseChassisSpeedUpdates, nil)
+				if err != nil {
+					c.Logger().Error("Error closing chassis speed updates",
+						"error", err)
+				}
+			}
+		}, cm)
+
+	var err error
+
+	c.wheelSpeed, err = typed.NewWriter[value.WheelSpeed](ub,
+		key.KeyMainControllerWheelSpeed)
+	if err != nil {
+		return nil, err
+	}
+
+	for w, k := range escFirmwareVersionKeys {
+		c.escFirmware[w], err = typed.NewReader[value.String](ub, k)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.limits = make([]typed.ReadWriter[value.Float64], len(limitsKeys))
+	for i, k := range limitsKeys {
+		c.limits[i], err = typed.NewReadWriter[value.Float64](ub, k)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.recordState, err = typed.NewReadWriter[value.Uint64](ub,
+		key.KeyMainControllerRecordState)
+	if err != nil {
+		return nil, err
+	}
+
+	c.recordSetting, err = typed.NewReader[value.RecordSetting](ub,
+		key.KeyMainControllerGetRecordSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	c.playRecordAttr, err = typed.NewReadWriter[value.PlayRecordAttr](ub,
+		key.KeyMainControllerPlayRecordAttr)
+	if err != nil {
+		return nil, err
+	}
+
+	c.playRecordSetting, err = typed.NewReader[value.PlayRecordSetting](ub,
+		key.KeyMainControllerGetPlayRecordSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setPlayRecord, err = typed.NewWriter[value.PlayRecordSetting](ub,
+		key.KeyMainControllerSetPlayRecordSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Start starts the chassis module.
+func (c *Chassis) Start() error {
+	var err error
+
+	// Telemetry updates come at a high rate and only the latest one matters.
+	o := &dispatcher.Options{
+		QueueSize:      1,
+		OverflowPolicy: dispatcher.OverflowPolicyCoalesceLatest,
+	}
+
+	// Listeners already added are removed if any of them fails.
+	unwind := func(err error) error {
+		return errors.Join(err, c.removeKeyListeners())
+	}
+
+	c.speedToken, err = c.UB().AddKeyListenerWithOptions(
+		key.KeyRobomasterChassisSpeed, c.onSpeed, false, o)
+	if err != nil {
+		return unwind(err)
+	}
+
+	c.positionToken, err = c.UB().AddKeyListenerWithOptions(
+		key.KeyRobomasterMainControllerRelativePosition, c.onPosition, true, o)
+	if err != nil {
+		return unwind(err)
+	}
+
+	c.attitudeToken, err = c.UB().AddKeyListenerWithOptions(
+		key.KeyRobomasterSystemAttitudeInfo, c.onAttitude, true, o)
+	if err != nil {
+		return unwind(err)
+	}
+
+	for w, k := range escMotorInfoKeys {
+		c.motorTokens[w], err = c.UB().AddKeyListenerWithOptions(k,
+			c.onMotorInfo(Wheel(w)), false, o)
+		if err != nil {
+			return unwind(err)
+		}
+	}
+
+	err = c.BaseModule.Start()
+	if err != nil {
+		return unwind(err)
+	}
+
+	return nil
+}
+
+// removeKeyListeners removes all key listeners added by Start (skipping the
+// ones that were not added). It tries to remove all of them even if some fail
+// and returns the errors, if any.
+func (c *Chassis) removeKeyListeners() error {
+	var errs []error
 
-	c.BaseModule = internal.NewBaseModule(ub, l, "Chassis", nil, func(r *result.Result) {
-		if !r.Succeeded() {
-			c.Logger().Error("Connection: Unsuccessfull result.", "result", r)
+	remove := func(k *key.Key, t *token.Token) {
+		if *t == 0 {
 			return
 		}
 
-		connectedValue, ok := r.Value().(*value.Bool)
-		if !ok {
-			c.Logger().Error("Connection: Unexpected value.", "value", r.Value())
+		if err := c.UB().RemoveKeyListener(k, *t); err != nil {
+			errs = append(errs, err)
+		}
+
+		*t = 0
+	}
+
+	remove(key.KeyRobomasterChassisSpeed, &c.speedToken)
+	remove(key.KeyRobomasterMainControllerRelativePosition, &c.positionToken)
+	remove(key.KeyRobomasterSystemAttitudeInfo, &c.attitudeToken)
+
+	for w, k := range escMotorInfoKeys {
+		remove(k, &c.motorTokens[w])
+	}
+
+	return errors.Join(errs...)
+}
+
+// Position returns the latest chassis position.
+func (c *Chassis) Position() Position {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.telemetry.Position
+}
+
+// Velocity returns the latest chassis velocity.
+func (c *Chassis) Velocity() Velocity {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.telemetry.Velocity
+}
+
+// Attitude returns the latest chassis attitude.
+func (c *Chassis) Attitude() Attitude {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.telemetry.Attitude
+}
+
+// Telemetry returns the latest chassis telemetry.
+func (c *Chassis) Telemetry() Telemetry {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.telemetry
+}
+
+// defaultTelemetryListenerOptions are the options used for telemetry
+// listeners when none are given. Only the latest snapshot is kept for a slow
+// listener so it never delays telemetry updates.
+var defaultTelemetryListenerOptions = dispatcher.Options{
+	QueueSize:      1,
+	OverflowPolicy: dispatcher.OverflowPolicyCoalesceLatest,
+}
+
+// AddTelemetryListener adds a listener that is called (in order) with a
+// telemetry snapshot whenever any of the telemetry data changes. If o is
+// nil, only the latest snapshot is delivered to a listener that does not
+// keep up. Note that using dispatcher.OverflowPolicyBlock makes a slow
+// listener delay telemetry updates for everybody. It returns a token that
+// can be used to remove the listener.
+func (c *Chassis) AddTelemetryListener(f func(Telemetry),
+	o *dispatcher.Options) (token.Token, error) {
+	if f == nil {
+		return 0, fmt.Errorf("listener must not be nil")
+	}
+
+	if o == nil {
+		o = &defaultTelemetryListenerOptions
+	}
+
+	d, err := dispatcher.New(o, f)
+	if err != nil {
+		return 0, err
+	}
+
+	t := c.tg.Next()
+
+	c.m.Lock()
+	c.listeners[t] = d
+	c.m.Unlock()
+
+	return t, nil
+}
+
+// RemoveTelemetryListener removes the telemetry listener associated with the
+// given token.
+func (c *Chassis) RemoveTelemetryListener(t token.Token) error {
+	c.m.Lock()
+	d, ok := c.listeners[t]
+	delete(c.listeners, t)
+	c.m.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no telemetry listener registered with token %d", t)
+	}
+
+	d.Stop()
+
+	return nil
+}
+
+// Motor returns the latest motor information for the given wheel.
+func (c *Chassis) Motor(w Wheel) (MotorInfo, error) {
+	if !w.Valid() {
+		return MotorInfo{}, fmt.Errorf("invalid wheel: %d", w)
+	}
+
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.motors[w], nil
+}
+
+// Motors returns the latest motor information for all wheels (indexed by
+// Wheel).
+func (c *Chassis) Motors() [WheelCount]MotorInfo {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return c.motors
+}
+
+// defaultMotorListenerOptions are the options used for motor listeners when
+// none are given. Each wheel reports separately so the oldest reports are
+// dropped (instead of coalesced) for a slow listener.
+var defaultMotorListenerOptions = dispatcher.Options{
+	QueueSize:      64,
+	OverflowPolicy: dispatcher.OverflowPolicyDropOldest,
+}
+
+// AddMotorListener adds a listener that is called (in order) with the motor
+// information of a wheel whenever its ESC reports it. If o is nil, the oldest
+// reports are dropped for a listener that does not keep up. Note that using
+// dispatcher.OverflowPolicyBlock makes a slow listener delay motor updates
+// for everybody. It returns a token that can be used to remove the listener.
+func (c *Chassis) AddMotorListener(f func(MotorInfo),
+	o *dispatcher.Options) (token.Token, error) {
+	if f == nil {
+		return 0, fmt.Errorf("listener must not be nil")
+	}
+
+	if o == nil {
+		o = &defaultMotorListenerOptions
+	}
+
+	d, err := dispatcher.New(o, f)
+	if err != nil {
+		return 0, err
+	}
+
+	t := c.tg.Next()
+
+	c.m.Lock()
+	c.motorListeners[t] = d
+	c.m.Unlock()
+
+	return t, nil
+}
+
+// RemoveMotorListener removes the motor listener associated with the given
+// token.
+func (c *Chassis) RemoveMotorListener(t token.Token) error {
+	c.m.Lock()
+	d, ok := c.motorListeners[t]
+	delete(c.motorListeners, t)
+	c.m.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no motor listener registered with token %d", t)
+	}
+
+	d.Stop()
+
+	return nil
+}
+
+// ESCFirmwareVersions returns the firmware versions of the wheel ESCs
+// (indexed by Wheel).
+func (c *Chassis) ESCFirmwareVersions() ([WheelCount]string, error) {
+	return c.ESCFirmwareVersionsContext(context.Background())
+}
+
+// ESCFirmwareVersionsContext is like ESCFirmwareVersions but honors the given
+// context.
+func (c *Chassis) ESCFirmwareVersionsContext(
+	ctx context.Context) ([WheelCount]string, error) {
+	var versions [WheelCount]string
+	for w, r := range c.escFirmware {
+		v, err := r.Get(ctx)
+		if err != nil {
+			return versions, fmt.Errorf("error getting %s ESC firmware "+
+				"version: %w", Wheel(w), err)
+		}
+
+		versions[w] = v.Value
+	}
+
+	return versions, nil
+}
+
+// Limits returns the configured chassis motion limits.
+func (c *Chassis) Limits() (Limits, error) {
+	return c.LimitsContext(context.Background())
+}
+
+// LimitsContext is like Limits but honors the given context.
+func (c *Chassis) LimitsContext(ctx context.Context) (Limits, error) {
+	var l Limits
+	for i, f := range l.fields() {
+		v, err := c.limits[i].Get(ctx)
+		if err != nil {
+			return Limits{}, err
+		}
+
+		*f = v.Value
+	}
+
+	return l, nil
+}
+
+// SetLimits sets the chassis motion limits. All limits are validated before
+// any of them is set. Each limit is set individually so, if setting any of
+// them fails, the ones already set are restored to their previous values.
+func (c *Chassis) SetLimits(l Limits) error {
+	return c.SetLimitsContext(context.Background(), l)
+}
+
+// SetLimitsContext is like SetLimits but honors the given context.
+func (c *Chassis) SetLimitsContext(ctx context.Context, l Limits) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	previous, err := c.LimitsContext(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading current limits: %w", err)
+	}
+
+	previousFields := previous.fields()
+
+	for i, f := range l.fields() {
+		err := c.limits[i].Set(ctx, value.Float64{Value: *f})
+		if err != nil {
+			// The given context might be done already, so restore the limits
+			// already set using a new one.
+			for j := 0; j < i; j++ {
+				restoreErr := c.limits[j].Set(context.Background(),
+					value.Float64{Value: *previousFields[j]})
+				if restoreErr != nil {
+					err = errors.Join(err, fmt.Errorf("error restoring %s: %w",
+						limitsKeys[j], restoreErr))
+				}
+			}
+
+			return fmt.Errorf("error setting %s: %w", limitsKeys[i], err)
+		}
+	}
+
+	return nil
+}
+
+// StartRecording starts recording the chassis drive path (route) using the
+// current record settings (see RecordSettings). Any route previously recorded
+// to the same slot is replaced. The settings can not be changed as the known
+// key for that is read-only.
+//
+// Experimental: Route recording is built on key value layouts that were not
+// verified with a robot. It might not work and might change or be removed.
+func (c *Chassis) StartRecording() error {
+	return c.StartRecordingContext(context.Background())
+}
+
+// StartRecordingContext is like StartRecording but honors the given context.
+func (c *Chassis) StartRecordingContext(ctx context.Context) error {
+	return c.recordState.Set(ctx, value.Uint64{Value: 1})
+}
+
+// StopRecording stops recording the chassis drive path.
+//
+// Experimental: See StartRecording.
+func (c *Chassis) StopRecording() error {
+	return c.StopRecordingContext(context.Background())
+}
+
+// StopRecordingContext is like StopRecording but honors the given context.
+func (c *Chassis) StopRecordingContext(ctx context.Context) error {
+	return c.recordState.Set(ctx, value.Uint64{Value: 0})
+}
+
+// IsRecording returns true if the chassis drive path is being recorded.
+//
+// Experimental: See StartRecording.
+func (c *Chassis) IsRecording() (bool, error) {
+	return c.IsRecordingContext(context.Background())
+}
+
+// IsRecordingContext is like IsRecording but honors the given context.
+func (c *Chassis) IsRecordingContext(ctx context.Context) (bool, error) {
+	v, err := c.recordState.Get(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return v.Value != 0, nil
+}
+
+// RecordSettings returns the current route recording settings.
+//
+// Experimental: See StartRecording.
+func (c *Chassis) RecordSettings() (RecordSettings, error) {
+	return c.RecordSettingsContext(context.Background())
+}
+
+// RecordSettingsContext is like RecordSettings but honors the given context.
+func (c *Chassis) RecordSettingsContext(
+	ctx context.Context) (RecordSettings, error) {
+	v, err := c.recordSetting.Get(ctx)
+	if err != nil {
+		return RecordSettings{}, err
+	}
+
+	return RecordSettings{
+		Slot:           v.Index,
+		MaxDuration:    time.Duration(v.MaxDuration) * time.Second,
+		SampleInterval: time.Duration(v.SampleInterval) * time.Millisecond,
+	}, nil
+}
+
+// PlaybackSettings returns the current route playback settings.
+//
+// Experimental: See StartRecording.
+func (c *Chassis) PlaybackSettings() (PlaybackSettings, error) {
+	return c.PlaybackSettingsContext(context.Background())
+}
+
+// PlaybackSettingsContext is like PlaybackSettings but honors the given
+// context.
+func (c *Chassis) PlaybackSettingsContext(
+	ctx context.Context) (PlaybackSettings, error) {
+	v, err := c.playRecordSetting.Get(ctx)
+	if err != nil {
+		return PlaybackSettings{}, err
+	}
+
+	return PlaybackSettings{
+		Slot:    v.Index,
+		Speed:   v.Speed,
+		Repeats: v.Repeat,
+	}, nil
+}
+
+// PlayRoute plays back the route recorded to the slot in the given settings.
+// It blocks until the playback completes, calling f (if not nil) with the
+// playback progress whenever the robot reports it.
+//
+// Experimental: See StartRecording.
+func (c *Chassis) PlayRoute(s PlaybackSettings,
+	f func(PlaybackProgress)) error {
+	return c.PlayRouteContext(context.Background(), s, f)
+}
+
+// PlayRouteContext is like PlayRoute but honors the given context. If the
+// context is done before the playback completes, the playback is stopped.
+func (c *Chassis) PlayRouteContext(ctx context.Context, s PlaybackSettings,
+	f func(PlaybackProgress)) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	err := c.setPlayRecord.Set(ctx, value.PlayRecordSetting{
+		Index:  s.Slot,
+		Speed:  s.Speed,
+		Repeat: s.Repeats,
+	})
+	if err != nil {
+		return err
+	}
+
+	var m sync.Mutex
+	started := false
+	done := make(chan PlaybackState, 1)
+
+	t, err := c.playRecordAttr.Watch(func(v value.PlayRecordAttr) {
+		if v.Index != s.Slot {
 			return
 		}
 
-		if connectedValue.Value {
-			c.Logger().Debug("Connected.")
-		} else {
-			c.Logger().Debug("Disconnected.")
+		p := PlaybackProgress{
+			Slot:    v.Index,
+			State:   PlaybackState(v.State),
+			Percent: v.Percent,
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		// Ignore any state (including the current one) reported before this
+		// playback started.
+		if !started {
+			if p.State != PlaybackStatePlaying {
+				return
+			}
+
+			started = true
+		}
+
+		if f != nil {
+			f(p)
+		}
+
+		if p.State == PlaybackStateDone || p.State == PlaybackStateFailed {
+			select {
+			case done <- p.State:
+			default:
+			}
 		}
 	})
+	if err != nil {
+		return err
+	}
 
-	return c, nil
+	defer c.playRecordAttr.Unwatch(t)
+
+	err = c.playRecordAttr.Set(ctx, value.PlayRecordAttr{
+		Index: s.Slot,
+		State: uint8(PlaybackStatePlaying),
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case state := <-done:
+		if state == PlaybackStateFailed {
+			return fmt.Errorf("route playback from slot %d failed", s.Slot)
+		}
+
+		return nil
+	case <-ctx.Done():
+		if err := c.StopPlayback(); err != nil {
+			c.Logger().Error("Error stopping route playback", "error", err)
+		}
+
+		return fmt.Errorf("error waiting for route playback: %w", ctx.Err())
+	}
+}
+
+// StopPlayback stops any ongoing route playback.
+//
+// Experimental: See StartRecording.
+func (c *Chassis) StopPlayback() error {
+	return c.StopPlaybackContext(context.Background())
+}
+
+// StopPlaybackContext is like StopPlayback but honors the given context.
+func (c *Chassis) StopPlaybackContext(ctx context.Context) error {
+	return c.playRecordAttr.Set(ctx, value.PlayRecordAttr{
+		State: uint8(PlaybackStateIdle),
+	})
+}
+
+// Stop stops the chassis module. All listeners are removed even if removing
+// some of them fails, in which case the errors are returned.
+func (c *Chassis) Stop() error {
+	err := c.removeKeyListeners()
+
+	c.m.Lock()
+	for t, d := range c.listeners {
+		d.Stop()
+		delete(c.listeners, t)
+	}
+	for t, d := range c.motorListeners {
+		d.Stop()
+		delete(c.motorListeners, t)
+	}
+	c.m.Unlock()
+
+	return errors.Join(err, c.BaseModule.Stop())
 }
 
 // SetMode sets the chassis mode for the robot.
@@ -64,8 +738,8 @@ func (c *Chassis) SetMode(m Mode) error {
 		return fmt.Errorf("invalid mode: %d", m)
 	}
 
-	// TODO(bga): Figure out this value.
-	value := uint64(1) | uint64(140) | uint64(17920) | uint64(235929600)
+	// Enable movement with zero speeds.
+	value := control.ChassisSpeed{Enabled: true}.Encode()
 
 	defer func() {
 		// Stop movement after 0.3 seconds. Most likelly to give enough
@@ -83,45 +757,149 @@ func (c *Chassis) StopMovement(m Mode) error {
 		return fmt.Errorf("invalid mode: %d", m)
 	}
 
-	// TODO(bga): Figure out this value.
-	value := uint64(0) | uint64(140) | uint64(17920) | uint64(235929600)
+	// Disable movement (with zero speeds).
+	value := control.ChassisSpeed{}.Encode()
 
-	return c.control(m, value)
+	err := c.control(m, value)
+	if err != nil {
+		return err
+	}
+
+	c.commandListeners.Notify(MotionCommand{Mode: m})
+
+	return nil
 }
 
 // SetSpeed sets the chassis speed. Limits are [-3.5, 3.5] (m/s) for x and y and
 // [-360, 360] (degrees/s) for z.
 func (c *Chassis) SetSpeed(m Mode, x, y, z float64) error {
-	if x > 3.5 || x < -3.5 || y > 3.5 || y < -3.5 || z > 360 || z < -360 {
-		return fmt.Errorf("invalid speed values: x=%f, y=%f, z=%f", x, y, z)
+	cs := control.ChassisSpeed{
+		Enabled: true,
+		X:       x,
+		Y:       y,
+		Z:       z,
 	}
 
-	xComponent := (int64(x*10) + 35) << 2
-	yComponent := (int64(y*10) + 35) << 9
-	zComponent := (int64(z*10) + 3600) << 16
+	if err := cs.Validate(); err != nil {
+		return err
+	}
 
-	value := uint64(1 | xComponent | yComponent | zComponent)
+	err := c.control(m, cs.Encode())
+	if err != nil {
+		return err
+	}
 
-	return c.control(m, value)
+	c.commandListeners.Notify(MotionCommand{
+		Mode:   m,
+		Moving: x != 0 || y != 0 || z != 0,
+	})
+
+	return nil
 }
 
-// SetPosition sets the chassis position.
+// SetWheelSpeed sets the speed of each wheel in RPM. Positive values move the
+// robot forward. Limits are [-1000, 1000] for all wheels.
+func (c *Chassis) SetWheelSpeed(frontRight, frontLeft, rearLeft,
+	rearRight int16) error {
+	return c.SetWheelSpeedContext(context.Background(), frontRight, frontLeft,
+		rearLeft, rearRight)
+}
+
+// SetWheelSpeedContext is like SetWheelSpeed but honors the given context.
+func (c *Chassis) SetWheelSpeedContext(ctx context.Context, frontRight,
+	frontLeft, rearLeft, rearRight int16) error {
+	for _, rpm := range []int16{frontRight, frontLeft, rearLeft, rearRight} {
+		if rpm > 1000 || rpm < -1000 {
+			return fmt.Errorf("invalid wheel speed values: frontRight=%d, "+
+				"frontLeft=%d, rearLeft=%d, rearRight=%d", frontRight,
+				frontLeft, rearLeft, rearRight)
+		}
+	}
+
+	err := c.wheelSpeed.Set(ctx, value.WheelSpeed{
+		FrontRight: frontRight,
+		FrontLeft:  frontLeft,
+		RearLeft:   rearLeft,
+		RearRight:  rearRight,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.commandListeners.Notify(MotionCommand{
+		Mode: ModeNone,
+		Moving: frontRight != 0 || frontLeft != 0 || rearLeft != 0 ||
+			rearRight != 0,
+	})
+
+	return nil
+}
+
+// AddMotionCommandListener adds a listener that is called whenever a
+// continuous motion command (one that keeps the chassis moving until another
+// command is sent, like SetSpeed) is sent to the robot. The listener is called
+// synchronously from the goroutine sending the command so it must not block.
+// Returns a token that can be used to remove the listener.
+func (c *Chassis) AddMotionCommandListener(
+	f func(MotionCommand)) (token.Token, error) {
+	return c.commandListeners.Add(f)
+}
+
+// RemoveMotionCommandListener removes the motion command listener associated
+// with the given token.
+func (c *Chassis) RemoveMotionCommandListener(t token.Token) error {
+	return c.commandListeners.Remove(t)
+}
+
+// SetPosition sets the chassis position (relative to its current position).
+// It returns as soon as the robot accepts the request (use SetPositionTask to
+// track the move).
 func (c *Chassis) SetPosition(m Mode, x, y, z float64) error {
-	// TODO(bga): We need to implement task id handling for this.
+	return c.SetPositionContext(context.Background(), m, x, y, z)
+}
+
+// SetPositionContext is like SetPosition but honors the given context.
+func (c *Chassis) SetPositionContext(ctx context.Context,
+	m Mode, x, y, z float64) error {
+	_, err := c.SetPositionTask(ctx, m, x, y, z)
+
+	return err
+}
+
+// SetPositionTask is like SetPositionContext but returns a task handle that
+// can be used to wait for the move to complete, track its progress or cancel
+// it.
+func (c *Chassis) SetPositionTask(ctx context.Context, m Mode, x, y,
+	z float64) (*robot.Task, error) {
+	if c.rm == nil {
+		return nil, fmt.Errorf("chassis position tasks require a robot " +
+			"module")
+	}
 
 	var controlMode uint8
 	if m == ModeYawFollow {
 		controlMode = 1
 	}
 
-	return c.UB().PerformActionForKeySync(key.KeyMainControllerChassisPosition, &value.ChassisPosition{
-		TaskType:    task.TypeChassisPosition,
-		IsCancel:    0,
-		ControlMode: controlMode,
-		X:           float32(x),
-		Y:           float32(y),
-		Z:           float32(z),
-	})
+	return c.rm.StartTask(ctx, task.TypeChassisPosition,
+		func(ctx context.Context) error {
+			return c.UB().PerformActionForKeySyncContext(ctx,
+				key.KeyMainControllerChassisPosition, &value.ChassisPosition{
+					TaskType:    task.TypeChassisPosition,
+					IsCancel:    0,
+					ControlMode: controlMode,
+					X:           float32(x),
+					Y:           float32(y),
+					Z:           float32(z),
+				})
+		}, func(ctx context.Context) error {
+			return c.UB().PerformActionForKeySyncContext(ctx,
+				key.KeyMainControllerChassisPosition, &value.ChassisPosition{
+					TaskType:    task.TypeChassisPosition,
+					IsCancel:    1,
+					ControlMode: controlMode,
+				})
+		})
 }
 
 func (c *Chassis) control(m Mode, value uint64) error {
@@ -138,3 +916,136 @@ func (c *Chassis) control(m Mode, value uint64) error {
 
 	return c.UB().DirectSendKeyValue(k, value)
 }
+
+func (c *Chassis) onSpeed(r *result.Result) {
+	if r == nil || !r.Succeeded() {
+		c.Logger().Error("Error getting chassis speed", "result", r)
+		return
+	}
+
+	value, ok := r.Value().(*value.ChassisSpeed)
+	if !ok {
+		c.Logger().Error("Unexpected result value", "key", r.Key(), "value",
+			r.Value())
+		return
+	}
+
+	c.updateTelemetry(func(t *Telemetry, now time.Time) {
+		t.Velocity = Velocity{
+			X:    float64(value.X),
+			Y:    float64(value.Y),
+			Yaw:  float64(value.Z),
+			Time: now,
+		}
+	})
+}
+
+func (c *Chassis) onPosition(r *result.Result) {
+	if r == nil || !r.Succeeded() {
+		c.Logger().Error("Error getting chassis position", "result", r)
+		return
+	}
+
+	value, ok := r.Value().(*value.ChassisRelativePosition)
+	if !ok {
+		c.Logger().Error("Unexpected result value", "key", r.Key(), "value",
+			r.Value())
+		return
+	}
+
+	c.updateTelemetry(func(t *Telemetry, now time.Time) {
+		t.Position = Position{
+			X:       float64(value.X),
+			Y:       float64(value.Y),
+			Heading: float64(value.Z),
+			Time:    now,
+		}
+	})
+}
+
+func (c *Chassis) onAttitude(r *result.Result) {
+	if r == nil || !r.Succeeded() {
+		c.Logger().Error("Error getting chassis attitude", "result", r)
+		return
+	}
+
+	value, ok := r.Value().(*value.AttitudeInfo)
+	if !ok {
+		c.Logger().Error("Unexpected result value", "key", r.Key(), "value",
+			r.Value())
+		return
+	}
+
+	c.updateTelemetry(func(t *Telemetry, now time.Time) {
+		t.Attitude = Attitude{
+			Pitch: float64(value.Pitch),
+			Roll:  float64(value.Roll),
+			Yaw:   float64(value.Yaw),
+			Time:  now,
+		}
+	})
+}
+
+// onMotorInfo returns a callback that handles motor information for the given
+// wheel.
+func (c *Chassis) onMotorInfo(w Wheel) result.Callback {
+	return func(r *result.Result) {
+		if r == nil || !r.Succeeded() {
+			c.Logger().Error("Error getting motor information", "wheel", w,
+				"result", r)
+			return
+		}
+
+		value, ok := r.Value().(*value.ESCMotorInfo)
+		if !ok {
+			c.Logger().Error("Unexpected result value", "key", r.Key(),
+				"value", r.Value())
+			return
+		}
+
+		mi := MotorInfo{
+			Wheel:     w,
+			Speed:     value.Speed,
+			Angle:     value.Angle,
+			TimeStamp: value.TimeStamp,
+			State:     value.State,
+			Time:      time.Now(),
+		}
+
+		c.m.Lock()
+
+		c.motors[w] = mi
+
+		ds := make([]*dispatcher.Dispatcher[MotorInfo], 0,
+			len(c.motorListeners))
+		for _, d := range c.motorListeners {
+			ds = append(ds, d)
+		}
+
+		c.m.Unlock()
+
+		for _, d := range ds {
+			d.Dispatch(mi)
+		}
+	}
+}
+
+// updateTelemetry updates the telemetry with the given function and notifies
+// listeners about the new telemetry snapshot.
+func (c *Chassis) updateTelemetry(f func(t *Telemetry, now time.Time)) {
+	c.m.Lock()
+
+	f(&c.telemetry, time.Now())
+	t := c.telemetry
+
+	ds := make([]*dispatcher.Dispatcher[Telemetry], 0, len(c.listeners))
+	for _, d := range c.listeners {
+		ds = append(ds, d)
+	}
+
+	c.m.Unlock()
+
+	for _, d := range ds {
+		d.Dispatch(t)
+	}
+}
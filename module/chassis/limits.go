@@ -0,0 +1,91 @@
+package chassis
+
+import (
+	"fmt"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+)
+
+// Limits ranges. These (and the units below) are unverified assumptions. They
+// were not checked against a robot, so values accepted by Validate might
+// still be rejected (or interpreted differently) by it.
+const (
+	MaxSpeedLimit = 3.5  // m/s.
+	MaxSlopeLimit = 10.0 // m/s².
+)
+
+// Limits are the chassis motion limits. Speeds limit how fast the chassis
+// moves in each direction and slopes limit how fast it accelerates (Slope)
+// and decelerates (SlopeBreak) along each axis.
+//
+// The underlying keys are assumed to hold floating point values in the units
+// documented below. Neither the type nor the units were confirmed yet.
+type Limits struct {
+	MaxSpeedForward  float64 // m/s, (0, 3.5].
+	MaxSpeedBackward float64 // m/s, (0, 3.5].
+	MaxSpeedLateral  float64 // m/s, (0, 3.5].
+
+	SlopeX      float64 // Forward/backward acceleration, m/s², (0, 10].
+	SlopeY      float64 // Lateral acceleration, m/s², (0, 10].
+	SlopeBreakX float64 // Forward/backward deceleration, m/s², (0, 10].
+	SlopeBreakY float64 // Lateral deceleration, m/s², (0, 10].
+}
+
+// Validate returns an error if any of the limits is out of range.
+func (l Limits) Validate() error {
+	for _, s := range []struct {
+		name  string
+		value float64
+	}{
+		{"max forward speed", l.MaxSpeedForward},
+		{"max backward speed", l.MaxSpeedBackward},
+		{"max lateral speed", l.MaxSpeedLateral},
+	} {
+		if s.value <= 0 || s.value > MaxSpeedLimit {
+			return fmt.Errorf("invalid %s %f, should be in the (0, %.1f] "+
+				"m/s range", s.name, s.value, MaxSpeedLimit)
+		}
+	}
+
+	for _, s := range []struct {
+		name  string
+		value float64
+	}{
+		{"x slope", l.SlopeX},
+		{"y slope", l.SlopeY},
+		{"x slope break", l.SlopeBreakX},
+		{"y slope break", l.SlopeBreakY},
+	} {
+		if s.value <= 0 || s.value > MaxSlopeLimit {
+			return fmt.Errorf("invalid %s %f, should be in the (0, %.1f] "+
+				"m/s² range", s.name, s.value, MaxSlopeLimit)
+		}
+	}
+
+	return nil
+}
+
+// fields returns pointers to all limits in the same order as limitsKeys.
+func (l *Limits) fields() []*float64 {
+	return []*float64{
+		&l.MaxSpeedForward,
+		&l.MaxSpeedBackward,
+		&l.MaxSpeedLateral,
+		&l.SlopeX,
+		&l.SlopeY,
+		&l.SlopeBreakX,
+		&l.SlopeBreakY,
+	}
+}
+
+// limitsKeys are the (configuration) keys for all limits in the same order as
+// Limits.fields.
+var limitsKeys = []*key.Key{
+	key.KeyMainControllerMaxSpeedForwardConfig,
+	key.KeyMainControllerMaxSpeedBackwardConfig,
+	key.KeyMainControllerMaxSpeedLateralConfig,
+	key.KeyMainControllerSlopSpeedXConfig,
+	key.KeyMainControllerSlopSpeedYConfig,
+	key.KeyMainControllerSlopBreakXConfig,
+	key.KeyMainControllerSlopBreakYConfig,
+}
@@ -0,0 +1,8 @@
+package chassis
+
+// MotionCommand describes a continuous motion command (one that keeps the
+// chassis moving until another command is sent) sent to the robot.
+type MotionCommand struct {
+	Mode   Mode // Mode the command was sent with (ModeNone for wheel speeds).
+	Moving bool // Whether the command makes the chassis move.
+}
@@ -0,0 +1,113 @@
+package chassis
+
+import (
+	"fmt"
+	"time"
+)
+
+// Route recording limits. They are not verified with a robot.
+//
+// Experimental: Route recording and playback might change or be removed (see
+// Chassis.StartRecording).
+const (
+	MaxRouteSlot       = 9
+	MaxRouteDuration   = 10 * time.Minute
+	MinSampleInterval  = 10 * time.Millisecond
+	MaxPlaybackSpeed   = 4.0
+	MaxPlaybackRepeats = 100
+)
+
+// RecordSettings are the settings used when recording a drive path (route).
+//
+// Experimental: See Chassis.StartRecording.
+type RecordSettings struct {
+	Slot           uint8         // Slot the route is recorded to ([0, 9]).
+	MaxDuration    time.Duration // Recording stops automatically after this.
+	SampleInterval time.Duration // How often the chassis pose is sampled.
+}
+
+// Validate returns an error if any of the settings is out of range.
+func (s RecordSettings) Validate() error {
+	if s.Slot > MaxRouteSlot {
+		return fmt.Errorf("invalid slot %d, should be between 0 and %d",
+			s.Slot, MaxRouteSlot)
+	}
+
+	if s.MaxDuration < time.Second || s.MaxDuration > MaxRouteDuration {
+		return fmt.Errorf("invalid max duration %s, should be between 1s "+
+			"and %s", s.MaxDuration, MaxRouteDuration)
+	}
+
+	if s.SampleInterval < MinSampleInterval ||
+		s.SampleInterval > s.MaxDuration {
+		return fmt.Errorf("invalid sample interval %s, should be between %s "+
+			"and the max duration", s.SampleInterval, MinSampleInterval)
+	}
+
+	return nil
+}
+
+// PlaybackSettings are the settings used when playing back a recorded route.
+//
+// Experimental: See Chassis.StartRecording.
+type PlaybackSettings struct {
+	Slot    uint8   // Slot the route is played from ([0, 9]).
+	Speed   float64 // Speed multiplier ((0, 4]).
+	Repeats uint8   // Number of additional times to play ([0, 100]).
+}
+
+// Validate returns an error if any of the settings is out of range.
+func (s PlaybackSettings) Validate() error {
+	if s.Slot > MaxRouteSlot {
+		return fmt.Errorf("invalid slot %d, should be between 0 and %d",
+			s.Slot, MaxRouteSlot)
+	}
+
+	if s.Speed <= 0 || s.Speed > MaxPlaybackSpeed {
+		return fmt.Errorf("invalid speed %f, should be in the (0, %.0f] "+
+			"range", s.Speed, MaxPlaybackSpeed)
+	}
+
+	if s.Repeats > MaxPlaybackRepeats {
+		return fmt.Errorf("invalid repeats %d, should be between 0 and %d",
+			s.Repeats, MaxPlaybackRepeats)
+	}
+
+	return nil
+}
+
+// PlaybackState is the state of a route playback.
+//
+// Experimental: See Chassis.StartRecording.
+type PlaybackState uint8
+
+const (
+	PlaybackStateIdle PlaybackState = iota
+	PlaybackStatePlaying
+	PlaybackStateDone
+	PlaybackStateFailed
+)
+
+func (s PlaybackState) String() string {
+	switch s {
+	case PlaybackStateIdle:
+		return "Idle"
+	case PlaybackStatePlaying:
+		return "Playing"
+	case PlaybackStateDone:
+		return "Done"
+	case PlaybackStateFailed:
+		return "Failed"
+	default:
+		return fmt.Sprintf("Unknown(%d)", s)
+	}
+}
+
+// PlaybackProgress is the progress of a route playback.
+//
+// Experimental: See Chassis.StartRecording.
+type PlaybackProgress struct {
+	Slot    uint8
+	State   PlaybackState
+	Percent float64
+}
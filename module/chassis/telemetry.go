@@ -0,0 +1,46 @@
+package chassis
+
+import (
+	"time"
+)
+
+// Position is the chassis position relative to where the robot was when it
+// was turned on.
+type Position struct {
+	X       float64 // Meters.
+	Y       float64 // Meters.
+	Heading float64 // Degrees.
+
+	// Time is when the position was received (zero if it was never
+	// received).
+	Time time.Time
+}
+
+// Velocity is the chassis velocity relative to its own heading.
+type Velocity struct {
+	X   float64 // Forward, m/s.
+	Y   float64 // Right, m/s.
+	Yaw float64 // Degrees/s.
+
+	// Time is when the velocity was received (zero if it was never
+	// received).
+	Time time.Time
+}
+
+// Attitude is the chassis attitude.
+type Attitude struct {
+	Pitch float64 // Degrees.
+	Roll  float64 // Degrees.
+	Yaw   float64 // Degrees.
+
+	// Time is when the attitude was received (zero if it was never
+	// received).
+	Time time.Time
+}
+
+// Telemetry is a snapshot of all the chassis telemetry data.
+type Telemetry struct {
+	Position Position
+	Velocity Velocity
+	Attitude Attitude
+}
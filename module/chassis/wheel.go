@@ -0,0 +1,52 @@
+package chassis
+
+import (
+	"fmt"
+	"time"
+)
+
+// Wheel identifies one of the chassis wheels. The order matches the ESC
+// numbering used by the robot.
+type Wheel uint8
+
+const (
+	WheelFrontRight Wheel = iota
+	WheelFrontLeft
+	WheelRearLeft
+	WheelRearRight
+	// WheelCount is the number of wheels.
+	WheelCount
+)
+
+func (w Wheel) String() string {
+	switch w {
+	case WheelFrontRight:
+		return "FrontRight"
+	case WheelFrontLeft:
+		return "FrontLeft"
+	case WheelRearLeft:
+		return "RearLeft"
+	case WheelRearRight:
+		return "RearRight"
+	default:
+		return fmt.Sprintf("Unknown(%d)", w)
+	}
+}
+
+func (w Wheel) Valid() bool {
+	return w < WheelCount
+}
+
+// MotorInfo is the information reported by the ESC of a wheel motor.
+type MotorInfo struct {
+	Wheel Wheel
+
+	Speed     int16  // RPM. Positive values move the robot forward.
+	Angle     uint16 // Encoder position ([0, 32767] for a full revolution).
+	TimeStamp uint32 // ESC timestamp in milliseconds.
+	State     uint8  // ESC state (0 is normal).
+
+	// Time is when the information was received (zero if it was never
+	// received).
+	Time time.Time
+}
@@ -1,6 +1,8 @@
 package connection
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net"
 	"sync/atomic"
@@ -25,6 +27,10 @@ const (
 	subTypeConnectionSetPort
 
 	wifiDirectIPString = "192.168.2.1"
+
+	// findTimeout is how long Start waits for a robot to be found when
+	// connecting through a router.
+	findTimeout = 30 * time.Second
 )
 
 // Connection provides support for managing the connection to the robot.
@@ -109,6 +115,12 @@ func New(ub unitybridge.UnityBridge,
 // Start starts the connection module. It will try to find a robot broadcasting
 // in the network and connect to it.
 func (c *Connection) Start() error {
+	return c.StartContext(context.Background())
+}
+
+// StartContext is like Start but gives up looking for a robot when the given
+// context is done. If the context has no deadline, findTimeout applies.
+func (c *Connection) StartContext(ctx context.Context) error {
 	err := c.BaseModule.Start()
 	if err != nil {
 		return err
@@ -116,9 +128,15 @@ func (c *Connection) Start() error {
 
 	var ip net.IP = net.ParseIP(wifiDirectIPString)
 	if c.typ == TypeRouter {
-		b, err := c.f.Find(30 * time.Second)
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, findTimeout)
+			defer cancel()
+		}
+
+		b, err := c.f.FindContext(ctx)
 		if err != nil {
-			return err
+			return fmt.Errorf("error finding robot: %w", err)
 		}
 
 		c.f.SendACK(b.SourceIp(), b.AppId())
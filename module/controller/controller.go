@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 
@@ -8,7 +9,9 @@ import (
 	"github.com/brunoga/robomaster/module/connection"
 	"github.com/brunoga/robomaster/module/internal"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge"
+	"github.com/brunoga/robomaster/unitybridge/unity/control"
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
 	"github.com/brunoga/robomaster/unitybridge/unity/result"
 	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
@@ -18,6 +21,8 @@ import (
 // movement using the dual stick interface.
 type Controller struct {
 	*internal.BaseModule
+
+	commandListeners *internal.CommandListeners[MotionCommand]
 }
 
 var _ module.Module = (*Controller)(nil)
@@ -31,7 +36,9 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 
 	l = l.WithGroup("controller_module")
 
-	c := &Controller{}
+	c := &Controller{
+		commandListeners: internal.NewCommandListeners[MotionCommand](),
+	}
 
 	c.BaseModule = internal.NewBaseModule(ub, l, "Controller",
 		key.KeyMainControllerConnection, func(r *result.Result) {
@@ -58,11 +65,17 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 
 // SetMode sets the controller mode for the robot.
 func (c *Controller) SetMode(m Mode) error {
+	return c.SetModeContext(context.Background(), m)
+}
+
+// SetModeContext is like SetMode but honors the given context.
+func (c *Controller) SetModeContext(ctx context.Context, m Mode) error {
 	if !m.Valid() {
 		return fmt.Errorf("invalid controller mode: %d", m)
 	}
 
-	return c.UB().SetKeyValueSync(key.KeyMainControllerChassisCarControlMode,
+	return c.UB().SetKeyValueSyncContext(ctx,
+		key.KeyMainControllerChassisCarControlMode,
 		&value.Uint64{Value: uint64(m)})
 }
 
@@ -73,23 +86,59 @@ func (c *Controller) Move(chassisStick *StickPosition,
 		return fmt.Errorf("invalid controller mode: %d", m)
 	}
 
-	var leftStickEnabled uint64
-	if chassisStick != nil {
-		leftStickEnabled = 1
+	if !chassisStick.valid() {
+		return fmt.Errorf("invalid chassis stick position: %+v", *chassisStick)
+	}
+
+	if !gimbalStick.valid() {
+		return fmt.Errorf("invalid gimbal stick position: %+v", *gimbalStick)
+	}
+
+	vs := control.VirtualStick{
+		Chassis: control.Stick{
+			X: uint16(chassisStick.InterpolatedX()),
+			Y: uint16(chassisStick.InterpolatedY()),
+		},
+		Gimbal: control.Stick{
+			X: uint16(gimbalStick.InterpolatedX()),
+			Y: uint16(gimbalStick.InterpolatedY()),
+		},
+		ChassisEnabled: chassisStick != nil,
+		GimbalEnabled:  gimbalStick != nil,
+		Mode:           uint8(m),
+	}
+
+	if err := vs.Validate(); err != nil {
+		return err
 	}
 
-	var rightStickEnabled uint64
-	if gimbalStick != nil {
-		rightStickEnabled = 1
+	err := c.UB().DirectSendKeyValue(key.KeyMainControllerVirtualStick,
+		vs.Encode())
+	if err != nil {
+		return err
 	}
 
-	v := chassisStick.InterpolatedY() |
-		chassisStick.InterpolatedX()<<11 |
-		gimbalStick.InterpolatedY()<<22 |
-		gimbalStick.InterpolatedX()<<33 |
-		leftStickEnabled<<44 |
-		rightStickEnabled<<45 |
-		uint64(m)<<46
+	c.commandListeners.Notify(MotionCommand{
+		Mode:   m,
+		Moving: chassisStick.moving() || gimbalStick.moving(),
+	})
+
+	return nil
+}
+
+// AddMotionCommandListener adds a listener that is called whenever Move is
+// used to send stick positions to the robot. The listener is called with the
+// mode the command was sent with and whether the stick positions make the
+// robot move. It is called synchronously from the goroutine sending the
+// command so it must not block. Returns a token that can be used to remove the
+// listener.
+func (c *Controller) AddMotionCommandListener(
+	f func(MotionCommand)) (token.Token, error) {
+	return c.commandListeners.Add(f)
+}
 
-	return c.UB().DirectSendKeyValue(key.KeyMainControllerVirtualStick, v)
+// RemoveMotionCommandListener removes the motion command listener associated
+// with the given token.
+func (c *Controller) RemoveMotionCommandListener(t token.Token) error {
+	return c.commandListeners.Remove(t)
 }
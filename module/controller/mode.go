@@ -26,5 +26,5 @@ func (m Mode) String() string {
 }
 
 func (m Mode) Valid() bool {
-	return m <= modeCount
+	return m < modeCount
 }
@@ -0,0 +1,7 @@
+package controller
+
+// MotionCommand describes stick positions sent to the robot with Move.
+type MotionCommand struct {
+	Mode   Mode // Mode the command was sent with.
+	Moving bool // Whether the stick positions make the robot move.
+}
@@ -33,6 +33,16 @@ func (s *StickPosition) InterpolatedY() uint64 {
 		offset)
 }
 
+// valid returns true if both stick axis are in the [-1, 1] range.
+func (s *StickPosition) valid() bool {
+	return s == nil || (s.X >= -1 && s.X <= 1 && s.Y >= -1 && s.Y <= 1)
+}
+
+// moving returns true if the stick is enabled and not centered.
+func (s *StickPosition) moving() bool {
+	return s != nil && (s.X != 0 || s.Y != 0)
+}
+
 func lerp(a, b, t float64) float64 {
 	return a + (b-a)*t
 }
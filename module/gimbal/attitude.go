@@ -0,0 +1,24 @@
+package gimbal
+
+import (
+	"time"
+)
+
+// Attitude is the gimbal attitude.
+type Attitude struct {
+	Pitch float64 // Degrees.
+	Roll  float64 // Degrees.
+	Yaw   float64 // Degrees, relative to the chassis.
+
+	// YawOpposite is the yaw in the opposite reference frame (relative to
+	// the chassis initial heading instead of to the chassis), in degrees.
+	YawOpposite float64
+
+	PitchSpeed float64 // Degrees/s.
+	RollSpeed  float64 // Degrees/s.
+	YawSpeed   float64 // Degrees/s.
+
+	// Time is when the attitude was received (zero if it was never
+	// received).
+	Time time.Time
+}
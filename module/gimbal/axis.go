@@ -1,5 +1,7 @@
 package gimbal
 
+import "fmt"
+
 type Axis int8
 
 const (
@@ -7,3 +9,15 @@ const (
 	AxisYaw
 	AxisCount
 )
+
+// String returns a human readable representation of the axis.
+func (a Axis) String() string {
+	switch a {
+	case AxisPitch:
+		return "pitch"
+	case AxisYaw:
+		return "yaw"
+	default:
+		return fmt.Sprintf("unknown(%d)", a)
+	}
+}
@@ -0,0 +1,447 @@
+package gimbal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/token"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+var (
+	// ErrCalibrationFailed is returned by Calibration.Wait when the robot
+	// reports the calibration failed.
+	ErrCalibrationFailed = errors.New("gimbal calibration failed")
+
+	// ErrCalibrationCanceled is returned by Calibration.Wait when the
+	// calibration was canceled before the robot reported a result.
+	ErrCalibrationCanceled = errors.New("gimbal calibration canceled")
+)
+
+// calibrationTimeout is how long CalibrateContext waits for a calibration to
+// complete when the given context has no deadline.
+const calibrationTimeout = 2 * time.Minute
+
+// CalibrationType is the type of gimbal calibration.
+type CalibrationType uint8
+
+const (
+	// CalibrationTypeManual is the calibration started through
+	// KeyGimbalCalibration.
+	CalibrationTypeManual CalibrationType = iota
+	// CalibrationTypeAuto is the calibration started through
+	// KeyGimbalAutoCalibrate.
+	CalibrationTypeAuto
+	calibrationTypeCount
+)
+
+// String returns a human readable representation of the calibration type.
+func (ct CalibrationType) String() string {
+	switch ct {
+	case CalibrationTypeManual:
+		return "Manual"
+	case CalibrationTypeAuto:
+		return "Auto"
+	default:
+		return fmt.Sprintf("Unknown(%d)", ct)
+	}
+}
+
+// Valid returns true if the calibration type is valid.
+func (ct CalibrationType) Valid() bool {
+	return ct < calibrationTypeCount
+}
+
+// CalibrationStatus is the status of a gimbal calibration.
+//
+// The status values are unverified assumptions that were not checked against
+// a robot.
+type CalibrationStatus uint8
+
+const (
+	// CalibrationStatusIdle means no calibration is running.
+	CalibrationStatusIdle CalibrationStatus = iota
+	// CalibrationStatusCalibrating means a calibration is running.
+	CalibrationStatusCalibrating
+	// CalibrationStatusSucceeded means the last calibration succeeded.
+	CalibrationStatusSucceeded
+	// CalibrationStatusFailed means the last calibration failed.
+	CalibrationStatusFailed
+)
+
+// String returns a human readable representation of the calibration status.
+func (cs CalibrationStatus) String() string {
+	switch cs {
+	case CalibrationStatusIdle:
+		return "Idle"
+	case CalibrationStatusCalibrating:
+		return "Calibrating"
+	case CalibrationStatusSucceeded:
+		return "Succeeded"
+	case CalibrationStatusFailed:
+		return "Failed"
+	default:
+		return fmt.Sprintf("Unknown(%d)", cs)
+	}
+}
+
+// CalibrationProgress is a progress report for an ongoing gimbal calibration.
+type CalibrationProgress struct {
+	Status  CalibrationStatus
+	Percent uint8 // 0 to 100.
+}
+
+// CalibrationResult is the final result of a gimbal calibration.
+type CalibrationResult struct {
+	Type     CalibrationType
+	Status   CalibrationStatus // Either Succeeded or Failed.
+	Duration time.Duration
+}
+
+// calibrationProgressQueueSize is the number of progress reports that can be
+// pending delivery on a Calibration progress channel.
+const calibrationProgressQueueSize = 128
+
+// Calibration is a handle to an ongoing gimbal calibration.
+type Calibration struct {
+	typ        CalibrationType
+	start      time.Time
+	progressCh chan CalibrationProgress
+	done       chan struct{}
+
+	// cleanup removes the calibration listeners and clears the gimbal
+	// calibration state. It is called once the calibration completes.
+	cleanup func()
+
+	m        sync.Mutex
+	started  bool
+	canceled bool
+	last     CalibrationProgress
+	result   CalibrationResult
+}
+
+// Type returns the calibration type.
+func (c *Calibration) Type() CalibrationType {
+	return c.typ
+}
+
+// Progress returns a channel that receives progress reports while the
+// calibration runs. It is closed when the calibration completes. Reports are
+// dropped if the channel is not drained fast enough.
+func (c *Calibration) Progress() <-chan CalibrationProgress {
+	return c.progressCh
+}
+
+// Done returns a channel that is closed when the calibration completes.
+func (c *Calibration) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait waits for the calibration to complete or for the given context to be
+// done. Returns the calibration result and ErrCalibrationFailed if the robot
+// reported a failure or ErrCalibrationCanceled if it was canceled.
+func (c *Calibration) Wait(ctx context.Context) (CalibrationResult, error) {
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return CalibrationResult{}, fmt.Errorf("error waiting for gimbal "+
+			"calibration: %w", ctx.Err())
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.canceled {
+		return c.result, ErrCalibrationCanceled
+	}
+
+	if c.result.Status != CalibrationStatusSucceeded {
+		return c.result, ErrCalibrationFailed
+	}
+
+	return c.result, nil
+}
+
+// Cancel stops tracking the calibration. Its listeners are removed, the
+// gimbal is not considered to be calibrating anymore and Wait returns
+// ErrCalibrationCanceled. There is no known way to stop a calibration on the
+// robot, so the gimbal itself might still be calibrating. It is a no-op if the
+// calibration already completed.
+func (c *Calibration) Cancel() {
+	c.m.Lock()
+
+	select {
+	case <-c.done:
+		c.m.Unlock()
+		return
+	default:
+	}
+
+	c.canceled = true
+	c.finishLocked(CalibrationStatusFailed)
+
+	c.m.Unlock()
+
+	c.cleanup()
+}
+
+// finishLocked completes the calibration with the given status. Must be
+// called with c.m held.
+func (c *Calibration) finishLocked(status CalibrationStatus) {
+	c.result = CalibrationResult{
+		Type:     c.typ,
+		Status:   status,
+		Duration: time.Since(c.start),
+	}
+
+	close(c.progressCh)
+	close(c.done)
+}
+
+// update updates the calibration with the given function and reports the new
+// progress. Returns true if the calibration completed.
+func (c *Calibration) update(f func(p *CalibrationProgress)) bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	f(&c.last)
+
+	// Ignore any status reported before this calibration started.
+	if !c.started {
+		if c.last.Status != CalibrationStatusCalibrating {
+			return false
+		}
+
+		c.started = true
+	}
+
+	if c.last.Status == CalibrationStatusSucceeded {
+		// A success status is final even if the last progress push was
+		// below 100.
+		c.last.Percent = 100
+	}
+
+	select {
+	case c.progressCh <- c.last:
+	default:
+	}
+
+	if c.last.Status == CalibrationStatusCalibrating {
+		return false
+	}
+
+	status := c.last.Status
+	if status == CalibrationStatusIdle {
+		// Stopped without reporting a result.
+		status = CalibrationStatusFailed
+	}
+
+	c.finishLocked(status)
+
+	return true
+}
+
+// StartCalibration starts a gimbal calibration of the given type and returns
+// a handle to track it. The gimbal must not be moved while it is being
+// calibrated. The calibration must be canceled (see Calibration.Cancel) if it
+// is abandoned before it completes. Only one calibration can run at a time.
+func (g *Gimbal) StartCalibration(ct CalibrationType) (*Calibration, error) {
+	return g.StartCalibrationContext(context.Background(), ct)
+}
+
+// StartCalibrationContext is like StartCalibration but honors the given
+// context while starting the calibration.
+func (g *Gimbal) StartCalibrationContext(ctx context.Context,
+	ct CalibrationType) (*Calibration, error) {
+	if !ct.Valid() {
+		return nil, fmt.Errorf("invalid calibration type: %d", ct)
+	}
+
+	c := &Calibration{
+		typ:        ct,
+		start:      time.Now(),
+		progressCh: make(chan CalibrationProgress, calibrationProgressQueueSize),
+		done:       make(chan struct{}),
+	}
+
+	// Reserve the calibration slot before doing anything else so concurrent
+	// starts can not both succeed.
+	g.m.Lock()
+	if previous := g.calibration; previous != nil {
+		select {
+		case <-previous.Done():
+		default:
+			g.m.Unlock()
+			return nil, fmt.Errorf("gimbal calibration already in progress")
+		}
+	}
+	g.calibration = c
+	g.m.Unlock()
+
+	var tm sync.Mutex
+	var statusToken, progressToken token.Token
+
+	var once sync.Once
+	c.cleanup = func() {
+		once.Do(func() {
+			tm.Lock()
+			defer tm.Unlock()
+
+			if statusToken != 0 {
+				g.UB().RemoveKeyListener(key.KeyGimbalCalibrationStatus,
+					statusToken)
+			}
+
+			if progressToken != 0 {
+				g.UB().RemoveKeyListener(key.KeyGimbalCalibrationProgress,
+					progressToken)
+			}
+
+			g.m.Lock()
+			if g.calibration == c {
+				g.calibration = nil
+			}
+			g.m.Unlock()
+		})
+	}
+
+	onUpdate := func(f func(p *CalibrationProgress)) {
+		if c.update(f) {
+			// Listeners can not be removed from inside a listener.
+			go c.cleanup()
+		}
+	}
+
+	// Only pushes received from now on matter. The status and progress keys
+	// are independent, so a cached status might belong to an earlier
+	// calibration.
+	tm.Lock()
+	var err error
+	statusToken, err = g.watchCalibrationKey(key.KeyGimbalCalibrationStatus,
+		func(v uint64) {
+			onUpdate(func(p *CalibrationProgress) {
+				p.Status = CalibrationStatus(v)
+			})
+		})
+	if err == nil {
+		progressToken, err = g.watchCalibrationKey(
+			key.KeyGimbalCalibrationProgress, func(v uint64) {
+				onUpdate(func(p *CalibrationProgress) {
+					p.Percent = uint8(v)
+				})
+			})
+	}
+	tm.Unlock()
+
+	if err != nil {
+		c.Cancel()
+		return nil, err
+	}
+
+	k := key.KeyGimbalCalibration
+	if ct == CalibrationTypeAuto {
+		k = key.KeyGimbalAutoCalibrate
+	}
+
+	err = g.UB().PerformActionForKeySyncContext(ctx, k, nil)
+	if err != nil {
+		c.Cancel()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// calibrating returns true if a calibration started by this module is still
+// running.
+func (g *Gimbal) calibrating() bool {
+	g.m.Lock()
+	c := g.calibration
+	g.m.Unlock()
+
+	if c == nil {
+		return false
+	}
+
+	select {
+	case <-c.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+// Calibrate starts a gimbal calibration of the given type and waits for it to
+// complete. The given function (which might be nil) is called with progress
+// reports.
+func (g *Gimbal) Calibrate(ct CalibrationType,
+	f func(CalibrationProgress)) (CalibrationResult, error) {
+	return g.CalibrateContext(context.Background(), ct, f)
+}
+
+// CalibrateContext is like Calibrate but honors the given context. If the
+// context is done before the calibration completes, it is canceled (see
+// Calibration.Cancel). If the context has no deadline, calibrationTimeout
+// applies.
+func (g *Gimbal) CalibrateContext(ctx context.Context, ct CalibrationType,
+	f func(CalibrationProgress)) (CalibrationResult, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, calibrationTimeout)
+		defer cancel()
+	}
+
+	c, err := g.StartCalibrationContext(ctx, ct)
+	if err != nil {
+		return CalibrationResult{}, err
+	}
+
+	for {
+		select {
+		case p, ok := <-c.Progress():
+			if !ok {
+				return c.Wait(ctx)
+			}
+
+			if f != nil {
+				f(p)
+			}
+		case <-ctx.Done():
+			c.Cancel()
+
+			return CalibrationResult{}, fmt.Errorf("error waiting for "+
+				"gimbal calibration: %w", ctx.Err())
+		}
+	}
+}
+
+// watchCalibrationKey calls the given function whenever a new value is pushed
+// for the given calibration key.
+func (g *Gimbal) watchCalibrationKey(k *key.Key,
+	f func(uint64)) (token.Token, error) {
+	return g.UB().AddKeyListener(k, func(res *result.Result) {
+		if res == nil || !res.Succeeded() {
+			return
+		}
+
+		v, ok := res.Value().(*value.Uint64)
+		if !ok {
+			g.Logger().Error("Unexpected calibration value.", "key", k,
+				"value", res.Value())
+			return
+		}
+
+		f(v.Value)
+	}, false)
+}
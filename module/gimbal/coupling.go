@@ -0,0 +1,225 @@
+package gimbal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brunoga/robomaster/module/chassis"
+)
+
+// Coupling describes how the gimbal and the chassis move in relation to each
+// other. Each coupling maps to a combination of gimbal work mode, gimbal
+// control mode and chassis mode that must be used together.
+type Coupling uint8
+
+const (
+	// CouplingFree means the gimbal and the chassis move independently.
+	CouplingFree Coupling = iota
+	// CouplingChassisFollowsGimbal means the chassis turns to follow the
+	// gimbal yaw.
+	CouplingChassisFollowsGimbal
+	// CouplingGimbalFollowsChassis means the gimbal yaw is locked to the
+	// chassis heading. Yaw rotations are not possible in this coupling.
+	CouplingGimbalFollowsChassis
+	// couplingCount is the number of couplings. Intentionaly not exported.
+	couplingCount
+)
+
+// String returns a human readable representation of the coupling.
+func (c Coupling) String() string {
+	switch c {
+	case CouplingFree:
+		return "Free"
+	case CouplingChassisFollowsGimbal:
+		return "ChassisFollowsGimbal"
+	case CouplingGimbalFollowsChassis:
+		return "GimbalFollowsChassis"
+	default:
+		return fmt.Sprintf("Unknown(%d)", c)
+	}
+}
+
+// Valid returns true if the coupling is valid.
+func (c Coupling) Valid() bool {
+	return c < couplingCount
+}
+
+// WorkMode returns the gimbal work mode used by the coupling.
+func (c Coupling) WorkMode() WorkMode {
+	switch c {
+	case CouplingChassisFollowsGimbal:
+		return WorkModeYawFollow
+	case CouplingGimbalFollowsChassis:
+		return WorkModeFPV
+	default:
+		return WorkModeFree
+	}
+}
+
+// ControlMode returns the gimbal control mode used by the coupling.
+//
+// This mapping is unverified: it assumes ControlMode2 is only needed when the
+// gimbal yaw is locked to the chassis.
+func (c Coupling) ControlMode() ControlMode {
+	if c == CouplingGimbalFollowsChassis {
+		return ControlMode2
+	}
+
+	return ControlMode1
+}
+
+// ChassisMode returns the chassis mode used by the coupling. Chassis speeds
+// must be set with this mode for the coupling to behave as expected.
+func (c Coupling) ChassisMode() chassis.Mode {
+	switch c {
+	case CouplingChassisFollowsGimbal:
+		return chassis.ModeYawFollow
+	case CouplingGimbalFollowsChassis:
+		return chassis.ModeFPV
+	default:
+		return chassis.ModeAngularVelocity
+	}
+}
+
+// Coupling returns the current gimbal/chassis coupling, as derived from the
+// gimbal work mode.
+func (g *Gimbal) Coupling() (Coupling, error) {
+	return g.CouplingContext(context.Background())
+}
+
+// CouplingContext is like Coupling but honors the given context.
+func (g *Gimbal) CouplingContext(ctx context.Context) (Coupling, error) {
+	wm, err := g.WorkModeContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	switch wm {
+	case WorkModeFree:
+		return CouplingFree, nil
+	case WorkModeFPV:
+		return CouplingGimbalFollowsChassis, nil
+	case WorkModeYawFollow:
+		return CouplingChassisFollowsGimbal, nil
+	default:
+		return 0, fmt.Errorf("unknown gimbal work mode: %s", wm)
+	}
+}
+
+// SetCoupling sets the gimbal/chassis coupling by setting the gimbal work
+// mode, the gimbal control mode and the mode of the given chassis. If any of
+// those fail, the previous gimbal work and control modes are restored. The
+// coupling can not be changed (and nothing is changed) if the current work
+// mode is unknown or while the gimbal is being calibrated or reset.
+func (g *Gimbal) SetCoupling(c Coupling, ch *chassis.Chassis) error {
+	return g.SetCouplingContext(context.Background(), c, ch)
+}
+
+// SetCouplingContext is like SetCoupling but honors the given context.
+func (g *Gimbal) SetCouplingContext(ctx context.Context, c Coupling,
+	ch *chassis.Chassis) error {
+	if !c.Valid() {
+		return fmt.Errorf("invalid coupling: %d", c)
+	}
+
+	if ch == nil {
+		return fmt.Errorf("chassis is required to set the coupling")
+	}
+
+	g.couplingM.Lock()
+	defer g.couplingM.Unlock()
+
+	previousWorkMode, err := g.WorkModeContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = g.checkCouplingTransition(previousWorkMode, c)
+	if err != nil {
+		return err
+	}
+
+	previousControlMode := g.ControlMode()
+
+	rollback := func() {
+		if err := g.SetControlMode(previousControlMode); err != nil {
+			g.Logger().Error("Error restoring gimbal control mode.",
+				"error", err)
+		}
+
+		if err := g.SetWorkMode(previousWorkMode); err != nil {
+			g.Logger().Error("Error restoring gimbal work mode.",
+				"error", err)
+		}
+	}
+
+	err = g.SetWorkModeContext(ctx, c.WorkMode())
+	if err != nil {
+		return err
+	}
+
+	err = g.SetControlMode(c.ControlMode())
+	if err != nil {
+		rollback()
+		return err
+	}
+
+	err = ch.SetMode(c.ChassisMode())
+	if err != nil {
+		rollback()
+		return err
+	}
+
+	g.m.Lock()
+	g.coupling = c
+	g.couplingSet = true
+	g.m.Unlock()
+
+	g.Logger().Debug("Coupling set.", "coupling", c)
+
+	return nil
+}
+
+// checkCouplingTransition returns an error if the coupling can not be changed
+// from the one associated with the given current work mode to the given one.
+// It is called before anything is changed.
+func (g *Gimbal) checkCouplingTransition(current WorkMode, c Coupling) error {
+	if !current.Valid() {
+		return fmt.Errorf("can not change coupling from unknown gimbal work "+
+			"mode %s", current)
+	}
+
+	if g.calibrating() {
+		return fmt.Errorf("can not change coupling while the gimbal is " +
+			"being calibrated")
+	}
+
+	if g.resetting() {
+		return fmt.Errorf("can not change coupling while the gimbal is " +
+			"being reset")
+	}
+
+	return nil
+}
+
+// checkRelativeYawRotation returns an error if relative yaw rotations are not
+// possible. As the coupling mappings are unverified, relative yaw rotations
+// are only allowed after a coupling that does not lock the gimbal yaw to the
+// chassis was set with SetCoupling (and the work and control modes were not
+// changed directly since).
+func (g *Gimbal) checkRelativeYawRotation() error {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	if !g.couplingSet {
+		return fmt.Errorf("relative yaw rotation requires a coupling to be " +
+			"set with SetCoupling")
+	}
+
+	if g.coupling == CouplingGimbalFollowsChassis {
+		return fmt.Errorf("yaw rotation not possible while the gimbal " +
+			"follows the chassis")
+	}
+
+	return nil
+}
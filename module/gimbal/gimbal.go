@@ -1,35 +1,86 @@
 package gimbal
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/connection"
 	"github.com/brunoga/robomaster/module/internal"
+	"github.com/brunoga/robomaster/module/robot"
+	"github.com/brunoga/robomaster/support/dispatcher"
 	"github.com/brunoga/robomaster/support/logger"
 	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge"
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/key/typed"
 	"github.com/brunoga/robomaster/unitybridge/unity/result"
 	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/unity/task"
 )
 
+// resetPositionTimeout is how long ResetPosition waits for the gimbal to
+// report its position was reset.
+const resetPositionTimeout = 10 * time.Second
+
 // Gimbal is the module that allows controlling the gimbal.
 type Gimbal struct {
 	*internal.BaseModule
 
+	rm *robot.Robot
+
 	gaToken token.Token
 
+	attitude atomic.Pointer[Attitude]
+
+	tg                *token.Generator
+	m                 sync.Mutex
+	attitudeListeners map[token.Token]*dispatcher.Dispatcher[Attitude]
+	calibration       *Calibration
+
+	// resetM serializes reset tasks.
+	resetM    sync.Mutex
+	resetTask *robot.Task
+
+	// couplingM serializes coupling changes.
+	couplingM sync.Mutex
+
+	// Guarded by m. couplingSet is true if coupling was set with
+	// SetCoupling and the work and control modes were not changed directly
+	// since.
 	controlMode ControlMode
+	coupling    Coupling
+	couplingSet bool
+
+	workMode typed.ReadWriter[value.Uint64]
+
+	commandListeners *internal.CommandListeners[bool]
 }
 
 var _ module.Module = (*Gimbal)(nil)
 
-// New creates a new Gimbal instance.
+// New creates a new Gimbal instance. Task based methods (the ones returning a
+// *robot.Task) are only available in instances created with NewWithRobot.
 func New(ub unitybridge.UnityBridge, l *logger.Logger,
 	cm *connection.Connection) (*Gimbal, error) {
-	g := &Gimbal{}
+	return NewWithRobot(ub, l, cm, nil)
+}
+
+// NewWithRobot is like New but uses the given robot module to track tasks.
+func NewWithRobot(ub unitybridge.UnityBridge, l *logger.Logger,
+	cm *connection.Connection, rm *robot.Robot) (*Gimbal, error) {
+	g := &Gimbal{
+		rm:               rm,
+		commandListeners: internal.NewCommandListeners[bool](),
+		tg:               token.NewGenerator(),
+		attitudeListeners: make(
+			map[token.Token]*dispatcher.Dispatcher[Attitude]),
+	}
+
+	g.attitude.Store(&Attitude{})
 
 	g.BaseModule = internal.NewBaseModule(ub, l, "Gimbal",
 		key.KeyGimbalConnection, func(r *result.Result) {
@@ -57,14 +108,26 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 			}
 		}, cm)
 
+	var err error
+
+	g.workMode, err = typed.NewReadWriter[value.Uint64](ub,
+		key.KeyGimbalWorkMode)
+	if err != nil {
+		return nil, err
+	}
+
 	return g, nil
 }
 
 func (g *Gimbal) Start() error {
 	var err error
 
-	g.gaToken, err = g.UB().AddKeyListener(key.KeyGimbalAttitude,
-		g.onAttitudeUpdates, false)
+	// Attitude updates come at a high rate and only the latest one matters.
+	g.gaToken, err = g.UB().AddKeyListenerWithOptions(key.KeyGimbalAttitude,
+		g.onAttitudeUpdates, false, &dispatcher.Options{
+			QueueSize:      1,
+			OverflowPolicy: dispatcher.OverflowPolicyCoalesceLatest,
+		})
 	if err != nil {
 		return err
 	}
@@ -89,70 +152,108 @@ func (g *Gimbal) SetRotationSpeed(pitch, yaw int16) error {
 		return err
 	}
 
-	return g.UB().PerformActionForKey(key.KeyGimbalSpeedRotation,
+	err = g.UB().PerformActionForKey(key.KeyGimbalSpeedRotation,
 		&value.GimbalSpeedRotation{Pitch: pitch * 10, Yaw: yaw * 10, Roll: 0}, nil)
+	if err != nil {
+		return err
+	}
+
+	g.commandListeners.Notify(pitch != 0 || yaw != 0)
+
+	return nil
+}
+
+// AddMotionCommandListener adds a listener that is called whenever a
+// continuous motion command (one that keeps the gimbal moving until another
+// command is sent, like SetRotationSpeed) is sent to the robot. The listener
+// is called with true if the command makes the gimbal move and with false
+// otherwise. It is called synchronously from the goroutine sending the
+// command so it must not block. Returns a token that can be used to remove the
+// listener.
+func (g *Gimbal) AddMotionCommandListener(
+	f func(moving bool)) (token.Token, error) {
+	return g.commandListeners.Add(f)
+}
+
+// RemoveMotionCommandListener removes the motion command listener associated
+// with the given token.
+func (g *Gimbal) RemoveMotionCommandListener(t token.Token) error {
+	return g.commandListeners.Remove(t)
 }
 
 // SetRelativeAngleRotation sets the gimbal rotation relative to the current
-// position. This is executed asynchronously.
-//
-// TODO(bga): Figure out units.
+// position. The angle is in degrees. This is executed asynchronously. See Move
+// for a variant that takes fractional degrees and waits for completion. Yaw
+// rotations require a coupling to be set with SetCoupling.
 func (g *Gimbal) SetRelativeAngleRotation(angle int16, axis Axis,
 	duration time.Duration) error {
-	if duration > 10*time.Second {
-		return fmt.Errorf("invalid duration %s, max is 10s", duration/time.Second)
-	}
+	return g.SetRelativeAngleRotationContext(context.Background(), angle, axis, duration)
+}
 
-	gimbalIncrementRotation := value.GimbalAngleRotation{
-		Time: int16(duration / time.Millisecond),
+// SetRelativeAngleRotationContext is like SetRelativeAngleRotation but honors
+// the given context.
+func (g *Gimbal) SetRelativeAngleRotationContext(ctx context.Context,
+	angle int16, axis Axis,
+	duration time.Duration) error {
+	gimbalIncrementRotation, err := g.relativeAngleRotation(ctx, angle, axis,
+		duration)
+	if err != nil {
+		return err
 	}
 
-	if axis == AxisPitch {
-		if angle < -60 || angle > 60 {
-			return fmt.Errorf("invalid pitch angle %d, should be between -60 "+
-				"and 60 degrees", angle)
-		}
+	return g.UB().PerformActionForKeySyncContext(ctx,
+		key.KeyGimbalAngleIncrementRotation,
+		gimbalIncrementRotation)
+}
 
-		gimbalIncrementRotation.Pitch = angle * 10
-		gimbalIncrementRotation.Yaw = 0
-	} else {
-		// TODO(bga): Fix this. It might be just something that needs to be set
-		//            before this is called, like the the chassis or gimbal
-		//            modes.
-		return fmt.Errorf("yaw axis not supported yet")
-		//gimbalIncrementRotation.Pitch = 0
-		//gimbalIncrementRotation.Yaw = angle * 10
+// SetRelativeAngleRotationTask is like SetRelativeAngleRotationContext but
+// returns a task handle that can be used to wait for the rotation to
+// complete, track its progress or cancel it.
+func (g *Gimbal) SetRelativeAngleRotationTask(ctx context.Context,
+	angle int16, axis Axis, duration time.Duration) (*robot.Task, error) {
+	gimbalIncrementRotation, err := g.relativeAngleRotation(ctx, angle, axis,
+		duration)
+	if err != nil {
+		return nil, err
 	}
 
-	return g.UB().PerformActionForKeySync(key.KeyGimbalAngleIncrementRotation,
-		&gimbalIncrementRotation)
+	return g.startTask(ctx, task.TypeGimbalAngle,
+		key.KeyGimbalAngleIncrementRotation, gimbalIncrementRotation)
 }
 
 // SetAbsoluteAngleRotation sets the absolute gimbal rotation relative to its
 // default position. This is executed asynchronously.
 func (g *Gimbal) SetAbsoluteAngleRotation(angle int16, axis Axis,
 	duration time.Duration) error {
-	gimbalAngleRotation := value.GimbalAngleRotation{
-		Time: int16(duration / time.Millisecond),
-	}
+	return g.SetAbsoluteAngleRotationContext(context.Background(), angle, axis, duration)
+}
 
-	var k *key.Key
+// SetAbsoluteAngleRotationContext is like SetAbsoluteAngleRotation but honors
+// the given context.
+func (g *Gimbal) SetAbsoluteAngleRotationContext(ctx context.Context,
+	angle int16, axis Axis,
+	duration time.Duration) error {
+	k, gimbalAngleRotation, err := absoluteAngleRotation(angle, axis,
+		duration)
+	if err != nil {
+		return err
+	}
 
-	if axis == AxisPitch {
-		if angle < -25 || angle > 35 {
-			return fmt.Errorf("invalid pitch angle %d", angle)
-		}
+	return g.UB().PerformActionForKeySyncContext(ctx, k, gimbalAngleRotation)
+}
 
-		gimbalAngleRotation.Pitch = angle * 10
-		gimbalAngleRotation.Yaw = 0
-		k = key.KeyGimbalAngleFrontPitchRotation
-	} else {
-		gimbalAngleRotation.Pitch = 0
-		gimbalAngleRotation.Yaw = angle * 10
-		k = key.KeyGimbalAngleFrontYawRotation
+// SetAbsoluteAngleRotationTask is like SetAbsoluteAngleRotationContext but
+// returns a task handle that can be used to wait for the rotation to
+// complete, track its progress or cancel it.
+func (g *Gimbal) SetAbsoluteAngleRotationTask(ctx context.Context,
+	angle int16, axis Axis, duration time.Duration) (*robot.Task, error) {
+	k, gimbalAngleRotation, err := absoluteAngleRotation(angle, axis,
+		duration)
+	if err != nil {
+		return nil, err
 	}
 
-	return g.UB().PerformActionForKeySync(k, &gimbalAngleRotation)
+	return g.startTask(ctx, task.TypeGimbalAngle, k, gimbalAngleRotation)
 }
 
 // StopRotation stops any ongoing gimbal rotation.
@@ -165,18 +266,50 @@ func (g *Gimbal) StopRotation() error {
 	return g.UB().PerformActionForKey(key.KeyGimbalSpeedRotationEnabled, &value.Uint64{Value: 0}, nil)
 }
 
-// ResetPosition resets the gimbal position.
-func (g *Gimbal) ResetPosition() error {
-	err := g.UB().PerformActionForKeySync(key.KeyGimbalResetPosition, nil)
+// StopRotationContext is like StopRotation but waits for the robot to
+// acknowledge the commands and honors the given context.
+func (g *Gimbal) StopRotationContext(ctx context.Context) error {
+	err := g.UB().PerformActionForKeySyncContext(ctx,
+		key.KeyGimbalSpeedRotationEnabled, &value.Uint64{Value: 1})
+	if err != nil {
+		return err
+	}
+
+	err = g.UB().PerformActionForKeySyncContext(ctx,
+		key.KeyGimbalSpeedRotation, &value.GimbalSpeedRotation{})
 	if err != nil {
 		return err
 	}
 
+	g.commandListeners.Notify(false)
+
+	return g.UB().PerformActionForKeySyncContext(ctx,
+		key.KeyGimbalSpeedRotationEnabled, &value.Uint64{Value: 0})
+}
+
+// ResetPosition resets the gimbal position. It blocks until the gimbal reports
+// that the reset is complete or resetPositionTimeout expires.
+func (g *Gimbal) ResetPosition() error {
+	return g.ResetPositionContext(context.Background())
+}
+
+// ResetPositionContext is like ResetPosition but honors the given context. If
+// the context has no deadline, resetPositionTimeout applies.
+func (g *Gimbal) ResetPositionContext(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, resetPositionTimeout)
+		defer cancel()
+	}
+
+	var m sync.Mutex
 	gimbalReset := 0
 	c := make(chan struct{})
 
-	var t token.Token
-	t, err = g.UB().AddKeyListener(key.KeyGimbalResetPositionState, func(r *result.Result) {
+	// The listener is added before the reset is requested so no state push
+	// is missed. The cached value is not delivered as it is from before the
+	// reset.
+	t, err := g.UB().AddKeyListener(key.KeyGimbalResetPositionState, func(r *result.Result) {
 		g.Logger().Debug("Reset position state", "result", r)
 		if !r.Succeeded() {
 			g.Logger().Error("Error resetting gimbal position", "error", r.ErrorDesc())
@@ -189,26 +322,96 @@ func (g *Gimbal) ResetPosition() error {
 			return
 		}
 
+		m.Lock()
+		defer m.Unlock()
+
+		if gimbalReset < 0 {
+			// Already done.
+			return
+		}
+
 		if value.Value == 0 && gimbalReset == 1 {
 			g.Logger().Debug("Reset position done")
 			close(c)
+			gimbalReset = -1
 			return
 		}
 
 		gimbalReset = int(value.Value)
-	}, true)
+	}, false)
 	if err != nil {
 		return err
 	}
 
 	defer g.UB().RemoveKeyListener(key.KeyGimbalResetPositionState, t)
 
-	<-c
+	err = g.UB().PerformActionForKeySyncContext(ctx,
+		key.KeyGimbalResetPosition, nil)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	select {
+	case <-c:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error waiting for gimbal position reset: %w",
+			ctx.Err())
+	}
+}
+
+// resetting returns true if a reset task started by this module is still
+// running.
+func (g *Gimbal) resetting() bool {
+	g.resetM.Lock()
+	defer g.resetM.Unlock()
+
+	if g.resetTask == nil {
+		return false
+	}
+
+	select {
+	case <-g.resetTask.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+// ResetPositionTask starts resetting the gimbal position and returns a task
+// handle that can be used to wait for the reset to complete, track its
+// progress or cancel it.
+//
+// Only one reset task can run at a time as reset status pushes can not be
+// told apart.
+func (g *Gimbal) ResetPositionTask(ctx context.Context) (*robot.Task, error) {
+	g.resetM.Lock()
+	defer g.resetM.Unlock()
+
+	if t := g.resetTask; t != nil {
+		select {
+		case <-t.Done():
+		default:
+			return nil, fmt.Errorf("gimbal reset already in progress")
+		}
+	}
+
+	t, err := g.startTask(ctx, task.TypeGimbalReset,
+		key.KeyGimbalResetPosition, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	g.resetTask = t
+
+	return t, nil
 }
 
+// ControlMode returns the gimbal control mode last set.
 func (g *Gimbal) ControlMode() ControlMode {
+	g.m.Lock()
+	defer g.m.Unlock()
+
 	return g.controlMode
 }
 
@@ -222,37 +425,57 @@ func (g *Gimbal) SetControlMode(cm ControlMode) error {
 		cm = ControlMode1
 	}
 
+	g.m.Lock()
+	defer g.m.Unlock()
+
 	err := g.UB().DirectSendKeyValue(key.KeyGimbalControlMode, uint64(cm))
 	if err != nil {
 		return err
 	}
 
 	g.controlMode = cm
+	g.couplingSet = false
 
 	return nil
 }
 
-func (g *Gimbal) WorkMode() (uint64, error) {
-	r, err := g.UB().GetKeyValueSync(key.KeyGimbalWorkMode, true)
+// WorkMode returns the gimbal work mode.
+func (g *Gimbal) WorkMode() (WorkMode, error) {
+	return g.WorkModeContext(context.Background())
+}
+
+// WorkModeContext is like WorkMode but honors the given context.
+func (g *Gimbal) WorkModeContext(ctx context.Context) (WorkMode, error) {
+	wm, err := g.workMode.Get(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	if !r.Succeeded() {
-		return 0, fmt.Errorf("error getting work mode: %s", r.ErrorDesc())
+	if wm.Value >= uint64(workModeCount) {
+		return 0, fmt.Errorf("unknown gimbal work mode: %d", wm.Value)
 	}
 
-	wm, ok := r.Value().(*value.Uint64)
-	if !ok {
-		return 0, fmt.Errorf("unexpected value: %v", r.Value())
-	}
+	return WorkMode(wm.Value), nil
+}
 
-	return wm.Value, nil
+// SetWorkMode sets the gimbal work mode. Note that the work mode must match
+// the gimbal control mode and the chassis mode in use. Use SetCoupling to set
+// all of them together.
+func (g *Gimbal) SetWorkMode(wm WorkMode) error {
+	return g.SetWorkModeContext(context.Background(), wm)
 }
 
-// SetWorkMode sets the gimbal work mode.
-func (g *Gimbal) SetWorkMode(wm uint64) error {
-	return g.UB().SetKeyValueSync(key.KeyGimbalWorkMode, &value.Uint64{Value: wm})
+// SetWorkModeContext is like SetWorkMode but honors the given context.
+func (g *Gimbal) SetWorkModeContext(ctx context.Context, wm WorkMode) error {
+	if !wm.Valid() {
+		return fmt.Errorf("invalid work mode: %d", wm)
+	}
+
+	g.m.Lock()
+	g.couplingSet = false
+	g.m.Unlock()
+
+	return g.workMode.Set(ctx, value.Uint64{Value: uint64(wm)})
 }
 
 func (g *Gimbal) Stop() error {
@@ -266,9 +489,141 @@ func (g *Gimbal) Stop() error {
 		return err
 	}
 
+	g.m.Lock()
+	c := g.calibration
+	g.m.Unlock()
+
+	if c != nil {
+		c.Cancel()
+	}
+
+	g.m.Lock()
+	for t, d := range g.attitudeListeners {
+		d.Stop()
+		delete(g.attitudeListeners, t)
+	}
+	g.m.Unlock()
+
 	return g.BaseModule.Stop()
 }
 
+// Attitude returns the latest gimbal attitude.
+func (g *Gimbal) Attitude() Attitude {
+	return *g.attitude.Load()
+}
+
+// defaultAttitudeListenerOptions are the options used for attitude listeners
+// when none are given. Only the latest attitude is kept for a slow listener
+// so it never delays attitude updates.
+var defaultAttitudeListenerOptions = dispatcher.Options{
+	QueueSize:      1,
+	OverflowPolicy: dispatcher.OverflowPolicyCoalesceLatest,
+}
+
+// AddAttitudeListener adds a listener that is called (in order) with the
+// gimbal attitude whenever it changes. If o is nil, only the latest attitude
+// is delivered to a listener that does not keep up. Note that using
+// dispatcher.OverflowPolicyBlock makes a slow listener delay attitude updates
+// for everybody. It returns a token that can be used to remove the listener.
+func (g *Gimbal) AddAttitudeListener(f func(Attitude),
+	o *dispatcher.Options) (token.Token, error) {
+	if f == nil {
+		return 0, fmt.Errorf("listener must not be nil")
+	}
+
+	if o == nil {
+		o = &defaultAttitudeListenerOptions
+	}
+
+	d, err := dispatcher.New(o, f)
+	if err != nil {
+		return 0, err
+	}
+
+	t := g.tg.Next()
+
+	g.m.Lock()
+	g.attitudeListeners[t] = d
+	g.m.Unlock()
+
+	return t, nil
+}
+
+// RemoveAttitudeListener removes the attitude listener associated with the
+// given token.
+func (g *Gimbal) RemoveAttitudeListener(t token.Token) error {
+	g.m.Lock()
+	d, ok := g.attitudeListeners[t]
+	delete(g.attitudeListeners, t)
+	g.m.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no attitude listener registered with token %d", t)
+	}
+
+	d.Stop()
+
+	return nil
+}
+
+// WaitForAttitude waits until the given predicate returns true for the
+// gimbal attitude (either the current one or a new one) or the given timeout
+// expires. Returns the attitude that satisfied the predicate.
+func (g *Gimbal) WaitForAttitude(predicate func(Attitude) bool,
+	timeout time.Duration) (Attitude, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return g.WaitForAttitudeContext(ctx, predicate)
+}
+
+// WaitForAttitudeContext is like WaitForAttitude but waits until the given
+// context is done instead of using a timeout.
+func (g *Gimbal) WaitForAttitudeContext(ctx context.Context,
+	predicate func(Attitude) bool) (Attitude, error) {
+	if predicate == nil {
+		return Attitude{}, fmt.Errorf("predicate must not be nil")
+	}
+
+	// Only the latest attitude matters.
+	attitudeCh := make(chan Attitude, 1)
+	t, err := g.AddAttitudeListener(func(a Attitude) {
+		// Replace any pending attitude. This is the only sender so the send
+		// never blocks.
+		select {
+		case <-attitudeCh:
+		default:
+		}
+
+		attitudeCh <- a
+	}, &dispatcher.Options{
+		QueueSize:      1,
+		OverflowPolicy: dispatcher.OverflowPolicyCoalesceLatest,
+	})
+	if err != nil {
+		return Attitude{}, err
+	}
+	defer g.RemoveAttitudeListener(t)
+
+	// Check the current attitude after adding the listener so no update is
+	// missed.
+	if a := g.Attitude(); !a.Time.IsZero() && predicate(a) {
+		return a, nil
+	}
+
+	for {
+		select {
+		case a := <-attitudeCh:
+			if predicate(a) {
+				return a, nil
+			}
+		case <-ctx.Done():
+			return Attitude{}, fmt.Errorf("error waiting for gimbal "+
+				"attitude: %w", ctx.Err())
+		}
+	}
+}
+
 func (g *Gimbal) onAttitudeUpdates(r *result.Result) {
 	if r == nil || !r.Succeeded() {
 		g.Logger().Error("Error getting gimbal attitude", "error", r.ErrorDesc())
@@ -281,7 +636,123 @@ func (g *Gimbal) onAttitudeUpdates(r *result.Result) {
 		return
 	}
 
-	g.Logger().Info("Gimbal attitude", "pitch", value.Pitch, "roll", value.Roll,
+	g.Logger().Debug("Gimbal attitude", "pitch", value.Pitch, "roll", value.Roll,
 		"yaw", value.Yaw, "yawOpposite", value.YawOpposite, "pitchSpeed",
 		value.PitchSpeed, "rollSpeed", value.RollSpeed, "yawSpeed", value.YawSpeed)
+
+	a := &Attitude{
+		Pitch:       float64(value.Pitch),
+		Roll:        float64(value.Roll),
+		Yaw:         float64(value.Yaw),
+		YawOpposite: float64(value.YawOpposite),
+		PitchSpeed:  float64(value.PitchSpeed),
+		RollSpeed:   float64(value.RollSpeed),
+		YawSpeed:    float64(value.YawSpeed),
+		Time:        time.Now(),
+	}
+
+	g.attitude.Store(a)
+
+	g.m.Lock()
+	ds := make([]*dispatcher.Dispatcher[Attitude], 0,
+		len(g.attitudeListeners))
+	for _, d := range g.attitudeListeners {
+		ds = append(ds, d)
+	}
+	g.m.Unlock()
+
+	for _, d := range ds {
+		d.Dispatch(*a)
+	}
+}
+
+// startTask starts a task of the given type by performing the action for the
+// given key with the given value. Canceling the task stops any ongoing gimbal
+// rotation.
+func (g *Gimbal) startTask(ctx context.Context, typ task.Type, k *key.Key,
+	v any) (*robot.Task, error) {
+	if g.rm == nil {
+		return nil, fmt.Errorf("gimbal tasks require a robot module (see " +
+			"NewWithRobot)")
+	}
+
+	return g.rm.StartTask(ctx, typ,
+		func(ctx context.Context) error {
+			return g.UB().PerformActionForKeySyncContext(ctx, k, v)
+		}, g.StopRotationContext)
+}
+
+// relativeAngleRotation validates the given parameters against the current
+// work mode and returns the value to be used for a relative angle rotation.
+func (g *Gimbal) relativeAngleRotation(ctx context.Context, angle int16,
+	axis Axis, duration time.Duration) (*value.GimbalAngleRotation, error) {
+	if duration > 10*time.Second {
+		return nil, fmt.Errorf("invalid duration %s, max is 10s",
+			duration/time.Second)
+	}
+
+	gimbalIncrementRotation := &value.GimbalAngleRotation{
+		Time: int16(duration / time.Millisecond),
+	}
+
+	if axis == AxisPitch {
+		if angle < -60 || angle > 60 {
+			return nil, fmt.Errorf("invalid pitch angle %d, should be "+
+				"between -60 and 60 degrees", angle)
+		}
+
+		gimbalIncrementRotation.Pitch = angle * 10
+		gimbalIncrementRotation.Yaw = 0
+	} else {
+		if angle < -250 || angle > 250 {
+			return nil, fmt.Errorf("invalid yaw angle %d, should be "+
+				"between -250 and 250 degrees", angle)
+		}
+
+		wm, err := g.WorkModeContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if wm == WorkModeFPV {
+			return nil, fmt.Errorf("yaw rotation not possible while the " +
+				"gimbal follows the chassis")
+		}
+
+		if err := g.checkRelativeYawRotation(); err != nil {
+			return nil, err
+		}
+
+		gimbalIncrementRotation.Pitch = 0
+		gimbalIncrementRotation.Yaw = angle * 10
+	}
+
+	return gimbalIncrementRotation, nil
+}
+
+// absoluteAngleRotation validates the given parameters and returns the key
+// and value to be used for an absolute angle rotation.
+func absoluteAngleRotation(angle int16, axis Axis,
+	duration time.Duration) (*key.Key, *value.GimbalAngleRotation, error) {
+	gimbalAngleRotation := &value.GimbalAngleRotation{
+		Time: int16(duration / time.Millisecond),
+	}
+
+	var k *key.Key
+
+	if axis == AxisPitch {
+		if angle < -25 || angle > 35 {
+			return nil, nil, fmt.Errorf("invalid pitch angle %d", angle)
+		}
+
+		gimbalAngleRotation.Pitch = angle * 10
+		gimbalAngleRotation.Yaw = 0
+		k = key.KeyGimbalAngleFrontPitchRotation
+	} else {
+		gimbalAngleRotation.Pitch = 0
+		gimbalAngleRotation.Yaw = angle * 10
+		k = key.KeyGimbalAngleFrontYawRotation
+	}
+
+	return k, gimbalAngleRotation, nil
 }
@@ -0,0 +1,251 @@
+package gimbal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/brunoga/robomaster/module/robot"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/unity/task"
+)
+
+// Gimbal angle limits in degrees, relative to the gimbal default (front)
+// position.
+const (
+	MinPitch = -25.0
+	MaxPitch = 35.0
+	MinYaw   = -250.0
+	MaxYaw   = 250.0
+)
+
+// Gimbal move limits.
+const (
+	// MaxMoveSpeed is the maximum speed for a gimbal move in degrees/s.
+	MaxMoveSpeed = 360.0
+	// MaxMoveDuration is the maximum duration for a gimbal move.
+	MaxMoveDuration = 10 * time.Second
+)
+
+// Frame is the reference frame for a gimbal move angle.
+type Frame uint8
+
+const (
+	// FrameRelative means the angle is relative to the current gimbal
+	// attitude.
+	FrameRelative Frame = iota
+	// FrameAbsolute means the angle is relative to the gimbal default (front)
+	// position.
+	FrameAbsolute
+	// frameCount is the number of frames. Intentionaly not exported.
+	frameCount
+)
+
+// String returns a human readable representation of the frame.
+func (f Frame) String() string {
+	switch f {
+	case FrameRelative:
+		return "Relative"
+	case FrameAbsolute:
+		return "Absolute"
+	default:
+		return fmt.Sprintf("Unknown(%d)", f)
+	}
+}
+
+// Valid returns true if the frame is valid.
+func (f Frame) Valid() bool {
+	return f < frameCount
+}
+
+// MoveOptions controls how fast a gimbal move is executed. Exactly one of
+// Speed or Duration must be set.
+type MoveOptions struct {
+	// Speed is the move speed in degrees/s (up to MaxMoveSpeed). Moves in
+	// the absolute frame require the current attitude to be known to use it.
+	Speed float64
+
+	// Duration is how long the move should take (up to MaxMoveDuration).
+	Duration time.Duration
+}
+
+// Move moves the given gimbal axis by (FrameRelative) or to (FrameAbsolute)
+// the given angle in degrees and blocks until the move completes. Relative yaw
+// moves require a coupling to be set with SetCoupling.
+func (g *Gimbal) Move(axis Axis, angle float64, frame Frame,
+	opts MoveOptions) error {
+	return g.MoveContext(context.Background(), axis, angle, frame, opts)
+}
+
+// MoveContext is like Move but honors the given context. The move is canceled
+// if the context is done before it completes.
+func (g *Gimbal) MoveContext(ctx context.Context, axis Axis, angle float64,
+	frame Frame, opts MoveOptions) error {
+	t, err := g.MoveTask(ctx, axis, angle, frame, opts)
+	if err != nil {
+		return err
+	}
+
+	err = t.Wait(ctx)
+	if err != nil && ctx.Err() != nil {
+		if cancelErr := t.Cancel(); cancelErr != nil {
+			g.Logger().Error("Error canceling gimbal move.", "error",
+				cancelErr)
+		}
+	}
+
+	return err
+}
+
+// MoveTask is like MoveContext but returns a task handle (of type
+// task.TypeGimbalAngle) that can be used to wait for the move to complete,
+// track its progress or cancel it.
+func (g *Gimbal) MoveTask(ctx context.Context, axis Axis, angle float64,
+	frame Frame, opts MoveOptions) (*robot.Task, error) {
+	k, v, err := g.moveRotation(ctx, axis, angle, frame, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.startTask(ctx, task.TypeGimbalAngle, k, v)
+}
+
+// moveRotation validates the given move parameters and returns the key and
+// value to be used for it.
+func (g *Gimbal) moveRotation(ctx context.Context, axis Axis, angle float64,
+	frame Frame, opts MoveOptions) (*key.Key, *value.GimbalAngleRotation,
+	error) {
+	if !frame.Valid() {
+		return nil, nil, fmt.Errorf("invalid frame: %d", frame)
+	}
+
+	if math.IsNaN(angle) || math.IsInf(angle, 0) {
+		return nil, nil, fmt.Errorf("invalid angle: %f", angle)
+	}
+
+	a := g.Attitude()
+
+	var lo, hi, current float64
+	switch axis {
+	case AxisPitch:
+		lo, hi = MinPitch, MaxPitch
+		current = a.Pitch
+	case AxisYaw:
+		lo, hi = MinYaw, MaxYaw
+		current = a.Yaw
+
+		wm, err := g.WorkModeContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if wm == WorkModeFPV {
+			return nil, nil, fmt.Errorf("yaw rotation not possible while " +
+				"the gimbal follows the chassis")
+		}
+
+		if frame == FrameRelative {
+			if err := g.checkRelativeYawRotation(); err != nil {
+				return nil, nil, err
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("invalid axis: %d", axis)
+	}
+
+	known := !a.Time.IsZero()
+
+	// Distance the gimbal will move, if it can be determined.
+	distance := math.Abs(angle)
+
+	if frame == FrameAbsolute {
+		if angle < lo || angle > hi {
+			return nil, nil, fmt.Errorf("invalid %s angle %.1f, should be "+
+				"between %.1f and %.1f degrees", axis, angle, lo, hi)
+		}
+
+		distance = math.Abs(angle - current)
+	} else {
+		if angle < lo-hi || angle > hi-lo {
+			return nil, nil, fmt.Errorf("invalid relative %s angle %.1f, "+
+				"should be between %.1f and %.1f degrees", axis, angle,
+				lo-hi, hi-lo)
+		}
+
+		if known && (current+angle < lo || current+angle > hi) {
+			return nil, nil, fmt.Errorf("invalid relative %s angle %.1f, "+
+				"target %.1f should be between %.1f and %.1f degrees", axis,
+				angle, current+angle, lo, hi)
+		}
+	}
+
+	duration, err := moveDuration(opts, distance, known ||
+		frame == FrameRelative)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := &value.GimbalAngleRotation{
+		Time: int16(duration / time.Millisecond),
+	}
+
+	tenths := int16(math.Round(angle * 10))
+
+	var k *key.Key
+	switch {
+	case frame == FrameRelative && axis == AxisPitch:
+		v.Pitch = tenths
+		k = key.KeyGimbalAngleIncrementRotation
+	case frame == FrameRelative:
+		v.Yaw = tenths
+		k = key.KeyGimbalAngleIncrementRotation
+	case axis == AxisPitch:
+		v.Pitch = tenths
+		k = key.KeyGimbalAngleFrontPitchRotation
+	default:
+		v.Yaw = tenths
+		k = key.KeyGimbalAngleFrontYawRotation
+	}
+
+	return k, v, nil
+}
+
+// moveDuration validates the given move options and returns the duration of a
+// move over the given distance. The distance is only used (and must be known)
+// if the options specify a speed.
+func moveDuration(opts MoveOptions, distance float64,
+	distanceKnown bool) (time.Duration, error) {
+	if (opts.Speed != 0) == (opts.Duration != 0) {
+		return 0, fmt.Errorf("exactly one of speed or duration must be set")
+	}
+
+	if opts.Duration != 0 {
+		if opts.Duration < 0 || opts.Duration > MaxMoveDuration {
+			return 0, fmt.Errorf("invalid duration %s, should be between "+
+				"0 and %s", opts.Duration, MaxMoveDuration)
+		}
+
+		return opts.Duration, nil
+	}
+
+	if opts.Speed < 0 || opts.Speed > MaxMoveSpeed ||
+		math.IsNaN(opts.Speed) {
+		return 0, fmt.Errorf("invalid speed %.1f, should be between 0 and "+
+			"%.1f degrees/s", opts.Speed, MaxMoveSpeed)
+	}
+
+	if !distanceKnown {
+		return 0, fmt.Errorf("current gimbal attitude is unknown, use a " +
+			"duration instead of a speed")
+	}
+
+	duration := time.Duration(distance / opts.Speed * float64(time.Second))
+	if duration > MaxMoveDuration {
+		return 0, fmt.Errorf("move would take %s at %.1f degrees/s, max is "+
+			"%s", duration, opts.Speed, MaxMoveDuration)
+	}
+
+	return duration, nil
+}
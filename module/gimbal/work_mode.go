@@ -0,0 +1,41 @@
+package gimbal
+
+import "fmt"
+
+// WorkMode is the gimbal work mode. It determines how the gimbal yaw relates
+// to the chassis heading.
+//
+// The work mode values are unverified. They were not checked against a robot.
+type WorkMode uint8
+
+const (
+	// WorkModeFree means the gimbal yaw is independent from the chassis
+	// heading.
+	WorkModeFree WorkMode = iota
+	// WorkModeFPV means the gimbal yaw is locked to the chassis heading (the
+	// gimbal follows the chassis).
+	WorkModeFPV
+	// WorkModeYawFollow means the chassis turns to follow the gimbal yaw.
+	WorkModeYawFollow
+	// workModeCount is the number of work modes. Intentionaly not exported.
+	workModeCount
+)
+
+// String returns a human readable representation of the work mode.
+func (wm WorkMode) String() string {
+	switch wm {
+	case WorkModeFree:
+		return "Free"
+	case WorkModeFPV:
+		return "FPV"
+	case WorkModeYawFollow:
+		return "YawFollow"
+	default:
+		return fmt.Sprintf("Unknown(%d)", wm)
+	}
+}
+
+// Valid returns true if the work mode is valid.
+func (wm WorkMode) Valid() bool {
+	return wm < workModeCount
+}
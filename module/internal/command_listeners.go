@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/brunoga/robomaster/support/token"
+)
+
+// CommandListeners keeps track of functions to be called whenever a module
+// sends a command (described by a value of type T) to the robot. Listeners
+// are called synchronously from the goroutine sending the command, so they
+// must not block.
+type CommandListeners[T any] struct {
+	tg *token.Generator
+
+	m         sync.Mutex
+	listeners map[token.Token]func(T)
+}
+
+// NewCommandListeners creates a new CommandListeners instance.
+func NewCommandListeners[T any]() *CommandListeners[T] {
+	return &CommandListeners[T]{
+		tg:        token.NewGenerator(),
+		listeners: make(map[token.Token]func(T)),
+	}
+}
+
+// Add adds the given listener. Returns a token that can be used to remove it
+// with Remove.
+func (cl *CommandListeners[T]) Add(f func(T)) (token.Token, error) {
+	if f == nil {
+		return 0, fmt.Errorf("listener must not be nil")
+	}
+
+	t := cl.tg.Next()
+
+	cl.m.Lock()
+	cl.listeners[t] = f
+	cl.m.Unlock()
+
+	return t, nil
+}
+
+// Remove removes the listener associated with the given token.
+func (cl *CommandListeners[T]) Remove(t token.Token) error {
+	cl.m.Lock()
+	defer cl.m.Unlock()
+
+	if _, ok := cl.listeners[t]; !ok {
+		return fmt.Errorf("no command listener registered with token %d", t)
+	}
+
+	delete(cl.listeners, t)
+
+	return nil
+}
+
+// Notify calls all listeners with the given command.
+func (cl *CommandListeners[T]) Notify(v T) {
+	cl.m.Lock()
+	fs := make([]func(T), 0, len(cl.listeners))
+	for _, f := range cl.listeners {
+		fs = append(fs, f)
+	}
+	cl.m.Unlock()
+
+	for _, f := range fs {
+		f(v)
+	}
+}
@@ -0,0 +1,391 @@
+package robot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/brunoga/robomaster/support/token"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+// IMUSide is the side the robot must be resting on during a given step of an
+// IMU calibration.
+//
+// The mapping of values to sides is unverified. Sides might not match the
+// descriptions below.
+type IMUSide uint8
+
+const (
+	// IMUSideBottom means the robot must be resting on its wheels (the normal
+	// position).
+	IMUSideBottom IMUSide = iota
+	// IMUSideTop means the robot must be resting upside down.
+	IMUSideTop
+	// IMUSideFront means the robot must be resting on its front.
+	IMUSideFront
+	// IMUSideBack means the robot must be resting on its back.
+	IMUSideBack
+	// IMUSideLeft means the robot must be resting on its left side.
+	IMUSideLeft
+	// IMUSideRight means the robot must be resting on its right side.
+	IMUSideRight
+	// imuSideCount is the number of sides. Intentionaly not exported.
+	imuSideCount
+)
+
+// String returns a human readable representation of the side.
+func (s IMUSide) String() string {
+	switch s {
+	case IMUSideBottom:
+		return "Bottom"
+	case IMUSideTop:
+		return "Top"
+	case IMUSideFront:
+		return "Front"
+	case IMUSideBack:
+		return "Back"
+	case IMUSideLeft:
+		return "Left"
+	case IMUSideRight:
+		return "Right"
+	default:
+		return fmt.Sprintf("Unknown(%d)", s)
+	}
+}
+
+// Valid returns true if the side is a known side.
+func (s IMUSide) Valid() bool {
+	return s < imuSideCount
+}
+
+// IMUCalibrationState is the state of an IMU calibration.
+//
+// The mapping of values to states is unverified. States might not match the
+// descriptions below.
+type IMUCalibrationState uint8
+
+const (
+	// IMUCalibrationStateIdle means no calibration is running.
+	IMUCalibrationStateIdle IMUCalibrationState = iota
+	// IMUCalibrationStateCalibrating means a calibration is running.
+	IMUCalibrationStateCalibrating
+	// IMUCalibrationStateSucceeded means the last calibration succeeded.
+	IMUCalibrationStateSucceeded
+	// IMUCalibrationStateFailed means the last calibration failed.
+	IMUCalibrationStateFailed
+)
+
+// String returns a human readable representation of the state.
+func (s IMUCalibrationState) String() string {
+	switch s {
+	case IMUCalibrationStateIdle:
+		return "Idle"
+	case IMUCalibrationStateCalibrating:
+		return "Calibrating"
+	case IMUCalibrationStateSucceeded:
+		return "Succeeded"
+	case IMUCalibrationStateFailed:
+		return "Failed"
+	default:
+		return fmt.Sprintf("Unknown(%d)", s)
+	}
+}
+
+// IMUCalibrationProgress is a progress report for an ongoing IMU calibration.
+type IMUCalibrationProgress struct {
+	State   IMUCalibrationState
+	Side    IMUSide // Side the robot must currently be resting on.
+	Percent uint8   // Overall progress (0 to 100).
+}
+
+// IMUCalibrationError is the error returned when the robot reports an IMU
+// calibration failure. Its value is the (non-zero) fail code reported by the
+// robot so specific failures can be checked with errors.Is.
+//
+// The meaning of each fail code is unverified. Codes might not match the
+// descriptions below.
+type IMUCalibrationError uint8
+
+const (
+	// IMUCalibrationErrorTimeout is returned when the robot was not placed
+	// on the requested side in time.
+	IMUCalibrationErrorTimeout IMUCalibrationError = iota + 1
+	// IMUCalibrationErrorMoved is returned when the robot moved while a side
+	// was being calibrated.
+	IMUCalibrationErrorMoved
+	// IMUCalibrationErrorWrongSide is returned when the robot was placed on a
+	// side other than the requested one.
+	IMUCalibrationErrorWrongSide
+	// IMUCalibrationErrorSensor is returned when the IMU reported abnormal
+	// data.
+	IMUCalibrationErrorSensor
+)
+
+// Error implements the error interface.
+func (e IMUCalibrationError) Error() string {
+	switch e {
+	case IMUCalibrationErrorTimeout:
+		return "imu calibration failed: timed out waiting for robot placement"
+	case IMUCalibrationErrorMoved:
+		return "imu calibration failed: robot moved during calibration"
+	case IMUCalibrationErrorWrongSide:
+		return "imu calibration failed: robot placed on the wrong side"
+	case IMUCalibrationErrorSensor:
+		return "imu calibration failed: abnormal sensor data"
+	default:
+		return fmt.Sprintf("imu calibration failed: fail code %d", uint8(e))
+	}
+}
+
+// CalibrateIMU runs a guided IMU calibration and blocks until it completes.
+// The given function (which might be nil) is called with progress updates,
+// which include the side the robot must be resting on at each step. Returns
+// an IMUCalibrationError if the robot reports a failure.
+func (r *Robot) CalibrateIMU(f func(IMUCalibrationProgress)) error {
+	return r.CalibrateIMUContext(context.Background(), f)
+}
+
+// CalibrateIMUContext is like CalibrateIMU but honors the given context. If
+// the context is done before the calibration completes, the calibration is
+// stopped.
+func (r *Robot) CalibrateIMUContext(ctx context.Context,
+	f func(IMUCalibrationProgress)) error {
+	var m sync.Mutex
+	started := false
+	closed := false
+	p := IMUCalibrationProgress{}
+	done := make(chan IMUCalibrationState, 1)
+	finished := make(chan struct{}, 1)
+
+	// Must be called with m held.
+	report := func() {
+		if !started || closed {
+			return
+		}
+
+		if f != nil {
+			f(p)
+		}
+
+		if p.State != IMUCalibrationStateCalibrating {
+			select {
+			case done <- p.State:
+			default:
+			}
+		}
+	}
+
+	// Must be called with m held.
+	setState := func(state IMUCalibrationState) {
+		p.State = state
+
+		// The progress key might not have reached 100 yet when the success
+		// state arrives, so force it.
+		if p.State == IMUCalibrationStateSucceeded {
+			p.Percent = 100
+		}
+
+		report()
+	}
+
+	// No progress is reported after returning.
+	defer func() {
+		m.Lock()
+		closed = true
+		m.Unlock()
+	}()
+
+	// Only new pushes are listened to. A cached state might be left over
+	// from an earlier calibration and the calibration keys are not pushed in
+	// any specific order.
+	stateToken, err := r.watchIMUCalibrationKey(
+		key.KeyRobomasterMainControllerIMUCalibrationState, func(v uint64) {
+			m.Lock()
+			defer m.Unlock()
+
+			state := IMUCalibrationState(v)
+
+			// Ignore any state reported before this calibration started.
+			if !started {
+				if state != IMUCalibrationStateCalibrating {
+					return
+				}
+
+				started = true
+			}
+
+			setState(state)
+		})
+	if err != nil {
+		return err
+	}
+
+	defer r.UB().RemoveKeyListener(
+		key.KeyRobomasterMainControllerIMUCalibrationState, stateToken)
+
+	sideToken, err := r.watchIMUCalibrationKey(
+		key.KeyRobomasterMainControllerIMUCalibrationCurrSide, func(v uint64) {
+			m.Lock()
+			defer m.Unlock()
+
+			p.Side = IMUSide(v)
+
+			report()
+		})
+	if err != nil {
+		return err
+	}
+
+	defer r.UB().RemoveKeyListener(
+		key.KeyRobomasterMainControllerIMUCalibrationCurrSide, sideToken)
+
+	progressToken, err := r.watchIMUCalibrationKey(
+		key.KeyRobomasterMainControllerIMUCalibrationProgress, func(v uint64) {
+			m.Lock()
+			defer m.Unlock()
+
+			p.Percent = uint8(v)
+
+			report()
+		})
+	if err != nil {
+		return err
+	}
+
+	defer r.UB().RemoveKeyListener(
+		key.KeyRobomasterMainControllerIMUCalibrationProgress, progressToken)
+
+	finishToken, err := r.UB().AddKeyListener(
+		key.KeyRobomasterMainControllerIMUCalibrationFinishFlag,
+		func(res *result.Result) {
+			if res == nil || !res.Succeeded() {
+				return
+			}
+
+			v, ok := res.Value().(*value.Bool)
+			if !ok || !v.Value {
+				return
+			}
+
+			m.Lock()
+			defer m.Unlock()
+
+			if !started {
+				return
+			}
+
+			select {
+			case finished <- struct{}{}:
+			default:
+			}
+		}, false)
+	if err != nil {
+		return err
+	}
+
+	defer r.UB().RemoveKeyListener(
+		key.KeyRobomasterMainControllerIMUCalibrationFinishFlag, finishToken)
+
+	err = r.startIMUCalibration.Perform(ctx, value.Void{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case state := <-done:
+			return r.imuCalibrationResult(ctx, state)
+		case <-finished:
+			// The finish flag might arrive before the final state, so get
+			// the state from the robot.
+			v, err := r.imuCalibrationState.Refresh(ctx)
+			if err != nil {
+				return fmt.Errorf("error getting imu calibration state: %w",
+					err)
+			}
+
+			state := IMUCalibrationState(v.Value)
+			if state == IMUCalibrationStateCalibrating {
+				continue
+			}
+
+			// The final state might not have been pushed yet, so report it.
+			m.Lock()
+			if p.State != state {
+				setState(state)
+			}
+			m.Unlock()
+
+			return r.imuCalibrationResult(ctx, state)
+		case <-ctx.Done():
+			if err := r.StopIMUCalibration(); err != nil {
+				r.Logger().Error("Error stopping IMU calibration", "error",
+					err)
+			}
+
+			return fmt.Errorf("error waiting for imu calibration: %w",
+				ctx.Err())
+		}
+	}
+}
+
+// imuCalibrationResult returns the result of a completed IMU calibration
+// with the given final state.
+func (r *Robot) imuCalibrationResult(ctx context.Context,
+	state IMUCalibrationState) error {
+	switch state {
+	case IMUCalibrationStateSucceeded:
+		return nil
+	case IMUCalibrationStateFailed:
+		// The fail code is reported through a different key, so there is no
+		// guarantee it was already pushed. Get it from the robot.
+		failCode, err := r.imuCalibrationFailCode.Refresh(ctx)
+		if err != nil {
+			return fmt.Errorf("error getting imu calibration fail code: %w",
+				err)
+		}
+
+		if failCode.Value == 0 {
+			// Zero means no error.
+			return fmt.Errorf("imu calibration failed without a fail code")
+		}
+
+		return IMUCalibrationError(failCode.Value)
+	default:
+		return fmt.Errorf("imu calibration stopped")
+	}
+}
+
+// StopIMUCalibration stops any ongoing IMU calibration.
+func (r *Robot) StopIMUCalibration() error {
+	return r.StopIMUCalibrationContext(context.Background())
+}
+
+// StopIMUCalibrationContext is like StopIMUCalibration but honors the given
+// context.
+func (r *Robot) StopIMUCalibrationContext(ctx context.Context) error {
+	return r.stopIMUCalibration.Perform(ctx, value.Void{})
+}
+
+// watchIMUCalibrationKey calls the given function whenever a new value is
+// pushed for the given IMU calibration key.
+func (r *Robot) watchIMUCalibrationKey(k *key.Key,
+	f func(uint64)) (token.Token, error) {
+	return r.UB().AddKeyListener(k, func(res *result.Result) {
+		if res == nil || !res.Succeeded() {
+			return
+		}
+
+		v, ok := res.Value().(*value.Uint64)
+		if !ok {
+			r.Logger().Error("Unexpected IMU calibration value.", "key", k,
+				"value", res.Value())
+			return
+		}
+
+		f(v.Value)
+	}, false)
+}
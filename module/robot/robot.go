@@ -1,6 +1,7 @@
 package robot
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"sort"
@@ -13,9 +14,11 @@ import (
 	"github.com/brunoga/robomaster/support/logger"
 	"github.com/brunoga/robomaster/unitybridge"
 	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/key/typed"
 	"github.com/brunoga/robomaster/unitybridge/unity/result"
 	"github.com/brunoga/robomaster/unitybridge/unity/result/listener"
 	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/unity/task"
 )
 
 // Robot is the module that controls the robot. It provides methods to
@@ -30,6 +33,16 @@ type Robot struct {
 	workingDevicesRL      *listener.Listener
 	batteryPowerPercentRL *listener.Listener
 	actionStatusRL        *listener.Listener
+
+	tm *taskManager
+
+	speakerVolume     typed.ReadWriter[value.Uint64]
+	chassisSpeedLevel typed.ReadWriter[value.Uint64]
+
+	startIMUCalibration    typed.Performer[value.Void]
+	stopIMUCalibration     typed.Performer[value.Void]
+	imuCalibrationState    typed.Reader[value.Uint64]
+	imuCalibrationFailCode typed.Reader[value.Uint64]
 }
 
 var _ module.Module = (*Robot)(nil)
@@ -43,7 +56,9 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 
 	l = l.WithGroup("robot_module")
 
-	rb := &Robot{}
+	rb := &Robot{
+		tm: newTaskManager(),
+	}
 
 	rb.BaseModule = internal.NewBaseModule(ub, l, "Robot",
 		key.KeyRobomasterSystemConnection, func(r *result.Result) {
@@ -102,9 +117,49 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 					rb.Logger().Error("Connection: Failed to stop action "+
 						"status result listener", "error", err)
 				}
+
+				rb.tm.failAll(fmt.Errorf("%w: disconnected", ErrTaskFailed))
 			}
 		}, cm)
 
+	var err error
+
+	rb.speakerVolume, err = typed.NewReadWriter[value.Uint64](ub,
+		key.KeyRobomasterSystemSpeakerVolumn)
+	if err != nil {
+		return nil, err
+	}
+
+	rb.chassisSpeedLevel, err = typed.NewReadWriter[value.Uint64](ub,
+		key.KeyRobomasterSystemChassisSpeedLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	rb.startIMUCalibration, err = typed.NewPerformer[value.Void](ub,
+		key.KeyRobomasterMainControllerStartIMUCalibration)
+	if err != nil {
+		return nil, err
+	}
+
+	rb.stopIMUCalibration, err = typed.NewPerformer[value.Void](ub,
+		key.KeyRobomasterMainControllerStopIMUCalibration)
+	if err != nil {
+		return nil, err
+	}
+
+	rb.imuCalibrationState, err = typed.NewReader[value.Uint64](ub,
+		key.KeyRobomasterMainControllerIMUCalibrationState)
+	if err != nil {
+		return nil, err
+	}
+
+	rb.imuCalibrationFailCode, err = typed.NewReader[value.Uint64](ub,
+		key.KeyRobomasterMainControllerIMUCalibrationFailCode)
+	if err != nil {
+		return nil, err
+	}
+
 	functions := make(map[FunctionType]bool)
 	rb.functions.Store(&functions)
 
@@ -136,6 +191,12 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 // previoudly enabled/didabled functions and always sends the full list with
 // the current status of all functions to the Unity Bridge.
 func (r *Robot) EnableFunction(ft FunctionType, enable bool) error {
+	return r.EnableFunctionContext(context.Background(), ft, enable)
+}
+
+// EnableFunctionContext is like EnableFunction but honors the given context.
+func (r *Robot) EnableFunctionContext(ctx context.Context,
+	ft FunctionType, enable bool) error {
 	var newFunctions map[FunctionType]bool
 	for {
 		oldFunctionsPtr := r.functions.Load()
@@ -163,7 +224,8 @@ func (r *Robot) EnableFunction(ft FunctionType, enable bool) error {
 		})
 	}
 
-	err := r.UB().PerformActionForKeySync(key.KeyRobomasterSystemFunctionEnable,
+	err := r.UB().PerformActionForKeySyncContext(ctx,
+		key.KeyRobomasterSystemFunctionEnable,
 		v)
 	if err != nil {
 		return err
@@ -206,18 +268,29 @@ func (r *Robot) Devices() []DeviceType {
 
 // SpeakerVolume returns the current speaker volume.
 func (r *Robot) SpeakerVolume() (uint8, error) {
-	res, err := r.UB().GetKeyValueSync(key.KeyRobomasterSystemSpeakerVolumn, true)
+	return r.SpeakerVolumeContext(context.Background())
+}
+
+// SpeakerVolumeContext is like SpeakerVolume but honors the given context.
+func (r *Robot) SpeakerVolumeContext(ctx context.Context) (uint8, error) {
+	v, err := r.speakerVolume.Get(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	return uint8(res.Value().(*value.Uint64).Value), nil
+	return uint8(v.Value), nil
 }
 
 // SetSpeakerVolume sets the speaker volume.
 func (r *Robot) SetSpeakerVolume(volume uint8) error {
-	return r.UB().SetKeyValueSync(key.KeyRobomasterSystemSpeakerVolumn,
-		&value.Uint64{Value: uint64(volume)})
+	return r.SetSpeakerVolumeContext(context.Background(), volume)
+}
+
+// SetSpeakerVolumeContext is like SetSpeakerVolume but honors the given
+// context.
+func (r *Robot) SetSpeakerVolumeContext(ctx context.Context,
+	volume uint8) error {
+	return r.speakerVolume.Set(ctx, value.Uint64{Value: uint64(volume)})
 }
 
 // BatteryPowerPercent returns the current battery power percent.
@@ -227,22 +300,57 @@ func (r *Robot) BatteryPowerPercent() uint8 {
 
 // ChassisSpeedLevel returns the current chassis speed level.
 func (r *Robot) ChassisSpeedLevel() (ChassisSpeedLevel, error) {
-	res, err := r.UB().GetKeyValueSync(key.KeyRobomasterSystemChassisSpeedLevel, true)
+	return r.ChassisSpeedLevelContext(context.Background())
+}
+
+// ChassisSpeedLevelContext is like ChassisSpeedLevel but honors the given
+// context.
+func (r *Robot) ChassisSpeedLevelContext(ctx context.Context) (ChassisSpeedLevel, error) {
+	v, err := r.chassisSpeedLevel.Get(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	return ChassisSpeedLevel(res.Value().(*value.Uint64).Value - 1), nil
+	return ChassisSpeedLevel(v.Value - 1), nil
 }
 
 // SetChassisSpeedLevel sets the chassis speed level.
 func (r *Robot) SetChassisSpeedLevel(speedLevel ChassisSpeedLevel) error {
+	return r.SetChassisSpeedLevelContext(context.Background(), speedLevel)
+}
+
+// SetChassisSpeedLevelContext is like SetChassisSpeedLevel but honors the given
+// context.
+func (r *Robot) SetChassisSpeedLevelContext(ctx context.Context,
+	speedLevel ChassisSpeedLevel) error {
 	if speedLevel >= ChassisSpeedLevelTypeCount {
 		return fmt.Errorf("invalid chassis speed level: %d", speedLevel)
 	}
 
-	return r.UB().SetKeyValueSync(key.KeyRobomasterSystemChassisSpeedLevel,
-		&value.Uint64{Value: uint64(speedLevel + 1)})
+	return r.chassisSpeedLevel.Set(ctx,
+		value.Uint64{Value: uint64(speedLevel + 1)})
+}
+
+// StartTask creates a new task of the given type and calls start to start it
+// on the robot. Task status pushes are correlated with it by type. The given
+// cancel function (which might be nil) is called to cancel the task on the
+// robot. Any running task of the same type is superseded by the new one.
+func (r *Robot) StartTask(ctx context.Context, typ task.Type, start,
+	cancel TaskFunc) (*Task, error) {
+	t, err := r.tm.newTask(typ, cancel)
+	if err != nil {
+		return nil, err
+	}
+
+	err = start(ctx)
+	if err != nil {
+		// The task never started on the robot so there is nothing to cancel
+		// there.
+		r.tm.finish(t, task.StatusFailure, err)
+		return nil, err
+	}
+
+	return t, nil
 }
 
 // Stop stops the Robot module.
@@ -252,6 +360,8 @@ func (r *Robot) Stop() error {
 		return err
 	}
 
+	r.tm.failAll(fmt.Errorf("%w: module stopped", ErrTaskFailed))
+
 	err = r.batteryPowerPercentRL.Stop()
 	if err != nil {
 		return err
@@ -310,10 +420,21 @@ func (r *Robot) onBatteryPowerPercent(res *result.Result) {
 }
 
 func (r *Robot) onActionStatus(res *result.Result) {
-	// Just log for now.
-	//
-	// TODO(bga): Implement me.
-	r.Logger().Debug("Action status", "result", res)
+	if res == nil || !res.Succeeded() {
+		r.Logger().Error("Unexpected action status result.", "result", res)
+		return
+	}
+
+	value, ok := res.Value().(*value.TaskStatus)
+	if !ok {
+		r.Logger().Error("Unexpected action status value.", "value",
+			res.Value())
+		return
+	}
+
+	r.Logger().Debug("Action status", "status", value)
+
+	r.tm.update(value)
 }
 
 func (r *Robot) checkDiff(oldWds, newWds map[DeviceType]struct{}) (
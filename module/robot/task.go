@@ -0,0 +1,315 @@
+package robot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/unity/task"
+)
+
+var (
+	// ErrTaskCanceled is returned by Task.Wait when the task was canceled.
+	ErrTaskCanceled = errors.New("task canceled")
+
+	// ErrTaskSuperseded is returned by Task.Wait when another task of the
+	// same type was started before the task completed.
+	ErrTaskSuperseded = errors.New("task superseded")
+
+	// ErrTaskFailed is returned by Task.Wait when the robot reports the task
+	// failed.
+	ErrTaskFailed = errors.New("task failed")
+)
+
+// TaskFunc is a function that acts (starts or cancels) on a task on the robot.
+type TaskFunc func(ctx context.Context) error
+
+// Task is a handle to a long running action (like moving the chassis to a
+// specific position) being executed by the robot.
+type Task struct {
+	id     uint8
+	typ    task.Type
+	cancel TaskFunc
+	tm     *taskManager
+
+	// started is set when the first running status is received for the
+	// task. Guarded by tm.m.
+	started bool
+
+	m         sync.Mutex
+	percent   float64
+	status    task.Status
+	err       error
+	canceling bool
+	done      chan struct{}
+}
+
+// ID returns the task ID. IDs are assigned locally to tell tasks apart and are
+// not sent to the robot.
+func (t *Task) ID() uint8 {
+	return t.id
+}
+
+// Type returns the task type.
+func (t *Task) Type() task.Type {
+	return t.typ
+}
+
+// Progress returns the last reported task progress (0 to 100).
+func (t *Task) Progress() float64 {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	return t.percent
+}
+
+// Status returns the last reported task status.
+func (t *Task) Status() task.Status {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	return t.status
+}
+
+// Done returns a channel that is closed when the task completes (either
+// successfully or not).
+func (t *Task) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait waits for the task to complete or for the given context to be done.
+// It returns nil if the task completed successfully.
+func (t *Task) Wait(ctx context.Context) error {
+	select {
+	case <-t.done:
+		t.m.Lock()
+		defer t.m.Unlock()
+
+		return t.err
+	case <-ctx.Done():
+		return fmt.Errorf("error waiting for task %d: %w", t.id, ctx.Err())
+	}
+}
+
+// Cancel cancels the task. It is a no-op if the task already completed.
+func (t *Task) Cancel() error {
+	return t.CancelContext(context.Background())
+}
+
+// CancelContext is like Cancel but honors the given context.
+func (t *Task) CancelContext(ctx context.Context) error {
+	select {
+	case <-t.done:
+		return nil
+	default:
+	}
+
+	// The robot might report the task failure before the cancel function
+	// returns, so make sure it is reported as a cancellation.
+	t.setCanceling(true)
+
+	if t.cancel != nil {
+		if err := t.cancel(ctx); err != nil {
+			t.setCanceling(false)
+			return err
+		}
+	}
+
+	t.tm.abandon(t, ErrTaskCanceled)
+
+	return nil
+}
+
+// String returns a string representation of the task.
+func (t *Task) String() string {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	return fmt.Sprintf("Task{ID: %d, Type: %d, Status: %d, Percent: %.1f}",
+		t.id, t.typ, t.status, t.percent)
+}
+
+func (t *Task) setCanceling(canceling bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	t.canceling = canceling
+}
+
+// update updates the task with the given status. It returns true if the task
+// completed.
+func (t *Task) update(ts *value.TaskStatus) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	t.percent = ts.Percent
+	t.status = ts.Status
+
+	return ts.Status != task.StatusRunning
+}
+
+// taskManager assigns task IDs and correlates task status pushes with
+// running tasks. Status pushes only identify the task type so there is at most
+// one running task per type.
+//
+// Tasks that are finished locally (superseded or canceled) might still get a
+// final status pushed by the robot. As those can not be told apart from the
+// ones for a new task of the same type, up to one final status per locally
+// finished task is ignored until the new task is reported as running.
+type taskManager struct {
+	m      sync.Mutex
+	lastID uint8
+	byType map[task.Type]*Task
+	stale  map[task.Type]int
+}
+
+func newTaskManager() *taskManager {
+	return &taskManager{
+		byType: make(map[task.Type]*Task),
+		stale:  make(map[task.Type]int),
+	}
+}
+
+// newTask creates and registers a new task of the given type. Any running
+// task of the same type is superseded as the robot only runs one task of each
+// type at a time.
+func (tm *taskManager) newTask(typ task.Type, cancel TaskFunc) (*Task,
+	error) {
+	tm.m.Lock()
+
+	// IDs are non-zero and, as there are only a few task types, unique among
+	// running tasks.
+	tm.lastID++
+	if tm.lastID == 0 {
+		tm.lastID = 1
+	}
+
+	id := tm.lastID
+
+	previous := tm.byType[typ]
+
+	t := &Task{
+		id:     id,
+		typ:    typ,
+		cancel: cancel,
+		tm:     tm,
+		done:   make(chan struct{}),
+	}
+
+	tm.byType[typ] = t
+
+	tm.m.Unlock()
+
+	if previous != nil {
+		tm.abandon(previous, ErrTaskSuperseded)
+	}
+
+	return t, nil
+}
+
+// update correlates the given status with the running task of the same type
+// and updates it. Statuses for types with no running task and final statuses
+// for tasks that were finished locally are ignored.
+func (tm *taskManager) update(ts *value.TaskStatus) {
+	tm.m.Lock()
+	t := tm.byType[ts.TaskType]
+
+	if ts.Status == task.StatusRunning {
+		// The robot moved on to the current task.
+		delete(tm.stale, ts.TaskType)
+
+		if t != nil {
+			t.started = true
+		}
+	} else if tm.stale[ts.TaskType] > 0 && (t == nil || !t.started) {
+		// Most likely the final status of a task that was finished
+		// locally.
+		tm.stale[ts.TaskType]--
+		tm.m.Unlock()
+
+		return
+	}
+	tm.m.Unlock()
+
+	if t == nil {
+		return
+	}
+
+	if !t.update(ts) {
+		return
+	}
+
+	var err error
+	if ts.Status != task.StatusSuccess {
+		t.m.Lock()
+		err = ErrTaskFailed
+		if t.canceling {
+			err = ErrTaskCanceled
+		}
+		t.m.Unlock()
+	}
+
+	tm.finish(t, ts.Status, err)
+}
+
+// abandon finishes the given task locally (with a failure status and the
+// given error) before the robot reported its final status. That final status
+// is ignored if it arrives later.
+func (tm *taskManager) abandon(t *Task, err error) {
+	// Count it before finishing so a final status pushed in between is not
+	// taken as the one of a new task.
+	tm.m.Lock()
+	tm.stale[t.typ]++
+	tm.m.Unlock()
+
+	if !tm.finish(t, task.StatusFailure, err) {
+		tm.m.Lock()
+		if tm.stale[t.typ] > 0 {
+			tm.stale[t.typ]--
+		}
+		tm.m.Unlock()
+	}
+}
+
+// finish marks the given task as completed with the given status and error.
+// It returns false if the task was already completed.
+func (tm *taskManager) finish(t *Task, status task.Status, err error) bool {
+	tm.m.Lock()
+	if tm.byType[t.typ] == t {
+		delete(tm.byType, t.typ)
+	}
+	tm.m.Unlock()
+
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	select {
+	case <-t.done:
+		// Already finished.
+		return false
+	default:
+	}
+
+	t.status = status
+	t.err = err
+
+	close(t.done)
+
+	return true
+}
+
+// failAll finishes all running tasks with the given error.
+func (tm *taskManager) failAll(err error) {
+	tm.m.Lock()
+	tasks := make([]*Task, 0, len(tm.byType))
+	for _, t := range tm.byType {
+		tasks = append(tasks, t)
+	}
+	tm.m.Unlock()
+
+	for _, t := range tasks {
+		tm.finish(t, task.StatusFailure, err)
+	}
+}
@@ -1,6 +1,7 @@
 package sdcard
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 
@@ -52,7 +53,13 @@ func New(ub unitybridge.UnityBridge, l *logger.Logger,
 }
 
 func (m *Module) IsInserted() (bool, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardIsInserted, false)
+	return m.IsInsertedContext(context.Background())
+}
+
+// IsInsertedContext is like IsInserted but honors the given context.
+func (m *Module) IsInsertedContext(ctx context.Context) (bool, error) {
+	r, err := m.UB().GetKeyValueSyncContext(ctx,
+		key.KeyCameraSDCardIsInserted, false)
 	if err != nil {
 		return false, err
 	}
@@ -70,7 +77,13 @@ func (m *Module) IsInserted() (bool, error) {
 }
 
 func (m *Module) Format() error {
-	err := m.UB().PerformActionForKeySync(key.KeyCameraFormatSDCard, nil)
+	return m.FormatContext(context.Background())
+}
+
+// FormatContext is like Format but honors the given context.
+func (m *Module) FormatContext(ctx context.Context) error {
+	err := m.UB().PerformActionForKeySyncContext(ctx,
+		key.KeyCameraFormatSDCard, nil)
 	if err != nil {
 		return err
 	}
@@ -79,7 +92,13 @@ func (m *Module) Format() error {
 }
 
 func (m *Module) IsFormatting() (bool, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardIsFormatting, false)
+	return m.IsFormattingContext(context.Background())
+}
+
+// IsFormattingContext is like IsFormatting but honors the given context.
+func (m *Module) IsFormattingContext(ctx context.Context) (bool, error) {
+	r, err := m.UB().GetKeyValueSyncContext(ctx,
+		key.KeyCameraSDCardIsFormatting, false)
 	if err != nil {
 		return false, err
 	}
@@ -97,7 +116,13 @@ func (m *Module) IsFormatting() (bool, error) {
 }
 
 func (m *Module) IsFull() (bool, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardIsFull, false)
+	return m.IsFullContext(context.Background())
+}
+
+// IsFullContext is like IsFull but honors the given context.
+func (m *Module) IsFullContext(ctx context.Context) (bool, error) {
+	r, err := m.UB().GetKeyValueSyncContext(ctx,
+		key.KeyCameraSDCardIsFull, false)
 	if err != nil {
 		return false, err
 	}
@@ -115,7 +140,13 @@ func (m *Module) IsFull() (bool, error) {
 }
 
 func (m *Module) HasError() (bool, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardHasError, false)
+	return m.HasErrorContext(context.Background())
+}
+
+// HasErrorContext is like HasError but honors the given context.
+func (m *Module) HasErrorContext(ctx context.Context) (bool, error) {
+	r, err := m.UB().GetKeyValueSyncContext(ctx,
+		key.KeyCameraSDCardHasError, false)
 	if err != nil {
 		return false, err
 	}
@@ -133,7 +164,13 @@ func (m *Module) HasError() (bool, error) {
 }
 
 func (m *Module) TotalSpaceInMB() (uint64, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardTotalSpaceInMB, false)
+	return m.TotalSpaceInMBContext(context.Background())
+}
+
+// TotalSpaceInMBContext is like TotalSpaceInMB but honors the given context.
+func (m *Module) TotalSpaceInMBContext(ctx context.Context) (uint64, error) {
+	r, err := m.UB().GetKeyValueSyncContext(ctx,
+		key.KeyCameraSDCardTotalSpaceInMB, false)
 	if err != nil {
 		return 0, err
 	}
@@ -151,7 +188,14 @@ func (m *Module) TotalSpaceInMB() (uint64, error) {
 }
 
 func (m *Module) RemainingSpaceInMB() (uint64, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardRemainingSpaceInMB, false)
+	return m.RemainingSpaceInMBContext(context.Background())
+}
+
+// RemainingSpaceInMBContext is like RemainingSpaceInMB but honors the given
+// context.
+func (m *Module) RemainingSpaceInMBContext(ctx context.Context) (uint64, error) {
+	r, err := m.UB().GetKeyValueSyncContext(ctx,
+		key.KeyCameraSDCardRemainingSpaceInMB, false)
 	if err != nil {
 		return 0, err
 	}
@@ -169,7 +213,14 @@ func (m *Module) RemainingSpaceInMB() (uint64, error) {
 }
 
 func (m *Module) AvailablePhotoCount() (uint64, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardAvailablePhotoCount, false)
+	return m.AvailablePhotoCountContext(context.Background())
+}
+
+// AvailablePhotoCountContext is like AvailablePhotoCount but honors the given
+// context.
+func (m *Module) AvailablePhotoCountContext(ctx context.Context) (uint64, error) {
+	r, err := m.UB().GetKeyValueSyncContext(ctx,
+		key.KeyCameraSDCardAvailablePhotoCount, false)
 	if err != nil {
 		return 0, err
 	}
@@ -187,7 +238,14 @@ func (m *Module) AvailablePhotoCount() (uint64, error) {
 }
 
 func (m *Module) AvailableRecordingTimeInSeconds() (uint64, error) {
-	r, err := m.UB().GetKeyValueSync(key.KeyCameraSDCardAvailableRecordingTimeInSeconds, false)
+	return m.AvailableRecordingTimeInSecondsContext(context.Background())
+}
+
+// AvailableRecordingTimeInSecondsContext is like
+// AvailableRecordingTimeInSeconds but honors the given context.
+func (m *Module) AvailableRecordingTimeInSecondsContext(ctx context.Context) (uint64, error) {
+	r, err := m.UB().GetKeyValueSyncContext(ctx,
+		key.KeyCameraSDCardAvailableRecordingTimeInSeconds, false)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,409 @@
+// Package watchdog provides an opt-in safety layer that stops the robot when
+// the application stops sending motion commands or the connection to the robot
+// is lost.
+package watchdog
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+	"github.com/brunoga/robomaster/module/connection"
+	"github.com/brunoga/robomaster/module/controller"
+	"github.com/brunoga/robomaster/module/gimbal"
+	"github.com/brunoga/robomaster/support/dispatcher"
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+// Reason is the reason the watchdog stopped the robot.
+type Reason uint8
+
+const (
+	// ReasonTimeout means no motion command was sent within the watchdog
+	// timeout while the robot was moving.
+	ReasonTimeout Reason = iota
+	// ReasonDisconnected means the connection to the robot was lost while
+	// it was moving.
+	ReasonDisconnected
+)
+
+// String returns a human readable representation of the reason.
+func (r Reason) String() string {
+	switch r {
+	case ReasonTimeout:
+		return "Timeout"
+	case ReasonDisconnected:
+		return "Disconnected"
+	default:
+		return fmt.Sprintf("Unknown(%d)", r)
+	}
+}
+
+// Watchdog tracks continuous motion commands (chassis speeds, gimbal rotation
+// speeds and controller stick positions) and stops that motion if no new
+// command is sent within a given timeout while the robot is moving or if the
+// connection to the robot is lost. Commands that stop motion disarm the
+// watchdog until a new command makes the robot move again.
+type Watchdog struct {
+	l       *logger.Logger
+	cm      *connection.Connection
+	ch      *chassis.Chassis
+	gb      *gimbal.Gimbal
+	ctrl    *controller.Controller
+	timeout time.Duration
+
+	tg *token.Generator
+
+	m         sync.Mutex
+	started   bool
+	state     motionState
+	last      time.Time
+	timer     *time.Timer
+	listeners map[token.Token]*dispatcher.Dispatcher[Reason]
+
+	connectionToken token.Token
+	chassisToken    token.Token
+	gimbalToken     token.Token
+	controllerToken token.Token
+}
+
+// motionState is the motion the robot was commanded to do through the
+// tracked modules.
+type motionState struct {
+	chassisMoving    bool
+	gimbalMoving     bool
+	controllerMoving bool
+	chassisMode      chassis.Mode
+	controllerMode   controller.Mode
+}
+
+// New creates a new Watchdog instance that stops the robot if no motion
+// command is sent within the given timeout while it is moving. Commands sent
+// through any of the given chassis, gimbal and controller modules (which
+// might be nil) are tracked and only the modules that were moving are
+// stopped. Controller commands are stopped by centering the sticks. If cm is
+// not nil, the robot is also stopped when the connection is lost.
+func New(l *logger.Logger, cm *connection.Connection, ch *chassis.Chassis,
+	gb *gimbal.Gimbal, ctrl *controller.Controller,
+	timeout time.Duration) (*Watchdog, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("invalid watchdog timeout: %s", timeout)
+	}
+
+	if ch == nil && gb == nil && ctrl == nil {
+		return nil, fmt.Errorf("at least one motion module is required")
+	}
+
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	l = l.WithGroup("watchdog")
+
+	return &Watchdog{
+		l:         l,
+		cm:        cm,
+		ch:        ch,
+		gb:        gb,
+		ctrl:      ctrl,
+		timeout:   timeout,
+		tg:        token.NewGenerator(),
+		state:     motionState{chassisMode: chassis.ModeNone},
+		listeners: make(map[token.Token]*dispatcher.Dispatcher[Reason]),
+	}, nil
+}
+
+// Start starts tracking motion commands.
+func (w *Watchdog) Start() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if w.started {
+		return fmt.Errorf("watchdog already started")
+	}
+
+	var err error
+
+	if w.cm != nil {
+		w.connectionToken, err = w.cm.UB().AddKeyListener(
+			key.KeyAirLinkConnection, w.onConnection, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.ch != nil {
+		w.chassisToken, err = w.ch.AddMotionCommandListener(
+			func(mc chassis.MotionCommand) {
+				w.onCommand(func() {
+					w.state.chassisMoving = mc.Moving
+					w.state.chassisMode = mc.Mode
+				})
+			})
+		if err != nil {
+			w.removeListeners()
+			return err
+		}
+	}
+
+	if w.gb != nil {
+		w.gimbalToken, err = w.gb.AddMotionCommandListener(func(moving bool) {
+			w.onCommand(func() {
+				w.state.gimbalMoving = moving
+			})
+		})
+		if err != nil {
+			w.removeListeners()
+			return err
+		}
+	}
+
+	if w.ctrl != nil {
+		w.controllerToken, err = w.ctrl.AddMotionCommandListener(
+			func(mc controller.MotionCommand) {
+				w.onCommand(func() {
+					w.state.controllerMoving = mc.Moving
+					w.state.controllerMode = mc.Mode
+				})
+			})
+		if err != nil {
+			w.removeListeners()
+			return err
+		}
+	}
+
+	w.started = true
+
+	return nil
+}
+
+// Armed returns true if the robot is moving due to a tracked motion command
+// (i.e. the watchdog will stop it if no new command is sent in time).
+func (w *Watchdog) Armed() bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	return w.armedLocked()
+}
+
+// AddTripListener adds a listener that is called with the reason whenever
+// the watchdog stops the robot. Listeners are kept when the watchdog is
+// stopped so they are called again if it is restarted. Returns a token that can
+// be used to remove the listener.
+func (w *Watchdog) AddTripListener(f func(Reason),
+	o *dispatcher.Options) (token.Token, error) {
+	if f == nil {
+		return 0, fmt.Errorf("listener must not be nil")
+	}
+
+	d, err := dispatcher.New(o, f)
+	if err != nil {
+		return 0, err
+	}
+
+	t := w.tg.Next()
+
+	w.m.Lock()
+	w.listeners[t] = d
+	w.m.Unlock()
+
+	return t, nil
+}
+
+// RemoveTripListener removes the trip listener associated with the given
+// token.
+func (w *Watchdog) RemoveTripListener(t token.Token) error {
+	w.m.Lock()
+	d, ok := w.listeners[t]
+	delete(w.listeners, t)
+	w.m.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no trip listener registered with token %d", t)
+	}
+
+	d.Stop()
+
+	return nil
+}
+
+// Stop stops tracking motion commands. It does not stop the robot and does not
+// remove trip listeners.
+func (w *Watchdog) Stop() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if !w.started {
+		return fmt.Errorf("watchdog not started")
+	}
+
+	w.removeListeners()
+
+	w.disarmLocked()
+
+	w.started = false
+
+	return nil
+}
+
+// onCommand updates the motion state with the given function and arms or
+// disarms the watchdog accordingly.
+func (w *Watchdog) onCommand(update func()) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	update()
+
+	if !w.armedLocked() {
+		w.disarmLocked()
+		return
+	}
+
+	w.last = time.Now()
+
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.timeout, w.onTimeout)
+	} else {
+		w.timer.Reset(w.timeout)
+	}
+}
+
+func (w *Watchdog) onTimeout() {
+	w.m.Lock()
+
+	// A command might have been sent right when the timer fired.
+	if !w.armedLocked() || time.Since(w.last) < w.timeout {
+		w.m.Unlock()
+		return
+	}
+
+	state := w.state
+	w.disarmLocked()
+	w.m.Unlock()
+
+	w.trip(ReasonTimeout, state)
+}
+
+func (w *Watchdog) onConnection(r *result.Result) {
+	if r == nil || !r.Succeeded() {
+		return
+	}
+
+	connected, ok := r.Value().(*value.Bool)
+	if !ok || connected.Value {
+		return
+	}
+
+	w.m.Lock()
+
+	if !w.armedLocked() {
+		w.m.Unlock()
+		return
+	}
+
+	state := w.state
+	w.disarmLocked()
+	w.m.Unlock()
+
+	w.trip(ReasonDisconnected, state)
+}
+
+// trip stops the motion described by the given state and notifies listeners.
+// The watchdog must have been disarmed already.
+func (w *Watchdog) trip(reason Reason, state motionState) {
+	w.l.Warn("Stopping robot.", "reason", reason)
+
+	if state.controllerMoving {
+		err := w.ctrl.Move(&controller.StickPosition{},
+			&controller.StickPosition{}, state.controllerMode)
+		if err != nil {
+			w.l.Error("Error centering controller sticks.", "error", err)
+		}
+	}
+
+	if state.chassisMoving {
+		err := w.ch.StopMovement(state.chassisMode)
+		if err != nil {
+			w.l.Error("Error stopping chassis movement.", "error", err)
+		}
+
+		// Wheel speed commands (ModeNone) are not stopped by the chassis
+		// speed mode disable word.
+		if state.chassisMode == chassis.ModeNone {
+			if err := w.ch.SetWheelSpeed(0, 0, 0, 0); err != nil {
+				w.l.Error("Error stopping wheels.", "error", err)
+			}
+		}
+	}
+
+	if state.gimbalMoving {
+		if err := w.gb.StopRotation(); err != nil {
+			w.l.Error("Error stopping gimbal rotation.", "error", err)
+		}
+	}
+
+	w.m.Lock()
+	ds := make([]*dispatcher.Dispatcher[Reason], 0, len(w.listeners))
+	for _, d := range w.listeners {
+		ds = append(ds, d)
+	}
+	w.m.Unlock()
+
+	for _, d := range ds {
+		d.Dispatch(reason)
+	}
+}
+
+func (w *Watchdog) armedLocked() bool {
+	return w.state.chassisMoving || w.state.gimbalMoving ||
+		w.state.controllerMoving
+}
+
+func (w *Watchdog) disarmLocked() {
+	w.state.chassisMoving = false
+	w.state.gimbalMoving = false
+	w.state.controllerMoving = false
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+func (w *Watchdog) removeListeners() {
+	if w.connectionToken != 0 {
+		if err := w.cm.UB().RemoveKeyListener(key.KeyAirLinkConnection,
+			w.connectionToken); err != nil {
+			w.l.Error("Error removing connection listener.", "error", err)
+		}
+		w.connectionToken = 0
+	}
+
+	if w.chassisToken != 0 {
+		if err := w.ch.RemoveMotionCommandListener(
+			w.chassisToken); err != nil {
+			w.l.Error("Error removing chassis listener.", "error", err)
+		}
+		w.chassisToken = 0
+	}
+
+	if w.gimbalToken != 0 {
+		if err := w.gb.RemoveMotionCommandListener(w.gimbalToken); err != nil {
+			w.l.Error("Error removing gimbal listener.", "error", err)
+		}
+		w.gimbalToken = 0
+	}
+
+	if w.controllerToken != 0 {
+		if err := w.ctrl.RemoveMotionCommandListener(
+			w.controllerToken); err != nil {
+			w.l.Error("Error removing controller listener.", "error", err)
+		}
+		w.controllerToken = 0
+	}
+}
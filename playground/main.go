@@ -1,13 +1,13 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"net"
 	"time"
 
 	"github.com/brunoga/net/client"
 	"github.com/brunoga/net/server"
+	"github.com/brunoga/robomaster/support/duml/session"
 	"github.com/brunoga/robomaster/support/finder"
 )
 
@@ -47,13 +47,20 @@ func connectFromPort10608ToPort10607(robotIP net.IP) *client.Client {
 	var c *client.Client
 
 	c, err = client.NewWithConn(conn, client.ScanFullBuffer, func(data []byte) {
-		if n := bytes.Index(data, []byte{0x55}); n != -1 {
-			m, err := NewMessage(data[n:])
-			if err == nil {
-				fmt.Printf("** Message: MsgSet:%02x MsgID:%02x\n", m.ProtoCmdSet(), m.ProtoCmdID())
-				return
-			}
+		p, err := session.Decode(data)
+		if err != nil {
+			fmt.Println("Received invalid packet:", err)
+			return
+		}
+
+		messages := p.Messages()
+		if len(messages) == 0 {
 			fmt.Println("Received packet with no message")
+			return
+		}
+
+		for _, m := range messages {
+			fmt.Println("** Message:", m)
 		}
 	})
 	if err != nil {
@@ -136,7 +143,7 @@ func main() {
 	// byte is 0x10 (0b10000) and the next one is 0x04 (0b00000100). According
 	// to the code pointed above, this encodes a size value of 0x10 (16) bytes,
 	// which matches the data size from the 55 value (inclusive) on. The next
-	// byte is a crc (we already have the code for that TODO(bga): add it here).
+	// byte is a crc (see the support/duml package).
 	// The next 2 bytes are sender and receiver. The next one is the lower 8
 	// bits of the sequence id, followed by the upper 8 bits. The an attribute
 	// byte (is_ack, need_ack, enc). Then if there is a proto associated with
@@ -160,7 +167,8 @@ func main() {
 	}
 
 	// This worked and we got a lot of data back but then the connection is
-	// closed. There must be some keepalive packets that are required.
+	// closed. There must be some keepalive packets that are required (see
+	// session.Keepalive).
 
 	select {}
 }
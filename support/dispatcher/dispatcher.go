@@ -0,0 +1,228 @@
+// Package dispatcher provides ordered, bounded delivery of values to a
+// function running in its own goroutine.
+package dispatcher
+
+import (
+	"fmt"
+	"sync"
+)
+
+// OverflowPolicy determines what happens when a value is dispatched and the
+// queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowPolicyBlock blocks the caller until there is space in the
+	// queue. No values are lost but a slow consumer slows down (and might
+	// stall) the producer.
+	OverflowPolicyBlock OverflowPolicy = iota
+
+	// OverflowPolicyDropOldest drops the oldest queued value to make space
+	// for the new one.
+	OverflowPolicyDropOldest
+
+	// OverflowPolicyCoalesceLatest replaces the most recently queued value
+	// with the new one. Combined with a queue size of 1, this means consumers
+	// always get the latest value available when they are ready for it.
+	OverflowPolicyCoalesceLatest
+)
+
+// String returns a string representation of the policy.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowPolicyBlock:
+		return "Block"
+	case OverflowPolicyDropOldest:
+		return "DropOldest"
+	case OverflowPolicyCoalesceLatest:
+		return "CoalesceLatest"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", p)
+}
+
+// Options controls how values are queued.
+type Options struct {
+	// QueueSize is the maximum number of values waiting to be delivered.
+	// Zero means DefaultOptions.QueueSize.
+	QueueSize int
+
+	// OverflowPolicy is what to do when a value is dispatched and the queue
+	// is full.
+	OverflowPolicy OverflowPolicy
+}
+
+// DefaultOptions are the options used when none are given.
+var DefaultOptions = Options{
+	QueueSize:      64,
+	OverflowPolicy: OverflowPolicyBlock,
+}
+
+// Dispatcher delivers dispatched values, in order, to a function that runs in
+// a dedicated goroutine.
+type Dispatcher[T any] struct {
+	f func(T)
+	o Options
+
+	m        sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	queue    []T
+	dropped  uint64
+	stopped  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New returns a new Dispatcher that calls f for each dispatched value. If o
+// is nil, DefaultOptions is used.
+func New[T any](o *Options, f func(T)) (*Dispatcher[T], error) {
+	if f == nil {
+		return nil, fmt.Errorf("function cannot be nil")
+	}
+
+	if o == nil {
+		o = &DefaultOptions
+	}
+
+	options := *o
+	if options.QueueSize == 0 {
+		options.QueueSize = DefaultOptions.QueueSize
+	}
+
+	if options.QueueSize < 0 {
+		return nil, fmt.Errorf("invalid queue size: %d", options.QueueSize)
+	}
+
+	switch options.OverflowPolicy {
+	case OverflowPolicyBlock, OverflowPolicyDropOldest,
+		OverflowPolicyCoalesceLatest:
+	default:
+		return nil, fmt.Errorf("invalid overflow policy: %s",
+			options.OverflowPolicy)
+	}
+
+	d := &Dispatcher[T]{
+		f:     f,
+		o:     options,
+		queue: make([]T, 0, options.QueueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	d.notEmpty = sync.NewCond(&d.m)
+	d.notFull = sync.NewCond(&d.m)
+
+	go d.loop()
+
+	return d, nil
+}
+
+// Dispatch queues the given value for delivery, applying the overflow policy
+// if the queue is full. Values dispatched after Stop is called are ignored.
+func (d *Dispatcher[T]) Dispatch(v T) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.stopped {
+		return
+	}
+
+	if len(d.queue) == d.o.QueueSize {
+		switch d.o.OverflowPolicy {
+		case OverflowPolicyBlock:
+			for len(d.queue) == d.o.QueueSize && !d.stopped {
+				d.notFull.Wait()
+			}
+
+			if d.stopped {
+				return
+			}
+		case OverflowPolicyDropOldest:
+			var zero T
+			d.queue[0] = zero
+			d.queue = append(d.queue[:0], d.queue[1:]...)
+			d.dropped++
+		case OverflowPolicyCoalesceLatest:
+			d.queue[len(d.queue)-1] = v
+			d.dropped++
+			return
+		}
+	}
+
+	d.queue = append(d.queue, v)
+
+	d.notEmpty.Signal()
+}
+
+// Dropped returns the number of values that were dropped or coalesced due to
+// the queue being full.
+func (d *Dispatcher[T]) Dropped() uint64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.dropped
+}
+
+// Stop stops the dispatcher. Queued values are discarded and callers blocked
+// in Dispatch are released. It does not wait for a delivery in progress to
+// finish (so it can be called from the delivery function itself). Use Done
+// for that.
+func (d *Dispatcher[T]) Stop() {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.stopped {
+		return
+	}
+
+	d.stopped = true
+	d.queue = nil
+
+	close(d.stop)
+
+	d.notEmpty.Broadcast()
+	d.notFull.Broadcast()
+}
+
+// Stopped returns a channel that is closed when Stop is called. Delivery
+// functions that might block can use it to bail out.
+func (d *Dispatcher[T]) Stopped() <-chan struct{} {
+	return d.stop
+}
+
+// Done returns a channel that is closed after Stop is called and any delivery
+// in progress finishes.
+func (d *Dispatcher[T]) Done() <-chan struct{} {
+	return d.done
+}
+
+func (d *Dispatcher[T]) loop() {
+	defer close(d.done)
+
+	for {
+		d.m.Lock()
+
+		for len(d.queue) == 0 && !d.stopped {
+			d.notEmpty.Wait()
+		}
+
+		if d.stopped {
+			d.m.Unlock()
+			return
+		}
+
+		v := d.queue[0]
+
+		var zero T
+		d.queue[0] = zero
+		d.queue = d.queue[1:]
+
+		d.notFull.Signal()
+
+		d.m.Unlock()
+
+		d.f(v)
+	}
+}
@@ -0,0 +1,161 @@
+package dispatcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher_Order(t *testing.T) {
+	c := make(chan int, 100)
+
+	d, err := New(nil, func(v int) {
+		c <- v
+	})
+	require.NoError(t, err)
+	defer d.Stop()
+
+	for i := 0; i < 100; i++ {
+		d.Dispatch(i)
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, i, receive(t, c))
+	}
+}
+
+func TestDispatcher_OverflowPolicies(t *testing.T) {
+	tests := []struct {
+		policy OverflowPolicy
+		want   []int
+	}{
+		{OverflowPolicyDropOldest, []int{0, 3, 4}},
+		{OverflowPolicyCoalesceLatest, []int{0, 1, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			c, release, d := blockedDispatcher(t, &Options{
+				QueueSize:      2,
+				OverflowPolicy: tt.policy,
+			})
+			defer d.Stop()
+
+			// 0 is being delivered, so 1 to 4 go to the queue.
+			for i := 1; i < 5; i++ {
+				d.Dispatch(i)
+			}
+
+			close(release)
+
+			for _, want := range tt.want {
+				assert.Equal(t, want, receive(t, c))
+			}
+
+			assert.Equal(t, uint64(2), d.Dropped())
+		})
+	}
+}
+
+func TestDispatcher_Block(t *testing.T) {
+	c, release, d := blockedDispatcher(t, &Options{
+		QueueSize:      1,
+		OverflowPolicy: OverflowPolicyBlock,
+	})
+	defer d.Stop()
+
+	d.Dispatch(1)
+
+	dispatched := make(chan struct{})
+	go func() {
+		d.Dispatch(2)
+		close(dispatched)
+	}()
+
+	select {
+	case <-dispatched:
+		t.Fatal("dispatch did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	for _, want := range []int{0, 1, 2} {
+		assert.Equal(t, want, receive(t, c))
+	}
+
+	<-dispatched
+	assert.Equal(t, uint64(0), d.Dropped())
+}
+
+func TestDispatcher_StopReleasesBlocked(t *testing.T) {
+	_, _, d := blockedDispatcher(t, &Options{
+		QueueSize:      1,
+		OverflowPolicy: OverflowPolicyBlock,
+	})
+
+	d.Dispatch(1)
+
+	dispatched := make(chan struct{})
+	go func() {
+		d.Dispatch(2)
+		close(dispatched)
+	}()
+
+	d.Stop()
+
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch still blocked after stop")
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	_, err := New(nil, (func(int))(nil))
+	assert.Error(t, err)
+
+	_, err = New(&Options{QueueSize: -1}, func(int) {})
+	assert.Error(t, err)
+
+	_, err = New(&Options{OverflowPolicy: 42}, func(int) {})
+	assert.Error(t, err)
+}
+
+// blockedDispatcher returns a dispatcher that already had 0 dispatched and is
+// blocked delivering it until release is closed. Delivered values are sent to
+// the returned channel.
+func blockedDispatcher(t *testing.T, o *Options) (chan int, chan struct{},
+	*Dispatcher[int]) {
+	c := make(chan int, 10)
+	release := make(chan struct{})
+	delivering := make(chan struct{})
+
+	d, err := New(o, func(v int) {
+		if v == 0 {
+			close(delivering)
+			<-release
+		}
+
+		c <- v
+	})
+	require.NoError(t, err)
+
+	d.Dispatch(0)
+	<-delivering
+
+	return c, release, d
+}
+
+func receive(t *testing.T, c chan int) int {
+	select {
+	case v := <-c:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("value not delivered")
+	}
+
+	return 0
+}
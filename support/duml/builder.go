@@ -0,0 +1,54 @@
+package duml
+
+import "sync/atomic"
+
+// Sequencer generates message sequence numbers. The zero value is ready to
+// use and it is safe for concurrent use.
+type Sequencer struct {
+	n atomic.Uint32
+}
+
+// Next returns the next sequence number. Sequence numbers wrap around.
+func (s *Sequencer) Next() uint16 {
+	return uint16(s.n.Add(1) - 1)
+}
+
+// Builder builds messages between a specific sender and receiver, assigning
+// sequence numbers to them. It is safe for concurrent use.
+type Builder struct {
+	sender   Host
+	receiver Host
+	s        Sequencer
+}
+
+// NewBuilder returns a new Builder for messages from the given sender to the
+// given receiver. The first message built will have the given sequence
+// number.
+func NewBuilder(sender, receiver Host, firstSequence uint16) *Builder {
+	b := &Builder{
+		sender:   sender,
+		receiver: receiver,
+	}
+
+	b.s.n.Store(uint32(firstSequence))
+
+	return b
+}
+
+// Build returns a new message for the given command and payload.
+func (b *Builder) Build(command Command, payload []byte,
+	needAck NeedAck) *Message {
+	return NewMessage(b.sender, b.receiver, b.s.Next(), command, payload,
+		needAck)
+}
+
+// BuildPayload returns a new message for the given typed payload.
+func (b *Builder) BuildPayload(p Payload, needAck NeedAck) (*Message,
+	error) {
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Build(p.Command(), data, needAck), nil
+}
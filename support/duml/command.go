@@ -0,0 +1,131 @@
+package duml
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Command identifies the command a message is about through a command set
+// and a command ID.
+type Command struct {
+	Set byte
+	ID  byte
+}
+
+// Known commands. Names (and IDs) follow the ones used in DJI's Robomaster
+// SDK.
+var (
+	CommandGetVersion        = registerCommand(0x00, 0x01, "GetVersion", nil)
+	CommandGetProductVersion = registerCommand(0x00, 0x4f, "GetProductVersion", nil)
+	CommandGetSN             = registerCommand(0x00, 0x51, "GetSN", nil)
+
+	CommandTakePhoto = registerCommand(0x02, 0x01, "TakePhoto", nil)
+	CommandSetZoom   = registerCommand(0x02, 0x34, "SetZoom", nil)
+	CommandGetZoom   = registerCommand(0x02, 0x35, "GetZoom", nil)
+
+	CommandGimbalCtrlSpeed   = registerCommand(0x04, 0x0c, "GimbalCtrlSpeed", &GimbalCtrlSpeed{})
+	CommandGimbalSetWorkMode = registerCommand(0x04, 0x4c, "GimbalSetWorkMode", &GimbalSetWorkMode{})
+
+	CommandChassisSetWorkMode = registerCommand(0x3f, 0x19, "ChassisSetWorkMode", nil)
+	CommandChassisSpeedSet    = registerCommand(0x3f, 0x21, "ChassisSpeedSet", &ChassisSpeedSet{})
+	CommandPositionMove       = registerCommand(0x3f, 0x25, "PositionMove", nil)
+	CommandChassisWheelSpeed  = registerCommand(0x3f, 0x26, "ChassisWheelSpeed", nil)
+	CommandSetSystemLed       = registerCommand(0x3f, 0x33, "SetSystemLed", nil)
+	CommandSetRobotMode       = registerCommand(0x3f, 0x46, "SetRobotMode", &SetRobotMode{})
+	CommandGetRobotMode       = registerCommand(0x3f, 0x47, "GetRobotMode", nil)
+	CommandBlasterFire        = registerCommand(0x3f, 0x51, "BlasterFire", &BlasterFire{})
+	CommandBlasterSetLed      = registerCommand(0x3f, 0x55, "BlasterSetLed", nil)
+	CommandGimbalRotate       = registerCommand(0x3f, 0xb0, "GimbalRotate", nil)
+	CommandGimbalRecenter     = registerCommand(0x3f, 0xb2, "GimbalRecenter", nil)
+	CommandPlaySound          = registerCommand(0x3f, 0xb3, "PlaySound", nil)
+	CommandSetSDKMode         = registerCommand(0x3f, 0xd1, "SetSDKMode", nil)
+	CommandStreamCtrl         = registerCommand(0x3f, 0xd2, "StreamCtrl", nil)
+	CommandSetSDKConnection   = registerCommand(0x3f, 0xd4, "SetSDKConnection", nil)
+
+	CommandSubscribeAddNode = registerCommand(0x48, 0x01, "SubscribeAddNode", nil)
+	CommandSubscribeReset   = registerCommand(0x48, 0x02, "SubscribeReset", nil)
+	CommandSubscribeAddMsg  = registerCommand(0x48, 0x03, "SubscribeAddMsg", nil)
+	CommandSubscribeDelMsg  = registerCommand(0x48, 0x04, "SubscribeDelMsg", nil)
+	CommandPushPeriodMsg    = registerCommand(0x48, 0x08, "PushPeriodMsg", nil)
+)
+
+// commandInfo is what the registry knows about a command.
+type commandInfo struct {
+	name        string
+	payloadType reflect.Type
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = make(map[Command]commandInfo)
+)
+
+// RegisterCommand registers a command with the given name and request payload
+// prototype (which might be nil if there is no known payload for it). It
+// returns an error if the command was already registered.
+func RegisterCommand(c Command, name string, prototype Payload) error {
+	var payloadType reflect.Type
+	if prototype != nil {
+		payloadType = reflect.TypeOf(prototype)
+		if payloadType.Kind() != reflect.Ptr {
+			return fmt.Errorf("payload prototype must be a pointer")
+		}
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, ok := registry[c]; ok {
+		return fmt.Errorf("command %02x/%02x already registered", c.Set, c.ID)
+	}
+
+	registry[c] = commandInfo{
+		name:        name,
+		payloadType: payloadType,
+	}
+
+	return nil
+}
+
+func registerCommand(set, id byte, name string, prototype Payload) Command {
+	c := Command{Set: set, ID: id}
+
+	if err := RegisterCommand(c, name, prototype); err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// Name returns the registered name for the command or an empty string if it
+// is unknown.
+func (c Command) Name() string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	return registry[c].name
+}
+
+// String returns a string representation of the command.
+func (c Command) String() string {
+	if name := c.Name(); name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("%02x/%02x", c.Set, c.ID)
+}
+
+// NewPayload returns a new (zero) request payload for the command. It
+// returns an error if the command has no known payload.
+func (c Command) NewPayload() (Payload, error) {
+	registryMutex.RLock()
+	payloadType := registry[c].payloadType
+	registryMutex.RUnlock()
+
+	if payloadType == nil {
+		return nil, fmt.Errorf("no known payload for command %s", c)
+	}
+
+	return reflect.New(payloadType.Elem()).Interface().(Payload), nil
+}
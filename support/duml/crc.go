@@ -1,95 +1,7 @@
-package main
+package duml
 
-import (
-	"fmt"
-)
-
-type Message struct {
-	data      []byte
-	protoData []byte
-}
-
-func NewMessage(data []byte) (Message, error) {
-	message := Message{}
-
-	if len(data) < 13 {
-		return message, fmt.Errorf("invalid message length: %d", len(data))
-	}
-
-	if data[0] != 0x55 {
-		return message, fmt.Errorf("invalid message magic byte: %02x", data[0])
-	}
-
-	expectedLength := uint16(data[1]) | uint16(data[2]&0x3)<<8
-	if expectedLength != uint16(len(data)) {
-		return message, fmt.Errorf("invalid message length: %d != %d", expectedLength, len(data))
-	}
-
-	expectedHeaderCrc8 := crc8(data[:3])
-	if expectedHeaderCrc8 != data[3] {
-		return message, fmt.Errorf("invalid message crc8: %02x != %02x", expectedHeaderCrc8, data[3])
-	}
-
-	expectedDataCrc16 := crc16(data[:len(data)-2])
-	actualDataCrc16 := uint16(data[len(data)-2]) | uint16(data[len(data)-1])<<8
-	if expectedDataCrc16 != actualDataCrc16 {
-		return message, fmt.Errorf("invalid data crc16: %04x != %04x", expectedDataCrc16, actualDataCrc16)
-	}
-
-	var protoData []byte
-	if len(data) > 13 {
-		protoData = data[11 : len(data)-2]
-	}
-
-	message.data = data
-	message.protoData = protoData
-
-	return message, nil
-}
-
-func (m Message) Sender() byte {
-	return m.data[4]
-}
-
-func (m Message) Receiver() byte {
-	return m.data[5]
-}
-
-func (m Message) Sequence() uint16 {
-	return uint16(m.data[6]) | uint16(m.data[7])<<8
-}
-
-func (m Message) Attrs() byte {
-	return m.data[8]
-}
-
-func (m Message) AttrIsAck() bool {
-	return m.data[8]&0b01000000 != 0
-}
-
-func (m Message) AttrNeedsAck() bool {
-	return m.data[8]&0b00010000 != 0
-}
-
-func (m Message) ProtoCmdSet() byte {
-	if m.protoData == nil {
-		return 0
-	}
-
-	return m.data[9]
-}
-
-func (m Message) ProtoCmdID() byte {
-	if m.protoData == nil {
-		return 0
-	}
-
-	return m.data[10]
-}
-
-func (m Message) ProtoData() []byte {
-	return m.protoData
-}
+// CRC algorithms used by DUML messages. The header is protected by a CRC8 and
+// the whole message by a CRC16 (both with DJI specific seeds).
 
 var crc8Table = []byte{
 	0x00, 0x5e, 0xbc, 0xe2, 0x61, 0x3f, 0xdd, 0x83, 0xc2, 0x9c, 0x7e, 0x20, 0xa3, 0xfd, 0x1f, 0x41,
@@ -0,0 +1,134 @@
+package duml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Captured from a real robot (see playground/main.go).
+var testMessage = []byte{
+	0x55, 0x10, 0x04, 0x56, 0x02, 0x09, 0x2d, 0x27,
+	0x40, 0x3f, 0x77, 0x01, 0x04, 0x01, 0x5b, 0x0e,
+}
+
+func TestDecode(t *testing.T) {
+	m, err := Decode(testMessage)
+	require.NoError(t, err)
+
+	assert.Equal(t, Host(0x02), m.Sender)
+	assert.Equal(t, Host(0x09), m.Receiver)
+	assert.Equal(t, uint16(10029), m.Sequence)
+	assert.Equal(t, Attr(0x40), m.Attr)
+	assert.False(t, m.IsAck())
+	assert.Equal(t, NeedAckRequired, m.NeedAck())
+	assert.Equal(t, Command{Set: 0x3f, ID: 0x77}, m.Command)
+	assert.Equal(t, []byte{0x01, 0x04, 0x01}, m.Payload)
+
+	data, err := m.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, data)
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	_, err := Decode(testMessage[:MinSize-1])
+	assert.Error(t, err)
+
+	_, err = Decode(testMessage[:len(testMessage)-1])
+	assert.Error(t, err)
+
+	for _, i := range []int{0, 3, 12} {
+		data := append([]byte(nil), testMessage...)
+		data[i] ^= 0xff
+		_, err = Decode(data)
+		assert.Error(t, err, "corrupted byte %d", i)
+	}
+}
+
+func TestFind(t *testing.T) {
+	// Outer packet captured from the robot with a message at offset 20.
+	data := []byte{
+		0x24, 0x80, 0x8a, 0x2a, 0xc8, 0x54, 0x05, 0x9d,
+		0xb8, 0x54, 0xc8, 0x54, 0x00, 0x00, 0x00, 0x00,
+		0x0f, 0x01, 0x00, 0x00,
+	}
+	data = append(data, testMessage...)
+
+	assert.Equal(t, 20, Find(data))
+	assert.Equal(t, -1, Find(data[:20]))
+}
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(NewHost(9, 0), NewHost(6, 1), 0xffff)
+
+	m, err := b.BuildPayload(&SetRobotMode{Mode: 1}, NeedAckRequired)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(0xffff), m.Sequence)
+	assert.True(t, m.NeedsAck())
+	assert.False(t, m.IsAck())
+	assert.Equal(t, NewAttr(false, NeedAckRequired), m.Attr)
+	assert.Equal(t, Attr(0x40), m.Attr)
+	assert.Equal(t, CommandSetRobotMode, m.Command)
+
+	// Sequence wraps around.
+	m2 := b.Build(CommandGetVersion, nil, NeedAckNone)
+	assert.Equal(t, uint16(0), m2.Sequence)
+	assert.False(t, m2.NeedsAck())
+
+	data, err := m.Encode()
+	require.NoError(t, err)
+
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, m, decoded)
+
+	ack := decoded.NewAck([]byte{0x00})
+	assert.True(t, ack.IsAck())
+	assert.False(t, ack.NeedsAck())
+	assert.Equal(t, Attr(0x80), ack.Attr)
+	assert.Equal(t, m.Sequence, ack.Sequence)
+	assert.Equal(t, m.Sender, ack.Receiver)
+	assert.Equal(t, m.Receiver, ack.Sender)
+}
+
+func TestPayloads(t *testing.T) {
+	payloads := []Payload{
+		&SetRobotMode{Mode: 2},
+		&ChassisSpeedSet{X: 0.5, Y: -0.25, Z: 30},
+		&GimbalCtrlSpeed{Yaw: -100, Roll: 0, Pitch: 250, Ctrl: 0xdc},
+		&GimbalSetWorkMode{WorkMode: 1, Recenter: 0},
+		&BlasterFire{Type: 1, Times: 3},
+	}
+
+	b := NewBuilder(NewHost(9, 0), NewHost(3, 6), 0)
+
+	for _, p := range payloads {
+		m, err := b.BuildPayload(p, NeedAckNone)
+		require.NoError(t, err)
+
+		data, err := m.Encode()
+		require.NoError(t, err)
+
+		decoded, err := Decode(data)
+		require.NoError(t, err)
+
+		decodedPayload, err := decoded.DecodePayload()
+		require.NoError(t, err)
+		assert.Equal(t, p, decodedPayload)
+	}
+
+	_, err := (&BlasterFire{Times: 16}).MarshalBinary()
+	assert.Error(t, err)
+
+	_, err = CommandGetVersion.NewPayload()
+	assert.Error(t, err)
+}
+
+func TestCommand(t *testing.T) {
+	assert.Equal(t, "ChassisSpeedSet", CommandChassisSpeedSet.String())
+	assert.Equal(t, "fe/fe", Command{Set: 0xfe, ID: 0xfe}.String())
+
+	assert.Error(t, RegisterCommand(CommandGetVersion, "Duplicate", nil))
+}
@@ -0,0 +1,278 @@
+// Package duml implements the DUML protocol used for communication between
+// DJI devices (including the Robomaster S1 and EP) and the apps that control
+// them.
+//
+// A DUML message has the following layout (multi-byte values are little
+// endian):
+//
+//	offset size description
+//	0      1    magic (0x55)
+//	1      2    length (10 bits) and version (6 bits)
+//	3      1    header CRC8
+//	4      1    sender
+//	5      1    receiver
+//	6      2    sequence number
+//	8      1    attributes
+//	9      1    command set
+//	10     1    command ID
+//	11     n    payload
+//	11+n   2    message CRC16
+package duml
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// Magic is the first byte of every DUML message.
+	Magic = 0x55
+
+	// headerSize is the size of everything before the payload.
+	headerSize = 11
+
+	// crc16Size is the size of the trailing CRC16.
+	crc16Size = 2
+
+	// MinSize is the size of a message with no payload.
+	MinSize = headerSize + crc16Size
+
+	// MaxSize is the maximum size of a message (the length field has 10
+	// bits).
+	MaxSize = 0x3ff
+
+	// MaxPayloadSize is the maximum size of a message payload.
+	MaxPayloadSize = MaxSize - MinSize
+
+	// version is the protocol version encoded with the length.
+	version = 1
+)
+
+// Attr is the attributes byte of a message. The layout follows the RoboMaster
+// SDK (protocol.py): bit 7 is set in acknowledgements and bits 5 and 6 are the
+// need-ack field. The remaining bits are kept as is.
+type Attr byte
+
+const (
+	// AttrAck is set in messages that are acknowledgements (replies) to
+	// previous messages.
+	AttrAck Attr = 0x80
+
+	needAckShift = 5
+	needAckMask  = 0x03
+)
+
+// NeedAck is the need-ack field of the message attributes.
+type NeedAck byte
+
+const (
+	// NeedAckNone means no acknowledgement is required.
+	NeedAckNone NeedAck = 0
+
+	// NeedAckRequired is the value used by the RoboMaster SDK when an
+	// acknowledgement is required. The meaning of the other non-zero values
+	// is unverified.
+	NeedAckRequired NeedAck = 2
+)
+
+// NewAttr returns the attributes for a message that is an acknowledgement (if
+// ack is true) with the given need-ack field.
+func NewAttr(ack bool, needAck NeedAck) Attr {
+	a := Attr(needAck&needAckMask) << needAckShift
+	if ack {
+		a |= AttrAck
+	}
+
+	return a
+}
+
+// IsAck returns true if the AttrAck attribute is set.
+func (a Attr) IsAck() bool {
+	return a&AttrAck != 0
+}
+
+// NeedAck returns the need-ack field.
+func (a Attr) NeedAck() NeedAck {
+	return NeedAck(a>>needAckShift) & needAckMask
+}
+
+// NeedsAck returns true if the need-ack field is not NeedAckNone.
+func (a Attr) NeedsAck() bool {
+	return a.NeedAck() != NeedAckNone
+}
+
+// Host identifies the sender or receiver of a message. The lower 5 bits are
+// the host type and the upper 3 bits are the host index.
+type Host byte
+
+// NewHost returns a Host with the given type and index.
+func NewHost(typ, index byte) Host {
+	return Host(index<<5 | typ&0x1f)
+}
+
+// Type returns the host type.
+func (h Host) Type() byte {
+	return byte(h) & 0x1f
+}
+
+// Index returns the host index.
+func (h Host) Index() byte {
+	return byte(h) >> 5
+}
+
+// String returns a string representation of the host.
+func (h Host) String() string {
+	return fmt.Sprintf("%d.%d", h.Type(), h.Index())
+}
+
+// Message is a DUML message.
+type Message struct {
+	Sender   Host
+	Receiver Host
+	Sequence uint16
+	Attr     Attr
+	Command  Command
+	Payload  []byte
+}
+
+// NewMessage returns a new Message with the given parameters. needAck is the
+// need-ack field of the message attributes.
+func NewMessage(sender, receiver Host, sequence uint16, command Command,
+	payload []byte, needAck NeedAck) *Message {
+	return &Message{
+		Sender:   sender,
+		Receiver: receiver,
+		Sequence: sequence,
+		Attr:     NewAttr(false, needAck),
+		Command:  command,
+		Payload:  payload,
+	}
+}
+
+// NewAck returns a new Message that is an acknowledgement to this one (same
+// sequence number and command, sender and receiver swapped) with the given
+// payload.
+func (m *Message) NewAck(payload []byte) *Message {
+	return &Message{
+		Sender:   m.Receiver,
+		Receiver: m.Sender,
+		Sequence: m.Sequence,
+		Attr:     NewAttr(true, NeedAckNone),
+		Command:  m.Command,
+		Payload:  payload,
+	}
+}
+
+// IsAck returns true if this message is an acknowledgement.
+func (m *Message) IsAck() bool {
+	return m.Attr.IsAck()
+}
+
+// NeedAck returns the need-ack field of this message attributes.
+func (m *Message) NeedAck() NeedAck {
+	return m.Attr.NeedAck()
+}
+
+// NeedsAck returns true if this message requires an acknowledgement.
+func (m *Message) NeedsAck() bool {
+	return m.Attr.NeedsAck()
+}
+
+// Size returns the size of the encoded message.
+func (m *Message) Size() int {
+	return MinSize + len(m.Payload)
+}
+
+// Encode returns the wire representation of the message.
+func (m *Message) Encode() ([]byte, error) {
+	return m.AppendEncode(nil)
+}
+
+// AppendEncode appends the wire representation of the message to the given
+// buffer and returns the extended buffer.
+func (m *Message) AppendEncode(b []byte) ([]byte, error) {
+	if len(m.Payload) > MaxPayloadSize {
+		return nil, fmt.Errorf("payload too big: %d bytes", len(m.Payload))
+	}
+
+	start := len(b)
+	size := m.Size()
+
+	b = append(b, Magic, byte(size), byte(size>>8)|version<<2)
+	b = append(b, crc8(b[start:start+3]))
+	b = append(b, byte(m.Sender), byte(m.Receiver))
+	b = binary.LittleEndian.AppendUint16(b, m.Sequence)
+	b = append(b, byte(m.Attr), m.Command.Set, m.Command.ID)
+	b = append(b, m.Payload...)
+	b = binary.LittleEndian.AppendUint16(b, crc16(b[start:]))
+
+	return b, nil
+}
+
+// Decode decodes the message in the given data, which must contain exactly
+// one message. The returned message payload references the given data.
+func Decode(data []byte) (*Message, error) {
+	if len(data) < MinSize {
+		return nil, fmt.Errorf("invalid message length: %d", len(data))
+	}
+
+	if data[0] != Magic {
+		return nil, fmt.Errorf("invalid message magic byte: %02x", data[0])
+	}
+
+	expectedLength := int(binary.LittleEndian.Uint16(data[1:3]) & MaxSize)
+	if expectedLength != len(data) {
+		return nil, fmt.Errorf("invalid message length: %d != %d",
+			expectedLength, len(data))
+	}
+
+	expectedHeaderCrc8 := crc8(data[:3])
+	if expectedHeaderCrc8 != data[3] {
+		return nil, fmt.Errorf("invalid message crc8: %02x != %02x",
+			expectedHeaderCrc8, data[3])
+	}
+
+	expectedDataCrc16 := crc16(data[:len(data)-crc16Size])
+	actualDataCrc16 := binary.LittleEndian.Uint16(data[len(data)-crc16Size:])
+	if expectedDataCrc16 != actualDataCrc16 {
+		return nil, fmt.Errorf("invalid data crc16: %04x != %04x",
+			expectedDataCrc16, actualDataCrc16)
+	}
+
+	var payload []byte
+	if len(data) > MinSize {
+		payload = data[headerSize : len(data)-crc16Size]
+	}
+
+	return &Message{
+		Sender:   Host(data[4]),
+		Receiver: Host(data[5]),
+		Sequence: binary.LittleEndian.Uint16(data[6:8]),
+		Attr:     Attr(data[8]),
+		Command: Command{
+			Set: data[9],
+			ID:  data[10],
+		},
+		Payload: payload,
+	}, nil
+}
+
+// Find returns the index of the first byte in the given data that looks like
+// the start of a message (i.e. has the magic byte and a valid header CRC8).
+// It returns -1 if there is none.
+func Find(data []byte) int {
+	for i := 0; i+4 <= len(data); i++ {
+		if data[i] == Magic && crc8(data[i:i+3]) == data[i+3] {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// String returns a string representation of the message.
+func (m *Message) String() string {
+	return fmt.Sprintf("Message{Sender: %s, Receiver: %s, Sequence: %d, "+
+		"Attr: %08b, Command: %s, Payload: % x}", m.Sender, m.Receiver,
+		m.Sequence, m.Attr, m.Command, m.Payload)
+}
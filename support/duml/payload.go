@@ -0,0 +1,211 @@
+package duml
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Payload is a typed message payload for a specific command.
+type Payload interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+
+	// Command returns the command this payload is for.
+	Command() Command
+}
+
+// DecodePayload decodes the message payload according to the registered
+// payload for its command. Only request payloads are known so this should
+// not be used with acknowledgements.
+func (m *Message) DecodePayload() (Payload, error) {
+	p, err := m.Command.NewPayload()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.UnmarshalBinary(m.Payload); err != nil {
+		return nil, fmt.Errorf("error decoding %s payload: %w", m.Command, err)
+	}
+
+	return p, nil
+}
+
+func checkPayloadSize(data []byte, size int) error {
+	if len(data) != size {
+		return fmt.Errorf("invalid payload size: %d != %d", len(data), size)
+	}
+
+	return nil
+}
+
+// SetRobotMode is the payload for CommandSetRobotMode.
+type SetRobotMode struct {
+	// Mode is 0 for free mode, 1 for gimbal lead and 2 for chassis lead.
+	Mode byte
+}
+
+// Command implements Payload.
+func (p *SetRobotMode) Command() Command {
+	return CommandSetRobotMode
+}
+
+// MarshalBinary implements Payload.
+func (p *SetRobotMode) MarshalBinary() ([]byte, error) {
+	return []byte{p.Mode}, nil
+}
+
+// UnmarshalBinary implements Payload.
+func (p *SetRobotMode) UnmarshalBinary(data []byte) error {
+	if err := checkPayloadSize(data, 1); err != nil {
+		return err
+	}
+
+	p.Mode = data[0]
+
+	return nil
+}
+
+// ChassisSpeedSet is the payload for CommandChassisSpeedSet.
+type ChassisSpeedSet struct {
+	// X and Y are linear speeds in m/s.
+	X float32
+	Y float32
+
+	// Z is the rotation speed in degrees/s.
+	Z float32
+}
+
+// Command implements Payload.
+func (p *ChassisSpeedSet) Command() Command {
+	return CommandChassisSpeedSet
+}
+
+// MarshalBinary implements Payload.
+func (p *ChassisSpeedSet) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 12)
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(p.X))
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(p.Y))
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(p.Z))
+
+	return b, nil
+}
+
+// UnmarshalBinary implements Payload.
+func (p *ChassisSpeedSet) UnmarshalBinary(data []byte) error {
+	if err := checkPayloadSize(data, 12); err != nil {
+		return err
+	}
+
+	p.X = math.Float32frombits(binary.LittleEndian.Uint32(data[0:4]))
+	p.Y = math.Float32frombits(binary.LittleEndian.Uint32(data[4:8]))
+	p.Z = math.Float32frombits(binary.LittleEndian.Uint32(data[8:12]))
+
+	return nil
+}
+
+// GimbalCtrlSpeed is the payload for CommandGimbalCtrlSpeed.
+type GimbalCtrlSpeed struct {
+	// Speeds in 0.1 degrees/s.
+	Yaw   int16
+	Roll  int16
+	Pitch int16
+
+	// Ctrl is a control byte. The official SDK always sets it to 0xdc.
+	Ctrl byte
+}
+
+// Command implements Payload.
+func (p *GimbalCtrlSpeed) Command() Command {
+	return CommandGimbalCtrlSpeed
+}
+
+// MarshalBinary implements Payload.
+func (p *GimbalCtrlSpeed) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 7)
+	b = binary.LittleEndian.AppendUint16(b, uint16(p.Yaw))
+	b = binary.LittleEndian.AppendUint16(b, uint16(p.Roll))
+	b = binary.LittleEndian.AppendUint16(b, uint16(p.Pitch))
+	b = append(b, p.Ctrl)
+
+	return b, nil
+}
+
+// UnmarshalBinary implements Payload.
+func (p *GimbalCtrlSpeed) UnmarshalBinary(data []byte) error {
+	if err := checkPayloadSize(data, 7); err != nil {
+		return err
+	}
+
+	p.Yaw = int16(binary.LittleEndian.Uint16(data[0:2]))
+	p.Roll = int16(binary.LittleEndian.Uint16(data[2:4]))
+	p.Pitch = int16(binary.LittleEndian.Uint16(data[4:6]))
+	p.Ctrl = data[6]
+
+	return nil
+}
+
+// GimbalSetWorkMode is the payload for CommandGimbalSetWorkMode.
+type GimbalSetWorkMode struct {
+	WorkMode byte
+	Recenter byte
+}
+
+// Command implements Payload.
+func (p *GimbalSetWorkMode) Command() Command {
+	return CommandGimbalSetWorkMode
+}
+
+// MarshalBinary implements Payload.
+func (p *GimbalSetWorkMode) MarshalBinary() ([]byte, error) {
+	return []byte{p.WorkMode, p.Recenter}, nil
+}
+
+// UnmarshalBinary implements Payload.
+func (p *GimbalSetWorkMode) UnmarshalBinary(data []byte) error {
+	if err := checkPayloadSize(data, 2); err != nil {
+		return err
+	}
+
+	p.WorkMode = data[0]
+	p.Recenter = data[1]
+
+	return nil
+}
+
+// BlasterFire is the payload for CommandBlasterFire.
+type BlasterFire struct {
+	// Type is 0 for water (gel beads) and 1 for infrared.
+	Type byte
+
+	// Times is the number of shots (up to 15).
+	Times byte
+}
+
+// Command implements Payload.
+func (p *BlasterFire) Command() Command {
+	return CommandBlasterFire
+}
+
+// MarshalBinary implements Payload.
+func (p *BlasterFire) MarshalBinary() ([]byte, error) {
+	if p.Type > 0x0f || p.Times > 0x0f {
+		return nil, fmt.Errorf("invalid blaster fire type or times: %d, %d",
+			p.Type, p.Times)
+	}
+
+	return []byte{p.Type<<4 | p.Times}, nil
+}
+
+// UnmarshalBinary implements Payload.
+func (p *BlasterFire) UnmarshalBinary(data []byte) error {
+	if err := checkPayloadSize(data, 1); err != nil {
+		return err
+	}
+
+	p.Type = data[0] >> 4
+	p.Times = data[0] & 0x0f
+
+	return nil
+}
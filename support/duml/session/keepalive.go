@@ -0,0 +1,130 @@
+package session
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/logger"
+)
+
+// Keepalive periodically sends a keepalive packet while a session is idle.
+// The robot drops sessions that do not send anything for a while.
+type Keepalive struct {
+	interval time.Duration
+	packet   func() []byte
+	send     func([]byte) error
+	l        *logger.Logger
+
+	m        sync.Mutex
+	lastSent time.Time
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+// NewKeepalive returns a new Keepalive that, once started, calls send with
+// the data returned by packet whenever nothing was sent for the given
+// interval.
+func NewKeepalive(l *logger.Logger, interval time.Duration,
+	packet func() []byte, send func([]byte) error) (*Keepalive, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive")
+	}
+
+	if packet == nil || send == nil {
+		return nil, fmt.Errorf("packet and send functions must not be nil")
+	}
+
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	return &Keepalive{
+		interval: interval,
+		packet:   packet,
+		send:     send,
+		l:        l.WithGroup("session_keepalive"),
+	}, nil
+}
+
+// Start starts sending keepalives.
+func (k *Keepalive) Start() error {
+	k.m.Lock()
+	defer k.m.Unlock()
+
+	if k.quit != nil {
+		return fmt.Errorf("keepalive already started")
+	}
+
+	k.quit = make(chan struct{})
+	k.done = make(chan struct{})
+	k.lastSent = time.Now()
+
+	go k.loop(k.quit, k.done)
+
+	return nil
+}
+
+// Stop stops sending keepalives.
+func (k *Keepalive) Stop() error {
+	k.m.Lock()
+
+	if k.quit == nil {
+		k.m.Unlock()
+		return fmt.Errorf("keepalive not started")
+	}
+
+	close(k.quit)
+	done := k.done
+
+	k.quit = nil
+	k.done = nil
+
+	k.m.Unlock()
+
+	<-done
+
+	return nil
+}
+
+// Touch records that something was just sent in the session, postponing the
+// next keepalive.
+func (k *Keepalive) Touch() {
+	k.m.Lock()
+	defer k.m.Unlock()
+
+	k.lastSent = time.Now()
+}
+
+func (k *Keepalive) loop(quit <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	timer := time.NewTimer(k.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-timer.C:
+		}
+
+		k.m.Lock()
+		idle := time.Since(k.lastSent)
+		k.m.Unlock()
+
+		if idle < k.interval {
+			timer.Reset(k.interval - idle)
+			continue
+		}
+
+		if err := k.send(k.packet()); err != nil {
+			k.l.Warn("Error sending keepalive", "error", err)
+		}
+
+		k.Touch()
+
+		timer.Reset(k.interval)
+	}
+}
@@ -0,0 +1,152 @@
+// Package session implements the outer framing used by the Robomaster app
+// when talking to the robot over UDP (robot port 10607).
+//
+// A session packet has the following layout (multi-byte values are little
+// endian):
+//
+//	offset size description
+//	0      2    length (11 bits) with the highest bit set (0x8000)
+//	2      2    session ID (arbitrary, but constant for the session)
+//	4      n    body
+//
+// The length includes the 4 header bytes. The body format is mostly unknown
+// but it might contain embedded DUML messages.
+package session
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/brunoga/robomaster/support/duml"
+)
+
+const (
+	// HeaderSize is the size of the packet header.
+	HeaderSize = 4
+
+	// MaxSize is the maximum size of a packet (the length field has 11
+	// bits).
+	MaxSize = 0x7ff
+
+	// MaxBodySize is the maximum size of a packet body.
+	MaxBodySize = MaxSize - HeaderSize
+
+	lengthFlag = 0x8000
+	lengthMask = 0x07ff
+)
+
+// Packet is a session packet.
+type Packet struct {
+	SessionID uint16
+	Body      []byte
+}
+
+// NewPacket returns a new Packet for the given session with the given body.
+func NewPacket(sessionID uint16, body []byte) *Packet {
+	return &Packet{
+		SessionID: sessionID,
+		Body:      body,
+	}
+}
+
+// NewPacketWithMessages returns a new Packet for the given session with a
+// body composed of the given prefix followed by the given encoded DUML
+// messages.
+func NewPacketWithMessages(sessionID uint16, prefix []byte,
+	messages ...*duml.Message) (*Packet, error) {
+	body := append([]byte(nil), prefix...)
+
+	var err error
+	for _, m := range messages {
+		body, err = m.AppendEncode(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(body) > MaxBodySize {
+		return nil, fmt.Errorf("packet body too big: %d bytes", len(body))
+	}
+
+	return NewPacket(sessionID, body), nil
+}
+
+// Size returns the size of the encoded packet.
+func (p *Packet) Size() int {
+	return HeaderSize + len(p.Body)
+}
+
+// Encode returns the wire representation of the packet.
+func (p *Packet) Encode() ([]byte, error) {
+	if len(p.Body) > MaxBodySize {
+		return nil, fmt.Errorf("packet body too big: %d bytes", len(p.Body))
+	}
+
+	b := make([]byte, 0, p.Size())
+	b = binary.LittleEndian.AppendUint16(b, uint16(p.Size())|lengthFlag)
+	b = binary.LittleEndian.AppendUint16(b, p.SessionID)
+	b = append(b, p.Body...)
+
+	return b, nil
+}
+
+// Decode decodes the packet in the given data, which must contain exactly one
+// packet. The returned packet body references the given data.
+func Decode(data []byte) (*Packet, error) {
+	if len(data) < HeaderSize {
+		return nil, fmt.Errorf("invalid packet length: %d", len(data))
+	}
+
+	lengthField := binary.LittleEndian.Uint16(data[0:2])
+	if lengthField&lengthFlag == 0 {
+		return nil, fmt.Errorf("invalid packet length field: %04x",
+			lengthField)
+	}
+
+	expectedLength := int(lengthField & lengthMask)
+	if expectedLength != len(data) {
+		return nil, fmt.Errorf("invalid packet length: %d != %d",
+			expectedLength, len(data))
+	}
+
+	return &Packet{
+		SessionID: binary.LittleEndian.Uint16(data[2:4]),
+		Body:      data[HeaderSize:],
+	}, nil
+}
+
+// Messages returns all valid DUML messages embedded in the packet body.
+// Anything that does not look like a valid DUML message is skipped.
+func (p *Packet) Messages() []*duml.Message {
+	var messages []*duml.Message
+
+	data := p.Body
+	for {
+		n := duml.Find(data)
+		if n == -1 {
+			break
+		}
+
+		data = data[n:]
+
+		size := int(binary.LittleEndian.Uint16(data[1:3]) & duml.MaxSize)
+		if size <= len(data) {
+			if m, err := duml.Decode(data[:size]); err == nil {
+				messages = append(messages, m)
+				data = data[size:]
+				continue
+			}
+		}
+
+		// Not a real message. Keep looking after the magic byte.
+		data = data[1:]
+	}
+
+	return messages
+}
+
+// String returns a string representation of the packet.
+func (p *Packet) String() string {
+	return fmt.Sprintf("Packet{SessionID: %04x, Body: % x}", p.SessionID,
+		p.Body)
+}
@@ -0,0 +1,160 @@
+package session
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/support/duml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Packets captured from a real session (see playground/main.go).
+const (
+	// Sent by the app. No embedded DUML messages.
+	fixtureAppPacket = `
+30 80 b6 05 00 00 00 03 b0 2d 64 00 64 00 c0 05
+14 00 00 64 00 64 00 64 00 c0 05 14 00 00 64 00
+14 00 64 00 c0 05 14 00 00 64 00 01 01 04 01 02`
+
+	// Sent by the robot. One embedded DUML message at body offset 16.
+	fixtureRobotPacket = `
+24 80 8a 2a c8 54 05 9d b8 54 c8 54 00 00 00 00
+0f 01 00 00 55 10 04 56 02 09 2d 27 40 3f 77 01
+04 01 5b 0e`
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	require.NoError(t, err)
+
+	return data
+}
+
+func TestDecode_AppPacket(t *testing.T) {
+	data := decodeHex(t, fixtureAppPacket)
+
+	p, err := Decode(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(0x05b6), p.SessionID)
+	assert.Len(t, p.Body, 44)
+	assert.Empty(t, p.Messages())
+
+	encoded, err := p.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, data, encoded)
+}
+
+func TestDecode_RobotPacket(t *testing.T) {
+	data := decodeHex(t, fixtureRobotPacket)
+
+	p, err := Decode(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(0x2a8a), p.SessionID)
+
+	messages := p.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, duml.Command{Set: 0x3f, ID: 0x77}, messages[0].Command)
+	assert.Equal(t, uint16(10029), messages[0].Sequence)
+
+	// Rebuild the same packet from its parts.
+	rebuilt, err := NewPacketWithMessages(p.SessionID, p.Body[:16],
+		messages...)
+	require.NoError(t, err)
+
+	encoded, err := rebuilt.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, data, encoded)
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	data := decodeHex(t, fixtureRobotPacket)
+
+	_, err := Decode(data[:3])
+	assert.Error(t, err)
+
+	_, err = Decode(data[:len(data)-1])
+	assert.Error(t, err)
+
+	noFlag := append([]byte(nil), data...)
+	noFlag[1] = 0x00
+	_, err = Decode(noFlag)
+	assert.Error(t, err)
+
+	_, err = NewPacket(1, make([]byte, MaxBodySize+1)).Encode()
+	assert.Error(t, err)
+}
+
+func TestPacket_LargeSize(t *testing.T) {
+	// 1472 byte packets use the extra length bits in the second byte.
+	p := NewPacket(0x1234, make([]byte, 1472-HeaderSize))
+
+	data, err := p.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xc0, 0x85, 0x34, 0x12}, data[:4])
+
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, p, decoded)
+}
+
+func TestPacket_MultipleMessages(t *testing.T) {
+	b := duml.NewBuilder(duml.NewHost(9, 0), duml.NewHost(6, 1), 1)
+
+	m1, err := b.BuildPayload(&duml.SetRobotMode{Mode: 1},
+		duml.NeedAckRequired)
+	require.NoError(t, err)
+	m2, err := b.BuildPayload(&duml.BlasterFire{Type: 0, Times: 1},
+		duml.NeedAckRequired)
+	require.NoError(t, err)
+
+	// Garbage (including a stray magic byte) before the messages.
+	p, err := NewPacketWithMessages(1, []byte{0x00, 0x55, 0x01}, m1, m2)
+	require.NoError(t, err)
+
+	data, err := p.Encode()
+	require.NoError(t, err)
+
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, []*duml.Message{m1, m2}, decoded.Messages())
+}
+
+func TestKeepalive(t *testing.T) {
+	var sent atomic.Int32
+
+	k, err := NewKeepalive(nil, 20*time.Millisecond, func() []byte {
+		return []byte{0x01}
+	}, func(data []byte) error {
+		sent.Add(1)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, k.Start())
+	assert.Error(t, k.Start())
+
+	// Keep the session busy. No keepalives should be sent.
+	for i := 0; i < 10; i++ {
+		k.Touch()
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, int32(0), sent.Load())
+
+	// Now let it go idle.
+	assert.Eventually(t, func() bool {
+		return sent.Load() >= 2
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, k.Stop())
+	assert.Error(t, k.Stop())
+
+	_, err = NewKeepalive(nil, 0, func() []byte { return nil },
+		func([]byte) error { return nil })
+	assert.Error(t, err)
+}
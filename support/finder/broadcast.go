@@ -56,6 +56,48 @@ func ParseBroadcast(data []byte) (*Broadcast, error) {
 	}, nil
 }
 
+// NewBroadcast returns a new Broadcast with the given parameters. This is
+// mostly useful for emulating a robot.
+func NewBroadcast(isPairing bool, sourceIp net.IP,
+	sourceMac net.HardwareAddr, appId uint64) (*Broadcast, error) {
+	ip := sourceIp.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("not an IPv4 address")
+	}
+
+	if len(sourceMac) != 6 {
+		return nil, fmt.Errorf("invalid MAC address length")
+	}
+
+	return &Broadcast{
+		isPairing,
+		ip,
+		sourceMac,
+		appId,
+	}, nil
+}
+
+// Encode returns the (encrypted) wire representation of the broadcast
+// message. It is the inverse of ParseBroadcast.
+func (b *Broadcast) Encode() []byte {
+	data := make([]byte, broadcastLen)
+
+	copy(data, broadcastHeader)
+
+	if b.isPairing {
+		data[2] = 1
+	}
+
+	copy(data[6:10], b.sourceIp.To4())
+	copy(data[10:16], b.sourceMac)
+	binary.LittleEndian.PutUint64(data[16:], b.appId)
+
+	// Encode outgoing data.
+	support.SimpleEncryptDecrypt(data)
+
+	return data
+}
+
 func (b *Broadcast) IsPairing() bool {
 	return b.isPairing
 }
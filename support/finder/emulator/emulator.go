@@ -0,0 +1,260 @@
+// Package emulator implements the network side of a Robomaster robot during
+// discovery: it broadcasts (encrypted) discovery messages and accepts the app
+// ID ACKs sent by apps. This allows testing robot discovery without an actual
+// robot.
+package emulator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/finder"
+	"github.com/brunoga/robomaster/support/logger"
+)
+
+// Options are the options for a Robot.
+type Options struct {
+	// IP is the robot IP address. It is reported in broadcast messages and
+	// used as the source address for them.
+	IP net.IP
+
+	// MAC is the robot MAC address reported in broadcast messages.
+	MAC net.HardwareAddr
+
+	// AppID is the app ID the robot is paired with (or is pairing with, if
+	// in pairing mode).
+	AppID uint64
+
+	// Pairing is true if the robot starts in pairing mode.
+	Pairing bool
+
+	// BroadcastAddr is where broadcast messages are sent to.
+	BroadcastAddr string
+
+	// ACKAddr is where the robot listens for app ID ACKs.
+	ACKAddr string
+
+	// BroadcastInterval is the interval between broadcast messages.
+	BroadcastInterval time.Duration
+}
+
+// DefaultOptions are the options for a robot that can be discovered in the
+// loopback interface.
+var DefaultOptions = Options{
+	IP:                net.IPv4(127, 0, 0, 1),
+	MAC:               net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+	AppID:             0,
+	Pairing:           false,
+	BroadcastAddr:     "127.0.0.1:45678",
+	ACKAddr:           "127.0.0.1:56789",
+	BroadcastInterval: 100 * time.Millisecond,
+}
+
+// Robot emulates the discovery related network behavior of a robot.
+type Robot struct {
+	o Options
+	l *logger.Logger
+
+	m        sync.Mutex
+	appID    uint64
+	pairing  bool
+	acks     []uint64
+	ackConn  *net.UDPConn
+	bcstConn *net.UDPConn
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New returns a new Robot with the given options. If o is nil,
+// DefaultOptions is used.
+func New(l *logger.Logger, o *Options) (*Robot, error) {
+	if o == nil {
+		o = &DefaultOptions
+	}
+
+	if o.IP.To4() == nil {
+		return nil, fmt.Errorf("robot IP must be an IPv4 address")
+	}
+
+	if len(o.MAC) != 6 {
+		return nil, fmt.Errorf("invalid MAC address length")
+	}
+
+	if o.BroadcastInterval <= 0 {
+		return nil, fmt.Errorf("broadcast interval must be positive")
+	}
+
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	return &Robot{
+		o:       *o,
+		l:       l.WithGroup("robot_emulator"),
+		appID:   o.AppID,
+		pairing: o.Pairing,
+	}, nil
+}
+
+// Start starts broadcasting and listening for ACKs.
+func (r *Robot) Start() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.quit != nil {
+		return fmt.Errorf("robot emulator already started")
+	}
+
+	ackAddr, err := net.ResolveUDPAddr("udp4", r.o.ACKAddr)
+	if err != nil {
+		return err
+	}
+
+	broadcastAddr, err := net.ResolveUDPAddr("udp4", r.o.BroadcastAddr)
+	if err != nil {
+		return err
+	}
+
+	ackConn, err := net.ListenUDP("udp4", ackAddr)
+	if err != nil {
+		return err
+	}
+
+	// Broadcasts must come from the reported IP or finders will ignore them.
+	bcstConn, err := net.DialUDP("udp4", &net.UDPAddr{IP: r.o.IP},
+		broadcastAddr)
+	if err != nil {
+		ackConn.Close()
+		return err
+	}
+
+	r.ackConn = ackConn
+	r.bcstConn = bcstConn
+	r.quit = make(chan struct{})
+
+	r.wg.Add(2)
+	go r.broadcastLoop(bcstConn, r.quit)
+	go r.ackLoop(ackConn)
+
+	return nil
+}
+
+// Stop stops broadcasting and listening for ACKs.
+func (r *Robot) Stop() error {
+	r.m.Lock()
+
+	if r.quit == nil {
+		r.m.Unlock()
+		return fmt.Errorf("robot emulator not started")
+	}
+
+	close(r.quit)
+	r.ackConn.Close()
+	r.bcstConn.Close()
+
+	r.quit = nil
+	r.ackConn = nil
+	r.bcstConn = nil
+
+	r.m.Unlock()
+
+	r.wg.Wait()
+
+	return nil
+}
+
+// SetPairing enters (or leaves) pairing mode. While in pairing mode, an ACK
+// with the robot app ID (or any ACK, if the robot app ID is zero) pairs the
+// robot with the ACKed app ID and leaves pairing mode.
+func (r *Robot) SetPairing(pairing bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.pairing = pairing
+}
+
+// IsPairing returns true if the robot is in pairing mode.
+func (r *Robot) IsPairing() bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.pairing
+}
+
+// AppID returns the app ID the robot is currently paired with.
+func (r *Robot) AppID() uint64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.appID
+}
+
+// ACKs returns the app IDs of all ACKs received so far.
+func (r *Robot) ACKs() []uint64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return append([]uint64(nil), r.acks...)
+}
+
+func (r *Robot) broadcastLoop(conn *net.UDPConn, quit <-chan struct{}) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.o.BroadcastInterval)
+	defer ticker.Stop()
+
+	for {
+		r.m.Lock()
+		b, err := finder.NewBroadcast(r.pairing, r.o.IP, r.o.MAC, r.appID)
+		r.m.Unlock()
+		if err != nil {
+			r.l.Error("Error creating broadcast message", "error", err)
+			return
+		}
+
+		if _, err := conn.Write(b.Encode()); err != nil {
+			r.l.Debug("Error sending broadcast message", "error", err)
+		}
+
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Robot) ackLoop(conn *net.UDPConn) {
+	defer r.wg.Done()
+
+	buf := make([]byte, 1024)
+
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			r.l.Debug("Stopped listening for ACKs", "error", err)
+			return
+		}
+
+		if n != 8 {
+			r.l.Warn("Unexpected ACK length", "length", n, "source", addr)
+			continue
+		}
+
+		appID := binary.LittleEndian.Uint64(buf[:n])
+
+		r.l.Debug("Received ACK", "appID", appID, "source", addr)
+
+		r.m.Lock()
+		r.acks = append(r.acks, appID)
+		if r.pairing && (r.appID == 0 || r.appID == appID) {
+			r.appID = appID
+			r.pairing = false
+		}
+		r.m.Unlock()
+	}
+}
@@ -0,0 +1,113 @@
+package emulator_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/connection"
+	"github.com/brunoga/robomaster/support/finder"
+	"github.com/brunoga/robomaster/support/finder/emulator"
+	"github.com/brunoga/robomaster/unitybridge"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startRobot(t *testing.T, appID uint64, pairing bool) *emulator.Robot {
+	o := emulator.DefaultOptions
+	o.AppID = appID
+	o.Pairing = pairing
+
+	r, err := emulator.New(nil, &o)
+	require.NoError(t, err)
+	require.NoError(t, r.Start())
+
+	t.Cleanup(func() {
+		assert.NoError(t, r.Stop())
+	})
+
+	return r
+}
+
+func TestFinder(t *testing.T) {
+	startRobot(t, 42, false)
+
+	b, err := finder.New(0, nil).Find(5 * time.Second)
+	require.NoError(t, err)
+
+	assert.True(t, b.SourceIp().Equal(emulator.DefaultOptions.IP))
+	assert.Equal(t, emulator.DefaultOptions.MAC, b.SourceMac())
+	assert.Equal(t, uint64(42), b.AppId())
+	assert.False(t, b.IsPairing())
+}
+
+func TestFinder_AppIDFiltering(t *testing.T) {
+	startRobot(t, 42, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		500*time.Millisecond)
+	defer cancel()
+
+	_, err := finder.New(43, nil).FindContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	b, err := finder.New(42, nil).Find(5 * time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), b.AppId())
+}
+
+func TestPairing(t *testing.T) {
+	r := startRobot(t, 0, true)
+
+	f := finder.New(0, nil)
+
+	b, err := f.Find(5 * time.Second)
+	require.NoError(t, err)
+	assert.True(t, b.IsPairing())
+
+	f.SendACK(b.SourceIp(), 99)
+
+	assert.Eventually(t, func() bool {
+		return !r.IsPairing() && r.AppID() == 99
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The robot now reports the paired app ID.
+	b, err = finder.New(99, nil).Find(5 * time.Second)
+	require.NoError(t, err)
+	assert.False(t, b.IsPairing())
+
+	// ACKs from other apps are ignored while not pairing.
+	f.SendACK(b.SourceIp(), 100)
+
+	assert.Eventually(t, func() bool {
+		return len(r.ACKs()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(99), r.AppID())
+}
+
+func TestConnection_Router(t *testing.T) {
+	r := startRobot(t, 42, false)
+
+	ub := unitybridge.Get(simulator.NewUnityBridgeWrapper(nil), false, nil)
+	require.NoError(t, ub.Start())
+	defer func() {
+		assert.NoError(t, ub.Stop())
+	}()
+
+	c, err := connection.New(ub, nil, 42, connection.TypeRouter)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, c.StartContext(ctx))
+	defer func() {
+		assert.NoError(t, c.Stop())
+	}()
+
+	assert.Eventually(t, func() bool {
+		acks := r.ACKs()
+		return len(acks) == 1 && acks[0] == 42
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -1,7 +1,9 @@
 package finder
 
 import (
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -92,7 +94,24 @@ func (f *Finder) StopFinding() error {
 // Find waits for a robot to broadcast its IP address in the network. It
 // returns a non-nil error if no robot is found in the given timeout.
 func (f *Finder) Find(timeout time.Duration) (*Broadcast, error) {
-	ch := make(chan *Broadcast)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	b, err := f.FindContext(ctx)
+	if err != nil && errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timeout")
+	}
+
+	return b, err
+}
+
+// FindContext waits for a robot to broadcast its IP address in the network
+// until the given context is done. It returns a non-nil error if no robot was
+// found.
+func (f *Finder) FindContext(ctx context.Context) (*Broadcast, error) {
+	// Buffered so the find loop never blocks on a broadcast nobody is
+	// waiting for anymore.
+	ch := make(chan *Broadcast, 1)
 
 	err := f.StartFinding(ch)
 	if err != nil {
@@ -103,8 +122,8 @@ func (f *Finder) Find(timeout time.Duration) (*Broadcast, error) {
 	select {
 	case broadcast := <-ch:
 		return broadcast, nil
-	case <-time.After(timeout):
-		return nil, fmt.Errorf("timeout")
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 }
 
@@ -0,0 +1,18 @@
+// Package motion provides acceleration limited motion profiles that smoothly
+// ramp chassis and gimbal velocities toward their targets instead of applying
+// them instantly.
+//
+// A Runner steps one Profile per axis at a fixed rate and sends the resulting
+// velocities to a Sink. For example, to drive the chassis with trapezoidal
+// profiles at 20 Hz:
+//
+//	x, _ := motion.NewTrapezoidal(1)   // m/s².
+//	y, _ := motion.NewTrapezoidal(1)   // m/s².
+//	z, _ := motion.NewTrapezoidal(180) // degrees/s².
+//
+//	r, _ := motion.NewRunner(nil, 50*time.Millisecond,
+//		motion.ChassisSpeedSink(c, chassis.ModeAngularVelocity), x, y, z)
+//	r.Start()
+//
+//	r.SetTargets(0.5, 0, 0)
+package motion
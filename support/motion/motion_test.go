@@ -0,0 +1,177 @@
+package motion_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/support/motion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dt = 100 * time.Millisecond
+
+func TestTrapezoidal(t *testing.T) {
+	_, err := motion.NewTrapezoidal(0)
+	assert.Error(t, err)
+
+	p, err := motion.NewTrapezoidal(1)
+	require.NoError(t, err)
+
+	var vs []float64
+	for i := 0; i < 5; i++ {
+		vs = append(vs, p.Step(0.35, dt.Seconds()))
+	}
+
+	assert.InDeltaSlice(t, []float64{0.1, 0.2, 0.3, 0.35, 0.35}, vs, 1e-9)
+
+	vs = vs[:0]
+	for i := 0; i < 5; i++ {
+		vs = append(vs, p.Step(0, dt.Seconds()))
+	}
+
+	assert.InDeltaSlice(t, []float64{0.25, 0.15, 0.05, 0, 0}, vs, 1e-9)
+}
+
+func TestSCurve(t *testing.T) {
+	_, err := motion.NewSCurve(1, 0)
+	assert.Error(t, err)
+
+	const maxAcceleration, maxJerk = 2.0, 5.0
+
+	p, err := motion.NewSCurve(maxAcceleration, maxJerk)
+	require.NoError(t, err)
+
+	for _, target := range []float64{1, -0.5, 0} {
+		v, a := p.Velocity(), 0.0
+		dir := math.Copysign(1, target-v)
+
+		for i := 0; p.Velocity() != target; i++ {
+			require.Less(t, i, 1000, "did not reach target %f", target)
+
+			newV := p.Step(target, dt.Seconds())
+			newA := (newV - v) / dt.Seconds()
+
+			// Monotonic, never overshoots and respects the limits (the
+			// last step snaps to the target and is not checked for jerk).
+			assert.GreaterOrEqual(t, (newV-v)*dir, 0.0)
+			assert.LessOrEqual(t, (newV-target)*dir, 0.0)
+			assert.LessOrEqual(t, math.Abs(newA), maxAcceleration+1e-9)
+			if newV != target {
+				assert.LessOrEqual(t, math.Abs(newA-a)/dt.Seconds(),
+					maxJerk+1e-9)
+			}
+
+			v, a = newV, newA
+		}
+	}
+}
+
+func TestRunner(t *testing.T) {
+	var sent [][]float64
+	sink := func(v []float64) error {
+		sent = append(sent, v)
+		return nil
+	}
+
+	x, err := motion.NewTrapezoidal(1)
+	require.NoError(t, err)
+	z, err := motion.NewTrapezoidal(90)
+	require.NoError(t, err)
+
+	_, err = motion.NewRunner(nil, 0, sink, x, z)
+	assert.Error(t, err)
+
+	r, err := motion.NewRunner(nil, dt, sink, x, z)
+	require.NoError(t, err)
+
+	assert.Error(t, r.SetTargets(1))
+	require.NoError(t, r.SetTargets(0.2, -18))
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, r.Step(dt))
+	}
+
+	// Keeps sending while moving.
+	assert.True(t, r.Settled())
+	assertSent(t, [][]float64{
+		{0.1, -9},
+		{0.2, -18},
+		{0.2, -18},
+		{0.2, -18},
+	}, sent)
+
+	sent = nil
+	require.NoError(t, r.SetTargets(0, 0))
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, r.Step(dt))
+	}
+
+	// Stops sending once stopped.
+	assertSent(t, [][]float64{
+		{0.1, -9},
+		{0, 0},
+	}, sent)
+
+	sinkErr := fmt.Errorf("sink error")
+	r, err = motion.NewRunner(nil, dt, func([]float64) error {
+		return sinkErr
+	}, x, z)
+	require.NoError(t, err)
+	assert.ErrorIs(t, r.Step(dt), sinkErr)
+}
+
+func TestRunnerStartStop(t *testing.T) {
+	sent := make(chan []float64, 100)
+
+	x, err := motion.NewSCurve(10, 100)
+	require.NoError(t, err)
+
+	r, err := motion.NewRunner(nil, 10*time.Millisecond,
+		func(v []float64) error {
+			sent <- v
+			return nil
+		}, x)
+	require.NoError(t, err)
+
+	assert.Error(t, r.Stop())
+	require.NoError(t, r.Start())
+	assert.Error(t, r.Start())
+
+	require.NoError(t, r.SetTargets(1))
+
+	require.Eventually(t, r.Settled, time.Second, 10*time.Millisecond)
+	require.NoError(t, r.Stop())
+
+	assert.NotEmpty(t, sent)
+	assert.Equal(t, []float64{1}, r.Velocities())
+}
+
+func TestRunnerSinkCallsRunner(t *testing.T) {
+	x, err := motion.NewTrapezoidal(1)
+	require.NoError(t, err)
+
+	var r *motion.Runner
+	r, err = motion.NewRunner(nil, 10*time.Millisecond,
+		func(v []float64) error {
+			// Must not deadlock.
+			return r.SetTargets(r.Velocities()...)
+		}, x)
+	require.NoError(t, err)
+
+	require.NoError(t, r.SetTargets(1))
+	require.NoError(t, r.Step(10*time.Millisecond))
+	assert.InDeltaSlice(t, []float64{0.01}, r.Velocities(), 1e-9)
+}
+
+func assertSent(t *testing.T, expected, sent [][]float64) {
+	t.Helper()
+
+	require.Len(t, sent, len(expected))
+	for i := range expected {
+		assert.InDeltaSlice(t, expected[i], sent[i], 1e-9, "command %d", i)
+	}
+}
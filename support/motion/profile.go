@@ -0,0 +1,151 @@
+package motion
+
+import (
+	"fmt"
+	"math"
+)
+
+// Profile shapes the velocity of a single axis as it ramps toward a target
+// velocity.
+type Profile interface {
+	// Step advances the profile by dt seconds toward the given target
+	// velocity and returns the new velocity.
+	Step(target, dt float64) float64
+
+	// Velocity returns the current velocity.
+	Velocity() float64
+
+	// Reset sets the current velocity to the given one (with no
+	// acceleration).
+	Reset(velocity float64)
+}
+
+// Trapezoidal is a Profile that changes velocity with at most a given
+// acceleration. Starting and stopping a move results in a trapezoidal velocity
+// curve (constant acceleration, cruise, constant deceleration).
+type Trapezoidal struct {
+	maxAcceleration float64
+	velocity        float64
+}
+
+var _ Profile = (*Trapezoidal)(nil)
+
+// NewTrapezoidal returns a new Trapezoidal profile with the given maximum
+// acceleration (in velocity units per second).
+func NewTrapezoidal(maxAcceleration float64) (*Trapezoidal, error) {
+	if maxAcceleration <= 0 {
+		return nil, fmt.Errorf("invalid maximum acceleration: %f",
+			maxAcceleration)
+	}
+
+	return &Trapezoidal{
+		maxAcceleration: maxAcceleration,
+	}, nil
+}
+
+// Step implements Profile.
+func (t *Trapezoidal) Step(target, dt float64) float64 {
+	maxDelta := t.maxAcceleration * dt
+
+	t.velocity += math.Max(-maxDelta, math.Min(maxDelta, target-t.velocity))
+
+	return t.velocity
+}
+
+// Velocity implements Profile.
+func (t *Trapezoidal) Velocity() float64 {
+	return t.velocity
+}
+
+// Reset implements Profile.
+func (t *Trapezoidal) Reset(velocity float64) {
+	t.velocity = velocity
+}
+
+// SCurve is a Profile that changes velocity with at most a given acceleration
+// and changes acceleration with at most a given jerk. This smooths the
+// transitions between accelerating and cruising, resulting in an S shaped
+// velocity curve.
+type SCurve struct {
+	maxAcceleration float64
+	maxJerk         float64
+	velocity        float64
+	acceleration    float64
+}
+
+var _ Profile = (*SCurve)(nil)
+
+// NewSCurve returns a new SCurve profile with the given maximum acceleration
+// (in velocity units per second) and jerk (in velocity units per second
+// squared).
+func NewSCurve(maxAcceleration, maxJerk float64) (*SCurve, error) {
+	if maxAcceleration <= 0 {
+		return nil, fmt.Errorf("invalid maximum acceleration: %f",
+			maxAcceleration)
+	}
+
+	if maxJerk <= 0 {
+		return nil, fmt.Errorf("invalid maximum jerk: %f", maxJerk)
+	}
+
+	return &SCurve{
+		maxAcceleration: maxAcceleration,
+		maxJerk:         maxJerk,
+	}, nil
+}
+
+// Step implements Profile.
+func (s *SCurve) Step(target, dt float64) float64 {
+	diff := target - s.velocity
+	if diff == 0 && s.acceleration == 0 {
+		return s.velocity
+	}
+
+	dir := sign(diff)
+	maxDelta := s.maxJerk * dt
+
+	// Velocity change that happens while bringing the current acceleration
+	// down to zero with maximum jerk.
+	stopping := s.acceleration * math.Abs(s.acceleration) / (2 * s.maxJerk)
+
+	if sign(s.acceleration) == dir && math.Abs(diff) <= math.Abs(stopping) {
+		// Time to start reducing acceleration.
+		s.acceleration -= math.Max(-maxDelta, math.Min(maxDelta,
+			s.acceleration))
+	} else {
+		s.acceleration = math.Max(-s.maxAcceleration, math.Min(
+			s.maxAcceleration, s.acceleration+dir*maxDelta))
+	}
+
+	s.velocity += s.acceleration * dt
+
+	if sign(target-s.velocity) != dir {
+		// Do not overshoot.
+		s.velocity = target
+		s.acceleration = 0
+	}
+
+	return s.velocity
+}
+
+// Velocity implements Profile.
+func (s *SCurve) Velocity() float64 {
+	return s.velocity
+}
+
+// Reset implements Profile.
+func (s *SCurve) Reset(velocity float64) {
+	s.velocity = velocity
+	s.acceleration = 0
+}
+
+func sign(v float64) float64 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,204 @@
+package motion
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/logger"
+)
+
+// Sink receives the velocities (one per axis) computed by a Runner. It is
+// usually a function that sends them to the robot.
+type Sink func(velocities []float64) error
+
+// Runner ramps a set of axes toward their target velocities at a fixed rate,
+// using one Profile per axis, and sends the resulting velocities to a Sink.
+// Velocities are sent whenever they change and, while any of them is not
+// zero, at every step (which keeps any command watchdog fed).
+type Runner struct {
+	l        *logger.Logger
+	period   time.Duration
+	sink     Sink
+	profiles []Profile
+
+	m          sync.Mutex
+	targets    []float64
+	velocities []float64
+	sent       bool
+	quitCh     chan struct{}
+	doneCh     chan struct{}
+}
+
+// NewRunner returns a new Runner that steps the given profiles (one per axis)
+// every period and sends the resulting velocities to the given sink.
+func NewRunner(l *logger.Logger, period time.Duration, sink Sink,
+	profiles ...Profile) (*Runner, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("invalid period: %s", period)
+	}
+
+	if sink == nil {
+		return nil, fmt.Errorf("sink must not be nil")
+	}
+
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("at least one profile is required")
+	}
+
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	l = l.WithGroup("motion_runner")
+
+	velocities := make([]float64, len(profiles))
+	for i, p := range profiles {
+		velocities[i] = p.Velocity()
+	}
+
+	return &Runner{
+		l:          l,
+		period:     period,
+		sink:       sink,
+		profiles:   profiles,
+		targets:    make([]float64, len(profiles)),
+		velocities: velocities,
+	}, nil
+}
+
+// SetTargets sets the target velocities (one per axis).
+func (r *Runner) SetTargets(targets ...float64) error {
+	if len(targets) != len(r.profiles) {
+		return fmt.Errorf("expected %d targets, got %d", len(r.profiles),
+			len(targets))
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	copy(r.targets, targets)
+
+	return nil
+}
+
+// Velocities returns the last computed velocities.
+func (r *Runner) Velocities() []float64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return append([]float64(nil), r.velocities...)
+}
+
+// Settled returns true if all axes reached their target velocities.
+func (r *Runner) Settled() bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for i, v := range r.velocities {
+		if v != r.targets[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Step advances all profiles by dt and sends the resulting velocities to the
+// sink if needed. This is called automatically at every period after Start
+// but it can also be called directly (for example, to drive a Runner with a
+// simulated clock).
+func (r *Runner) Step(dt time.Duration) error {
+	r.m.Lock()
+
+	changed := false
+	moving := false
+	for i, p := range r.profiles {
+		v := p.Step(r.targets[i], dt.Seconds())
+		if v != r.velocities[i] {
+			changed = true
+		}
+
+		if v != 0 {
+			moving = true
+		}
+
+		r.velocities[i] = v
+	}
+
+	if r.sent && !changed && !moving {
+		r.m.Unlock()
+		return nil
+	}
+
+	// Always send the first velocities so the robot state is known.
+	r.sent = true
+
+	velocities := append([]float64(nil), r.velocities...)
+
+	r.m.Unlock()
+
+	// The sink is called without holding the lock so it can safely call
+	// back into the Runner.
+	return r.sink(velocities)
+}
+
+// Start starts stepping the profiles every period.
+func (r *Runner) Start() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.quitCh != nil {
+		return fmt.Errorf("runner already started")
+	}
+
+	r.quitCh = make(chan struct{})
+	r.doneCh = make(chan struct{})
+
+	go r.loop(r.quitCh, r.doneCh)
+
+	return nil
+}
+
+// Stop stops stepping the profiles. It does not send any velocities so the
+// robot keeps moving with the last ones sent (set all targets to zero and
+// wait for the runner to settle before stopping to stop the robot smoothly).
+func (r *Runner) Stop() error {
+	r.m.Lock()
+
+	if r.quitCh == nil {
+		r.m.Unlock()
+		return fmt.Errorf("runner not started")
+	}
+
+	close(r.quitCh)
+	doneCh := r.doneCh
+
+	r.quitCh = nil
+	r.doneCh = nil
+
+	r.m.Unlock()
+
+	<-doneCh
+
+	return nil
+}
+
+func (r *Runner) loop(quitCh <-chan struct{}, doneCh chan<- struct{}) {
+	defer close(doneCh)
+
+	ticker := time.NewTicker(r.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Step(r.period); err != nil {
+				r.l.Error("Error sending velocities.", "error", err)
+			}
+		case <-quitCh:
+			return
+		}
+	}
+}
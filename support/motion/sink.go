@@ -0,0 +1,35 @@
+package motion
+
+import (
+	"math"
+
+	"github.com/brunoga/robomaster/module/chassis"
+	"github.com/brunoga/robomaster/module/gimbal"
+)
+
+// ChassisSpeedSink returns a Sink that sets the chassis speed using the given
+// mode. It expects 3 velocities (x and y in m/s and z in degrees/s, as in
+// Chassis.SetSpeed).
+func ChassisSpeedSink(c *chassis.Chassis, m chassis.Mode) Sink {
+	return func(v []float64) error {
+		if v[0] == 0 && v[1] == 0 && v[2] == 0 {
+			return c.StopMovement(m)
+		}
+
+		return c.SetSpeed(m, v[0], v[1], v[2])
+	}
+}
+
+// GimbalRotationSpeedSink returns a Sink that sets the gimbal rotation speed.
+// It expects 2 velocities (pitch and yaw in degrees/s, as in
+// Gimbal.SetRotationSpeed). Velocities are rounded to the nearest integer.
+func GimbalRotationSpeedSink(g *gimbal.Gimbal) Sink {
+	return func(v []float64) error {
+		pitch, yaw := int16(math.Round(v[0])), int16(math.Round(v[1]))
+		if pitch == 0 && yaw == 0 {
+			return g.StopRotation()
+		}
+
+		return g.SetRotationSpeed(pitch, yaw)
+	}
+}
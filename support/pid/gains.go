@@ -0,0 +1,9 @@
+package pid
+
+// Gains are the proportional, integral and derivative multipliers for a
+// PIDController.
+type Gains struct {
+	Kp float64
+	Ki float64
+	Kd float64
+}
@@ -42,6 +42,12 @@ func (i *IController) Output(currentError float64) float64 {
 
 // Adjust modifies the integral term to counteract windup.
 func (i *IController) Adjust(adjustment float64) {
+	if i.ki == 0 {
+		// No integral component so nothing to adjust (and avoid dividing by
+		// zero).
+		return
+	}
+
 	// The adjustment is subtracted because if the output is too high, we want
 	// to reduce the integral and vice versa.
 	i.integral -= adjustment / i.ki
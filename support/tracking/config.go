@@ -0,0 +1,166 @@
+package tracking
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/brunoga/robomaster/module/camera"
+	"github.com/brunoga/robomaster/support/pid"
+	"github.com/brunoga/robomaster/unitybridge/unity/control"
+)
+
+// Config is the Tracker configuration.
+type Config struct {
+	// Yaw and Pitch gains are used to compute the gimbal rotation speed
+	// (degrees/s) from the angle (degrees) between the camera center and the
+	// target.
+	Yaw   pid.Gains
+	Pitch pid.Gains
+
+	// MaxSpeed is the maximum gimbal rotation speed (degrees/s).
+	MaxSpeed float64
+
+	// Deadband is the angle error (degrees) below which an axis is
+	// considered centered on the target.
+	Deadband float64
+
+	// LostTimeout is how long the target can go without updates before it
+	// is considered lost.
+	LostTimeout time.Duration
+
+	// Frame size in pixels and field of view in degrees of the images the
+	// target coordinates refer to. If the camera digital zoom is in use, the
+	// field of view must be divided by the zoom factor.
+	FrameWidth    int
+	FrameHeight   int
+	HorizontalFOV float64
+	VerticalFOV   float64
+
+	// ChassisFollow makes the chassis turn toward the gimbal yaw so the
+	// gimbal can be kept centered while tracking. Requires a chassis to be
+	// passed to New.
+	ChassisFollow bool
+	// Chassis gains are used to compute the chassis rotation speed
+	// (degrees/s) from the gimbal yaw (degrees) relative to the chassis.
+	Chassis pid.Gains
+	// MaxChassisYawRate is the maximum chassis rotation speed (degrees/s).
+	// It can not be higher than control.ChassisMaxYawSpeed.
+	MaxChassisYawRate float64
+	// ChassisDeadband is the gimbal yaw (degrees) below which the chassis
+	// does not turn.
+	ChassisDeadband float64
+
+	// Period is how often the gimbal (and chassis) speeds are updated.
+	Period time.Duration
+}
+
+// DefaultConfig returns a configuration that works for most cases using the
+// camera default resolution and field of view.
+func DefaultConfig() *Config {
+	return &Config{
+		Yaw: pid.Gains{
+			Kp: 4,
+		},
+		Pitch: pid.Gains{
+			Kp: 4,
+		},
+		MaxSpeed:          180,
+		Deadband:          1,
+		LostTimeout:       500 * time.Millisecond,
+		FrameWidth:        camera.HorizontalResolutionPoints,
+		FrameHeight:       camera.VerticalResolutionPoints,
+		HorizontalFOV:     camera.HorizontalFOVDegrees,
+		VerticalFOV:       camera.VerticalFOVDegrees,
+		Chassis:           pid.Gains{Kp: 2},
+		MaxChassisYawRate: 90,
+		ChassisDeadband:   5,
+		Period:            50 * time.Millisecond,
+	}
+}
+
+// Validate returns an error if the configuration is not valid.
+func (c *Config) Validate() error {
+	if c.MaxSpeed <= 0 || c.MaxSpeed > 360 {
+		return fmt.Errorf("invalid maximum speed: %f", c.MaxSpeed)
+	}
+
+	if c.Deadband < 0 {
+		return fmt.Errorf("invalid deadband: %f", c.Deadband)
+	}
+
+	if c.LostTimeout <= 0 {
+		return fmt.Errorf("invalid lost timeout: %s", c.LostTimeout)
+	}
+
+	if c.FrameWidth <= 0 || c.FrameHeight <= 0 {
+		return fmt.Errorf("invalid frame size: %dx%d", c.FrameWidth,
+			c.FrameHeight)
+	}
+
+	if c.HorizontalFOV <= 0 || c.HorizontalFOV >= 180 {
+		return fmt.Errorf("invalid horizontal field of view: %f",
+			c.HorizontalFOV)
+	}
+
+	if c.VerticalFOV <= 0 || c.VerticalFOV >= 180 {
+		return fmt.Errorf("invalid vertical field of view: %f",
+			c.VerticalFOV)
+	}
+
+	if c.ChassisFollow {
+		if c.MaxChassisYawRate <= 0 ||
+			c.MaxChassisYawRate > control.ChassisMaxYawSpeed {
+			return fmt.Errorf("invalid maximum chassis yaw rate: %f",
+				c.MaxChassisYawRate)
+		}
+
+		if c.ChassisDeadband < 0 {
+			return fmt.Errorf("invalid chassis deadband: %f",
+				c.ChassisDeadband)
+		}
+	}
+
+	if c.Period <= 0 {
+		return fmt.Errorf("invalid period: %s", c.Period)
+	}
+
+	return nil
+}
+
+// Angles converts the given pixel coordinates to the angles (in degrees)
+// between the camera optical axis and the ray through that pixel. Yaw is
+// positive to the right of the image center and pitch is positive above it.
+func (c *Config) Angles(x, y float64) (yaw, pitch float64) {
+	halfWidth := float64(c.FrameWidth) / 2
+	halfHeight := float64(c.FrameHeight) / 2
+
+	// Pinhole camera model: the distance from the center is proportional to
+	// the tangent of the angle.
+	tanX := (x - halfWidth) / halfWidth * math.Tan(radians(c.HorizontalFOV/2))
+	tanY := (halfHeight - y) / halfHeight * math.Tan(radians(c.VerticalFOV/2))
+
+	return degrees(math.Atan(tanX)), degrees(math.Atan(tanY))
+}
+
+// Pixel is the inverse of Angles. It returns the pixel coordinates for the
+// given angles (in degrees) from the camera optical axis.
+func (c *Config) Pixel(yaw, pitch float64) (x, y float64) {
+	halfWidth := float64(c.FrameWidth) / 2
+	halfHeight := float64(c.FrameHeight) / 2
+
+	x = halfWidth + math.Tan(radians(yaw))/
+		math.Tan(radians(c.HorizontalFOV/2))*halfWidth
+	y = halfHeight - math.Tan(radians(pitch))/
+		math.Tan(radians(c.VerticalFOV/2))*halfHeight
+
+	return x, y
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
@@ -0,0 +1,332 @@
+// Package tracking provides a visual target tracker that drives the gimbal
+// (and optionally the chassis) to keep a target detected in camera frames
+// centered in the image.
+package tracking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+	"github.com/brunoga/robomaster/module/gimbal"
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/pid"
+)
+
+// State is the tracking state.
+type State uint8
+
+const (
+	// StateWaiting means no target was seen yet.
+	StateWaiting State = iota
+	// StateTracking means the target is being tracked.
+	StateTracking
+	// StateLost means the target was cleared or not updated for longer than
+	// the configured lost timeout.
+	StateLost
+)
+
+// String returns a human readable representation of the state.
+func (s State) String() string {
+	switch s {
+	case StateWaiting:
+		return "Waiting"
+	case StateTracking:
+		return "Tracking"
+	case StateLost:
+		return "Lost"
+	default:
+		return fmt.Sprintf("Unknown(%d)", s)
+	}
+}
+
+// Gimbal is the subset of the gimbal module used by the Tracker. It is
+// implemented by *gimbal.Gimbal.
+type Gimbal interface {
+	Attitude() gimbal.Attitude
+	SetRotationSpeed(pitch, yaw int16) error
+	StopRotation() error
+}
+
+var _ Gimbal = (*gimbal.Gimbal)(nil)
+
+// Chassis is the subset of the chassis module used by the Tracker. It is
+// implemented by *chassis.Chassis.
+type Chassis interface {
+	SetSpeed(m chassis.Mode, x, y, z float64) error
+	StopMovement(m chassis.Mode) error
+}
+
+var _ Chassis = (*chassis.Chassis)(nil)
+
+// target is the last known target position.
+type target struct {
+	// Degrees. The yaw is in the world frame (see gimbal.Attitude.YawOpposite)
+	// so chassis rotation does not move the target.
+	yaw, pitch float64
+	time       time.Time
+}
+
+// Tracker drives the gimbal rotation speed to center a target given in image
+// (pixel) coordinates. Target positions come from an external detector
+// (usually running on camera frames) through SetTarget. The angle between the
+// image center and the target is used to compute the gimbal rotation speed
+// for each axis (using PID controllers). If enabled, the chassis is turned
+// toward the gimbal yaw (in chassis.ModeAngularVelocity mode) so the gimbal
+// does not run out of yaw range.
+type Tracker struct {
+	l   *logger.Logger
+	g   Gimbal
+	c   Chassis
+	cfg Config
+
+	m         sync.Mutex
+	running   bool
+	state     State
+	target    target
+	hasTarget bool
+}
+
+// New returns a new Tracker that drives the given gimbal (and, if chassis
+// follow is enabled, the given chassis) using the given configuration. If cfg
+// is nil, DefaultConfig is used.
+func New(l *logger.Logger, g Gimbal, c Chassis, cfg *Config) (*Tracker,
+	error) {
+	if g == nil {
+		return nil, fmt.Errorf("gimbal must not be nil")
+	}
+
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.ChassisFollow && c == nil {
+		return nil, fmt.Errorf("chassis follow requires a chassis")
+	}
+
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	l = l.WithGroup("tracker")
+
+	return &Tracker{
+		l:   l,
+		g:   g,
+		c:   c,
+		cfg: *cfg,
+	}, nil
+}
+
+// SetTarget sets the current target position in pixels (relative to the
+// top-left corner of the configured frame size). It must be called whenever
+// the detector finds the target. The gimbal attitude at the time of the call
+// is used to compensate for gimbal (and chassis) movement until the next
+// update.
+func (t *Tracker) SetTarget(x, y float64) error {
+	if x < 0 || x > float64(t.cfg.FrameWidth) || y < 0 ||
+		y > float64(t.cfg.FrameHeight) {
+		return fmt.Errorf("target (%.1f, %.1f) outside of the %dx%d frame",
+			x, y, t.cfg.FrameWidth, t.cfg.FrameHeight)
+	}
+
+	yaw, pitch := t.cfg.Angles(x, y)
+	a := t.g.Attitude()
+
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	t.target = target{
+		yaw:   a.YawOpposite + yaw,
+		pitch: a.Pitch + pitch,
+		time:  time.Now(),
+	}
+	t.hasTarget = true
+
+	return nil
+}
+
+// ClearTarget marks the target as lost immediately. It should be called when
+// the detector knows the target is not visible anymore.
+func (t *Tracker) ClearTarget() {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	t.hasTarget = false
+}
+
+// State returns the current tracking state.
+func (t *Tracker) State() State {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	return t.state
+}
+
+// Run tracks the target until the given context is done, returning its
+// error. The given function (which might be nil) is called whenever the
+// tracking state changes. The gimbal (and chassis) are stopped whenever the
+// target is lost and when Run returns.
+func (t *Tracker) Run(ctx context.Context,
+	onStateChange func(State)) error {
+	t.m.Lock()
+	if t.running {
+		t.m.Unlock()
+		return fmt.Errorf("tracker already running")
+	}
+
+	t.running = true
+	t.state = StateWaiting
+	t.m.Unlock()
+
+	defer func() {
+		t.m.Lock()
+		t.running = false
+		t.m.Unlock()
+
+		t.stop()
+	}()
+
+	ticker := time.NewTicker(t.cfg.Period)
+	defer ticker.Stop()
+
+	var yawPID, pitchPID, chassisPID pid.Controller
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		t.m.Lock()
+		tg, fresh := t.target, t.hasTarget &&
+			time.Since(t.target.time) <= t.cfg.LostTimeout
+		previous := t.state
+		t.m.Unlock()
+
+		if !fresh {
+			if previous == StateTracking {
+				t.l.Debug("Target lost.")
+
+				t.stop()
+				t.setState(StateLost, onStateChange)
+			}
+
+			continue
+		}
+
+		if previous != StateTracking {
+			t.l.Debug("Target acquired.")
+
+			// Start fresh as the target might be anywhere now.
+			yawPID, pitchPID, chassisPID = t.newControllers()
+			t.setState(StateTracking, onStateChange)
+		}
+
+		err := t.step(tg, yawPID, pitchPID, chassisPID)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// step sets the gimbal (and chassis) speeds to move toward the given target.
+func (t *Tracker) step(tg target, yawPID, pitchPID,
+	chassisPID pid.Controller) error {
+	a := t.g.Attitude()
+
+	// The target yaw is not wrapped so it might be on the other side of the
+	// -180/180 seam.
+	yawError := deadband(normalizeAngle(tg.yaw-a.YawOpposite),
+		t.cfg.Deadband)
+	pitchError := deadband(tg.pitch-a.Pitch, t.cfg.Deadband)
+
+	yawSpeed := yawPID.Output(yawError)
+	pitchSpeed := pitchPID.Output(pitchError)
+
+	err := t.g.SetRotationSpeed(int16(math.Round(pitchSpeed)),
+		int16(math.Round(yawSpeed)))
+	if err != nil {
+		return err
+	}
+
+	if !t.cfg.ChassisFollow {
+		return nil
+	}
+
+	// The gimbal yaw is relative to the chassis so turning the chassis
+	// toward it brings it back to the center.
+	z := chassisPID.Output(deadband(a.Yaw, t.cfg.ChassisDeadband))
+
+	return t.c.SetSpeed(chassis.ModeAngularVelocity, 0, 0, z)
+}
+
+// stop stops the gimbal and, if chassis follow is enabled, the chassis.
+func (t *Tracker) stop() {
+	if err := t.g.StopRotation(); err != nil {
+		t.l.Error("Error stopping gimbal.", "error", err)
+	}
+
+	if !t.cfg.ChassisFollow {
+		return
+	}
+
+	if err := t.c.StopMovement(chassis.ModeAngularVelocity); err != nil {
+		t.l.Error("Error stopping chassis.", "error", err)
+	}
+}
+
+// setState sets the tracking state and calls the given function (if not nil)
+// with it.
+func (t *Tracker) setState(s State, onStateChange func(State)) {
+	t.m.Lock()
+	t.state = s
+	t.m.Unlock()
+
+	if onStateChange != nil {
+		onStateChange(s)
+	}
+}
+
+// newControllers returns new PID controllers for the gimbal yaw and pitch and
+// the chassis rotation.
+func (t *Tracker) newControllers() (yaw, pitch, chassis pid.Controller) {
+	yaw = pid.NewPIDController(t.cfg.Yaw.Kp, t.cfg.Yaw.Ki, t.cfg.Yaw.Kd,
+		-t.cfg.MaxSpeed, t.cfg.MaxSpeed)
+	pitch = pid.NewPIDController(t.cfg.Pitch.Kp, t.cfg.Pitch.Ki,
+		t.cfg.Pitch.Kd, -t.cfg.MaxSpeed, t.cfg.MaxSpeed)
+	chassis = pid.NewPIDController(t.cfg.Chassis.Kp, t.cfg.Chassis.Ki,
+		t.cfg.Chassis.Kd, -t.cfg.MaxChassisYawRate, t.cfg.MaxChassisYawRate)
+
+	return yaw, pitch, chassis
+}
+
+// deadband returns 0 if the absolute value of the given error is not bigger
+// than the given deadband and the error otherwise.
+// normalizeAngle returns the given angle (in degrees) normalized to the
+// [-180, 180) range.
+func normalizeAngle(a float64) float64 {
+	a = math.Mod(a+180, 360)
+	if a < 0 {
+		a += 360
+	}
+
+	return a - 180
+}
+
+func deadband(err, deadband float64) float64 {
+	if math.Abs(err) <= deadband {
+		return 0
+	}
+
+	return err
+}
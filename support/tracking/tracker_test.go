@@ -0,0 +1,368 @@
+package tracking_test
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+	"github.com/brunoga/robomaster/module/gimbal"
+	"github.com/brunoga/robomaster/support/tracking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGimbal is a gimbal that rotates with the last speeds set. It is mounted
+// on the given chassis (if any) so chassis rotation changes its world yaw.
+type fakeGimbal struct {
+	m          sync.Mutex
+	c          *fakeChassis
+	attitude   gimbal.Attitude
+	lastUpdate time.Time
+}
+
+func newFakeGimbal(c *fakeChassis) *fakeGimbal {
+	now := time.Now()
+
+	return &fakeGimbal{
+		c:          c,
+		attitude:   gimbal.Attitude{Time: now},
+		lastUpdate: now,
+	}
+}
+
+func (g *fakeGimbal) Attitude() gimbal.Attitude {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	g.update()
+
+	return g.attitude
+}
+
+func (g *fakeGimbal) SetRotationSpeed(pitch, yaw int16) error {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	g.update()
+	g.attitude.PitchSpeed = float64(pitch)
+	g.attitude.YawSpeed = float64(yaw)
+
+	return nil
+}
+
+func (g *fakeGimbal) StopRotation() error {
+	return g.SetRotationSpeed(0, 0)
+}
+
+func (g *fakeGimbal) moving() bool {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	return g.attitude.PitchSpeed != 0 || g.attitude.YawSpeed != 0
+}
+
+func (g *fakeGimbal) update() {
+	now := time.Now()
+	dt := now.Sub(g.lastUpdate).Seconds()
+	g.lastUpdate = now
+
+	g.attitude.Pitch += g.attitude.PitchSpeed * dt
+	g.attitude.Yaw += g.attitude.YawSpeed * dt
+	g.attitude.YawOpposite = g.attitude.Yaw
+	if g.c != nil {
+		g.attitude.YawOpposite += g.c.heading()
+	}
+
+	// Like the robot, report the world yaw in the [-180, 180) range.
+	g.attitude.YawOpposite = math.Mod(g.attitude.YawOpposite+180, 360)
+	if g.attitude.YawOpposite < 0 {
+		g.attitude.YawOpposite += 360
+	}
+	g.attitude.YawOpposite -= 180
+	g.attitude.Time = now
+}
+
+// fakeChassis rotates with the last rotation speed set.
+type fakeChassis struct {
+	m          sync.Mutex
+	z          float64
+	yaw        float64
+	lastUpdate time.Time
+}
+
+func (c *fakeChassis) SetSpeed(m chassis.Mode, x, y, z float64) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.update()
+	c.z = z
+
+	return nil
+}
+
+func (c *fakeChassis) StopMovement(m chassis.Mode) error {
+	return c.SetSpeed(m, 0, 0, 0)
+}
+
+func (c *fakeChassis) rotationSpeed() float64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.z
+}
+
+// heading returns the chassis yaw in the world frame, in degrees.
+func (c *fakeChassis) heading() float64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.update()
+
+	return c.yaw
+}
+
+func (c *fakeChassis) update() {
+	now := time.Now()
+	if !c.lastUpdate.IsZero() {
+		c.yaw += c.z * now.Sub(c.lastUpdate).Seconds()
+	}
+	c.lastUpdate = now
+}
+
+func TestConfigAngles(t *testing.T) {
+	cfg := tracking.DefaultConfig()
+
+	yaw, pitch := cfg.Angles(float64(cfg.FrameWidth)/2,
+		float64(cfg.FrameHeight)/2)
+	assert.InDelta(t, 0, yaw, 1e-9)
+	assert.InDelta(t, 0, pitch, 1e-9)
+
+	yaw, pitch = cfg.Angles(float64(cfg.FrameWidth), 0)
+	assert.InDelta(t, cfg.HorizontalFOV/2, yaw, 1e-9)
+	assert.InDelta(t, cfg.VerticalFOV/2, pitch, 1e-9)
+
+	x, y := cfg.Pixel(-20, 10)
+	yaw, pitch = cfg.Angles(x, y)
+	assert.InDelta(t, -20, yaw, 1e-9)
+	assert.InDelta(t, 10, pitch, 1e-9)
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := tracking.New(nil, nil, nil, nil)
+	assert.Error(t, err)
+
+	cfg := tracking.DefaultConfig()
+	cfg.ChassisFollow = true
+	_, err = tracking.New(nil, newFakeGimbal(nil), nil, cfg)
+	assert.Error(t, err)
+
+	cfg = tracking.DefaultConfig()
+	cfg.HorizontalFOV = 0
+	_, err = tracking.New(nil, newFakeGimbal(nil), nil, cfg)
+	assert.Error(t, err)
+
+	cfg = tracking.DefaultConfig()
+	cfg.ChassisFollow = true
+	cfg.MaxChassisYawRate = 400
+	_, err = tracking.New(nil, newFakeGimbal(nil), &fakeChassis{}, cfg)
+	assert.Error(t, err)
+}
+
+func TestTrackerCentersTarget(t *testing.T) {
+	c := &fakeChassis{}
+	g := newFakeGimbal(c)
+
+	cfg := tracking.DefaultConfig()
+	cfg.ChassisFollow = true
+	cfg.ChassisDeadband = 10
+	cfg.Period = 10 * time.Millisecond
+	cfg.LostTimeout = 100 * time.Millisecond
+
+	tr, err := tracking.New(nil, g, c, cfg)
+	require.NoError(t, err)
+
+	assert.Error(t, tr.SetTarget(-1, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var m sync.Mutex
+	var states []tracking.State
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- tr.Run(ctx, func(s tracking.State) {
+			m.Lock()
+			defer m.Unlock()
+
+			states = append(states, s)
+		})
+	}()
+
+	// Target at a fixed position in the world frame, reported by a fake
+	// detector.
+	const targetYaw, targetPitch = 30.0, -10.0
+
+	feedCtx, stopFeeding := context.WithCancel(ctx)
+	feedDone := make(chan struct{})
+	go func() {
+		defer close(feedDone)
+
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-feedCtx.Done():
+				return
+			}
+
+			a := g.Attitude()
+			x, y := cfg.Pixel(targetYaw-a.YawOpposite,
+				targetPitch-a.Pitch)
+			assert.NoError(t, tr.SetTarget(x, y))
+		}
+	}()
+
+	assert.Eventually(t, func() bool {
+		return c.rotationSpeed() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		a := g.Attitude()
+		return math.Abs(a.YawOpposite-targetYaw) <= cfg.Deadband+1 &&
+			math.Abs(a.Pitch-targetPitch) <= cfg.Deadband+1 &&
+			math.Abs(a.Yaw) <= cfg.ChassisDeadband+1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, tracking.StateTracking, tr.State())
+
+	stopFeeding()
+	<-feedDone
+
+	assert.Eventually(t, func() bool {
+		return tr.State() == tracking.StateLost
+	}, time.Second, 10*time.Millisecond)
+
+	assert.False(t, g.moving())
+	assert.Zero(t, c.rotationSpeed())
+
+	cancel()
+	assert.ErrorIs(t, <-runErr, context.Canceled)
+
+	m.Lock()
+	defer m.Unlock()
+
+	assert.Equal(t, []tracking.State{tracking.StateTracking,
+		tracking.StateLost}, states)
+}
+
+func TestTrackerCompensatesChassisRotation(t *testing.T) {
+	c := &fakeChassis{}
+	g := newFakeGimbal(c)
+
+	cfg := tracking.DefaultConfig()
+	cfg.Period = 10 * time.Millisecond
+	cfg.LostTimeout = 5 * time.Second
+
+	tr, err := tracking.New(nil, g, nil, cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- tr.Run(ctx, nil)
+	}()
+
+	// Target centered in the image, set only once.
+	require.NoError(t, tr.SetTarget(cfg.Pixel(0, 0)))
+
+	// Rotate the chassis under the gimbal. The target does not move in the
+	// world so the gimbal must rotate the other way.
+	const chassisRate = 30.0
+	require.NoError(t, c.SetSpeed(chassis.ModeAngularVelocity, 0, 0,
+		chassisRate))
+
+	assert.Eventually(t, func() bool {
+		return g.Attitude().Yaw < -20
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A proportional controller lags a moving target by rate / Kp.
+	assert.InDelta(t, 0, g.Attitude().YawOpposite,
+		chassisRate/cfg.Yaw.Kp+cfg.Deadband+1)
+
+	require.NoError(t, c.StopMovement(chassis.ModeAngularVelocity))
+
+	cancel()
+	assert.ErrorIs(t, <-runErr, context.Canceled)
+}
+
+func TestTrackerWrapsYaw(t *testing.T) {
+	g := newFakeGimbal(nil)
+	g.attitude.Yaw = 170
+
+	cfg := tracking.DefaultConfig()
+	cfg.Period = 10 * time.Millisecond
+	cfg.LostTimeout = 5 * time.Second
+
+	tr, err := tracking.New(nil, g, nil, cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- tr.Run(ctx, nil)
+	}()
+
+	// Target 20 degrees to the right, across the -180/180 seam.
+	require.NoError(t, tr.SetTarget(cfg.Pixel(20, 0)))
+
+	assert.Eventually(t, func() bool {
+		a := g.Attitude()
+		return math.Abs(a.YawOpposite+170) <= cfg.Deadband+1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The gimbal took the short way and stopped there.
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, g.moving())
+	assert.InDelta(t, 190, g.Attitude().Yaw, cfg.Deadband+1)
+
+	cancel()
+	assert.ErrorIs(t, <-runErr, context.Canceled)
+}
+
+func TestTrackerClearTarget(t *testing.T) {
+	g := newFakeGimbal(nil)
+
+	cfg := tracking.DefaultConfig()
+	cfg.Period = 10 * time.Millisecond
+
+	tr, err := tracking.New(nil, g, nil, cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go tr.Run(ctx, nil)
+
+	require.NoError(t, tr.SetTarget(float64(cfg.FrameWidth),
+		float64(cfg.FrameHeight)/2))
+
+	assert.Eventually(t, func() bool {
+		return tr.State() == tracking.StateTracking && g.moving()
+	}, time.Second, 5*time.Millisecond)
+
+	tr.ClearTarget()
+
+	assert.Eventually(t, func() bool {
+		return tr.State() == tracking.StateLost && !g.moving()
+	}, time.Second, 5*time.Millisecond)
+}
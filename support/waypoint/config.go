@@ -0,0 +1,82 @@
+package waypoint
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brunoga/robomaster/support/pid"
+)
+
+// Pose is a 2D pose in the chassis odometry frame (see chassis.Position).
+type Pose struct {
+	X       float64 // Meters.
+	Y       float64 // Meters.
+	Heading float64 // Degrees.
+
+	// IgnoreHeading makes the robot only drive to the pose position, keeping
+	// whatever heading it has.
+	IgnoreHeading bool
+}
+
+// Config is the Follower configuration.
+type Config struct {
+	// Distance gains are used to compute the chassis speed from the distance
+	// to the current waypoint.
+	Distance pid.Gains
+	// Heading gains are used to compute the chassis rotation speed from the
+	// heading error for the current waypoint.
+	Heading pid.Gains
+
+	MaxSpeed   float64 // Maximum chassis speed (m/s).
+	MaxYawRate float64 // Maximum chassis rotation speed (degrees/s).
+
+	// A waypoint is reached when the robot is within these tolerances.
+	PositionTolerance float64 // Meters.
+	HeadingTolerance  float64 // Degrees.
+
+	// Period is how often the chassis speed is updated.
+	Period time.Duration
+}
+
+// DefaultConfig returns a configuration that works for most cases.
+func DefaultConfig() *Config {
+	return &Config{
+		Distance: pid.Gains{
+			Kp: 1.5,
+		},
+		Heading: pid.Gains{
+			Kp: 2.5,
+		},
+		MaxSpeed:          0.5,
+		MaxYawRate:        90,
+		PositionTolerance: 0.05,
+		HeadingTolerance:  3,
+		Period:            50 * time.Millisecond,
+	}
+}
+
+// Validate returns an error if the configuration is not valid.
+func (c *Config) Validate() error {
+	if c.MaxSpeed <= 0 || c.MaxSpeed > 3.5 {
+		return fmt.Errorf("invalid maximum speed: %f", c.MaxSpeed)
+	}
+
+	if c.MaxYawRate <= 0 || c.MaxYawRate > 360 {
+		return fmt.Errorf("invalid maximum yaw rate: %f", c.MaxYawRate)
+	}
+
+	if c.PositionTolerance <= 0 {
+		return fmt.Errorf("invalid position tolerance: %f",
+			c.PositionTolerance)
+	}
+
+	if c.HeadingTolerance <= 0 {
+		return fmt.Errorf("invalid heading tolerance: %f", c.HeadingTolerance)
+	}
+
+	if c.Period <= 0 {
+		return fmt.Errorf("invalid period: %s", c.Period)
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+// Package waypoint provides a closed-loop waypoint follower that drives the
+// robot chassis through a list of poses using odometry feedback.
+package waypoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/pid"
+)
+
+// ErrAborted is returned by Follow when Abort is called.
+var ErrAborted = errors.New("waypoint following aborted")
+
+// Chassis is the subset of the chassis module used by the Follower. It is
+// implemented by *chassis.Chassis.
+type Chassis interface {
+	Position() chassis.Position
+	SetSpeed(m chassis.Mode, x, y, z float64) error
+	StopMovement(m chassis.Mode) error
+}
+
+var _ Chassis = (*chassis.Chassis)(nil)
+
+// Follower drives the chassis through a list of poses. The chassis speed is
+// set in chassis.ModeAngularVelocity mode, the distance to the current pose
+// is used to compute the translation speed (toward the pose) and the heading
+// error is used to compute the rotation speed (both using PID controllers).
+type Follower struct {
+	l   *logger.Logger
+	c   Chassis
+	cfg Config
+
+	m        sync.Mutex
+	running  bool
+	paused   bool
+	pausedCh chan struct{} // Closed on resume.
+	abortCh  chan struct{}
+}
+
+// New returns a new Follower that drives the given chassis using the given
+// configuration. If cfg is nil, DefaultConfig is used.
+func New(l *logger.Logger, c Chassis, cfg *Config) (*Follower, error) {
+	if c == nil {
+		return nil, fmt.Errorf("chassis must not be nil")
+	}
+
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	l = l.WithGroup("waypoint_follower")
+
+	return &Follower{
+		l:   l,
+		c:   c,
+		cfg: *cfg,
+	}, nil
+}
+
+// Follow drives the chassis through the given poses, in order, and blocks
+// until the last one is reached. The given function (which might be nil) is
+// called with the index of each pose when it is reached. The chassis is
+// stopped when Follow returns. Returns ErrAborted if Abort is called and the
+// context error if it is done before the last pose is reached.
+func (f *Follower) Follow(ctx context.Context, poses []Pose,
+	onArrival func(index int, p Pose)) error {
+	if len(poses) == 0 {
+		return fmt.Errorf("no poses to follow")
+	}
+
+	f.m.Lock()
+	if f.running {
+		f.m.Unlock()
+		return fmt.Errorf("follower already running")
+	}
+
+	f.running = true
+	f.paused = false
+	abortCh := make(chan struct{})
+	f.abortCh = abortCh
+	f.m.Unlock()
+
+	defer func() {
+		f.m.Lock()
+		f.running = false
+		if f.paused {
+			f.paused = false
+			close(f.pausedCh)
+		}
+		f.m.Unlock()
+
+		if err := f.c.StopMovement(chassis.ModeAngularVelocity); err != nil {
+			f.l.Error("Error stopping chassis.", "error", err)
+		}
+	}()
+
+	ticker := time.NewTicker(f.cfg.Period)
+	defer ticker.Stop()
+
+	for i := 0; i < len(poses); {
+		distancePID, headingPID := f.newControllers()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-abortCh:
+				return ErrAborted
+			case <-ctx.Done():
+				return fmt.Errorf("error following waypoints: %w", ctx.Err())
+			}
+
+			if resumedCh := f.pausedChannel(); resumedCh != nil {
+				if err := f.c.StopMovement(
+					chassis.ModeAngularVelocity); err != nil {
+					f.l.Error("Error stopping chassis.", "error", err)
+				}
+
+				select {
+				case <-resumedCh:
+				case <-abortCh:
+					return ErrAborted
+				case <-ctx.Done():
+					return fmt.Errorf("error following waypoints: %w",
+						ctx.Err())
+				}
+
+				// Start fresh as the robot might have been moved while
+				// paused.
+				distancePID, headingPID = f.newControllers()
+				continue
+			}
+
+			pos := f.c.Position()
+			if pos.Time.IsZero() {
+				// No odometry yet.
+				continue
+			}
+
+			arrived, err := f.step(poses[i], pos, distancePID, headingPID)
+			if err != nil {
+				return err
+			}
+
+			if arrived {
+				break
+			}
+		}
+
+		f.l.Debug("Waypoint reached.", "index", i, "pose", poses[i])
+
+		if onArrival != nil {
+			onArrival(i, poses[i])
+		}
+
+		i++
+	}
+
+	return nil
+}
+
+// Pause stops the chassis and pauses following waypoints until Resume is
+// called.
+func (f *Follower) Pause() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if !f.running {
+		return fmt.Errorf("follower not running")
+	}
+
+	if f.paused {
+		return nil
+	}
+
+	f.paused = true
+	f.pausedCh = make(chan struct{})
+
+	return nil
+}
+
+// Resume resumes following waypoints after a Pause.
+func (f *Follower) Resume() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if !f.running {
+		return fmt.Errorf("follower not running")
+	}
+
+	if !f.paused {
+		return nil
+	}
+
+	f.paused = false
+	close(f.pausedCh)
+
+	return nil
+}
+
+// Paused returns true if following waypoints is paused.
+func (f *Follower) Paused() bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.paused
+}
+
+// Abort stops the chassis and makes the running Follow call return
+// ErrAborted.
+func (f *Follower) Abort() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if !f.running {
+		return fmt.Errorf("follower not running")
+	}
+
+	select {
+	case <-f.abortCh:
+	default:
+		close(f.abortCh)
+	}
+
+	return nil
+}
+
+// step sets the chassis speed to move toward the given pose. Returns true if
+// the pose was reached.
+func (f *Follower) step(p Pose, pos chassis.Position, distancePID,
+	headingPID pid.Controller) (bool, error) {
+	dx, dy := p.X-pos.X, p.Y-pos.Y
+	distance := math.Hypot(dx, dy)
+
+	var headingError float64
+	if !p.IgnoreHeading {
+		headingError = normalizeAngle(p.Heading - pos.Heading)
+	}
+
+	if distance <= f.cfg.PositionTolerance &&
+		math.Abs(headingError) <= f.cfg.HeadingTolerance {
+		return true, nil
+	}
+
+	var x, y float64
+	if distance > f.cfg.PositionTolerance {
+		speed := distancePID.Output(distance)
+
+		// Direction to the pose relative to the chassis heading.
+		rad := pos.Heading * math.Pi / 180
+		x = (dx*math.Cos(rad) + dy*math.Sin(rad)) / distance * speed
+		y = (-dx*math.Sin(rad) + dy*math.Cos(rad)) / distance * speed
+	}
+
+	var z float64
+	if math.Abs(headingError) > f.cfg.HeadingTolerance {
+		z = headingPID.Output(headingError)
+	}
+
+	return false, f.c.SetSpeed(chassis.ModeAngularVelocity, x, y, z)
+}
+
+func (f *Follower) newControllers() (pid.Controller, pid.Controller) {
+	d, h := f.cfg.Distance, f.cfg.Heading
+
+	return pid.NewPIDController(d.Kp, d.Ki, d.Kd, 0, f.cfg.MaxSpeed),
+		pid.NewPIDController(h.Kp, h.Ki, h.Kd, -f.cfg.MaxYawRate,
+			f.cfg.MaxYawRate)
+}
+
+// pausedChannel returns a channel that is closed on resume if following
+// waypoints is paused and nil otherwise.
+func (f *Follower) pausedChannel() chan struct{} {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if !f.paused {
+		return nil
+	}
+
+	return f.pausedCh
+}
+
+// normalizeAngle normalizes the given angle (in degrees) to [-180, 180).
+func normalizeAngle(a float64) float64 {
+	a = math.Mod(a+180, 360)
+	if a < 0 {
+		a += 360
+	}
+
+	return a - 180
+}
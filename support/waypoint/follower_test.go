@@ -0,0 +1,181 @@
+package waypoint_test
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+	"github.com/brunoga/robomaster/support/waypoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChassis is a chassis that moves with the last speeds set.
+type fakeChassis struct {
+	m          sync.Mutex
+	pos        chassis.Position
+	x, y, z    float64
+	lastUpdate time.Time
+}
+
+func newFakeChassis() *fakeChassis {
+	now := time.Now()
+
+	return &fakeChassis{
+		pos:        chassis.Position{Time: now},
+		lastUpdate: now,
+	}
+}
+
+func (c *fakeChassis) Position() chassis.Position {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.update()
+
+	return c.pos
+}
+
+func (c *fakeChassis) SetSpeed(m chassis.Mode, x, y, z float64) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.update()
+	c.x, c.y, c.z = x, y, z
+
+	return nil
+}
+
+func (c *fakeChassis) StopMovement(m chassis.Mode) error {
+	return c.SetSpeed(m, 0, 0, 0)
+}
+
+func (c *fakeChassis) moving() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.x != 0 || c.y != 0 || c.z != 0
+}
+
+// update integrates the speeds since the last update. Must be called with m
+// held.
+func (c *fakeChassis) update() {
+	now := time.Now()
+	dt := now.Sub(c.lastUpdate).Seconds()
+	c.lastUpdate = now
+
+	rad := c.pos.Heading * math.Pi / 180
+	c.pos.X += (c.x*math.Cos(rad) - c.y*math.Sin(rad)) * dt
+	c.pos.Y += (c.x*math.Sin(rad) + c.y*math.Cos(rad)) * dt
+	c.pos.Heading += c.z * dt
+	c.pos.Time = now
+}
+
+func newFollower(t *testing.T, c waypoint.Chassis) *waypoint.Follower {
+	cfg := waypoint.DefaultConfig()
+	cfg.Distance.Kp = 5
+	cfg.Heading.Kp = 5
+	cfg.MaxSpeed = 2
+	cfg.MaxYawRate = 180
+	cfg.Period = 10 * time.Millisecond
+
+	f, err := waypoint.New(nil, c, cfg)
+	require.NoError(t, err)
+
+	return f
+}
+
+func TestConfig(t *testing.T) {
+	assert.NoError(t, waypoint.DefaultConfig().Validate())
+
+	cfg := waypoint.DefaultConfig()
+	cfg.MaxSpeed = 0
+	assert.Error(t, cfg.Validate())
+
+	cfg = waypoint.DefaultConfig()
+	cfg.Period = 0
+	_, err := waypoint.New(nil, newFakeChassis(), cfg)
+	assert.Error(t, err)
+}
+
+func TestFollow(t *testing.T) {
+	c := newFakeChassis()
+	f := newFollower(t, c)
+
+	poses := []waypoint.Pose{
+		{X: 1, Y: 0, Heading: 0},
+		{X: 1, Y: 1, Heading: 90},
+		{X: 0, Y: 0, IgnoreHeading: true},
+	}
+
+	var arrivals []int
+	err := f.Follow(context.Background(), poses,
+		func(index int, p waypoint.Pose) {
+			assert.Equal(t, poses[index], p)
+
+			pos := c.Position()
+			assert.InDelta(t, p.X, pos.X, 0.05)
+			assert.InDelta(t, p.Y, pos.Y, 0.05)
+			if !p.IgnoreHeading {
+				assert.InDelta(t, p.Heading, pos.Heading, 3)
+			}
+
+			arrivals = append(arrivals, index)
+		})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{0, 1, 2}, arrivals)
+	assert.False(t, c.moving())
+
+	assert.Error(t, f.Follow(context.Background(), nil, nil))
+}
+
+func TestPauseResumeAbort(t *testing.T) {
+	c := newFakeChassis()
+	f := newFollower(t, c)
+
+	assert.Error(t, f.Pause())
+	assert.Error(t, f.Abort())
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- f.Follow(context.Background(), []waypoint.Pose{
+			{X: 100, Y: 0},
+		}, nil)
+	}()
+
+	require.Eventually(t, c.moving, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, f.Pause())
+	assert.True(t, f.Paused())
+	require.Eventually(t, func() bool { return !c.moving() }, time.Second,
+		10*time.Millisecond)
+
+	x := c.Position().X
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, x, c.Position().X)
+
+	require.NoError(t, f.Resume())
+	assert.False(t, f.Paused())
+	require.Eventually(t, c.moving, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, f.Abort())
+	assert.ErrorIs(t, <-errCh, waypoint.ErrAborted)
+	assert.False(t, c.moving())
+}
+
+func TestFollowCanceled(t *testing.T) {
+	c := newFakeChassis()
+	f := newFollower(t, c)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		100*time.Millisecond)
+	defer cancel()
+
+	err := f.Follow(ctx, []waypoint.Pose{{X: 100, Y: 0}}, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, c.moving())
+}
@@ -0,0 +1,60 @@
+package chassis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+)
+
+func TestLimits(t *testing.T) {
+	original, err := chassisModule.Limits()
+	if err != nil {
+		t.Fatalf("Failed to get limits: %v", err)
+	}
+	defer func() {
+		if err := chassisModule.SetLimits(original); err != nil {
+			t.Fatalf("Failed to restore limits: %v", err)
+		}
+	}()
+
+	// Slow and gentle.
+	limits := chassis.Limits{
+		MaxSpeedForward:  0.2,
+		MaxSpeedBackward: 0.1,
+		MaxSpeedLateral:  0.1,
+		SlopeX:           1,
+		SlopeY:           1,
+		SlopeBreakX:      2,
+		SlopeBreakY:      2,
+	}
+
+	if err := chassisModule.SetLimits(limits); err != nil {
+		t.Fatalf("Failed to set limits: %v", err)
+	}
+
+	got, err := chassisModule.Limits()
+	if err != nil {
+		t.Fatalf("Failed to get limits: %v", err)
+	}
+
+	if got != limits {
+		t.Fatalf("Unexpected limits: %+v != %+v", got, limits)
+	}
+
+	start := chassisModule.Position()
+
+	setSpeed(1*time.Second, 1.0, 0.0, 0.0)
+
+	time.Sleep(100 * time.Millisecond)
+
+	if moved := chassisModule.Position().X - start.X; moved > 0.3 {
+		t.Fatalf("Chassis moved faster than the limit: %f meters", moved)
+	}
+
+	invalid := limits
+	invalid.MaxSpeedForward = 4
+	if err := chassisModule.SetLimits(invalid); err == nil {
+		t.Fatal("Expected error setting invalid limits")
+	}
+}
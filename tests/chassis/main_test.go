@@ -4,12 +4,11 @@ import (
 	"os"
 	"testing"
 
-	robomaster "github.com/brunoga/robomaster"
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/chassis"
 	"github.com/brunoga/robomaster/module/controller"
-	"github.com/brunoga/robomaster/support"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/tests/internal"
 )
 
 var chassisModule *chassis.Chassis
@@ -17,7 +16,7 @@ var chassisModule *chassis.Chassis
 func TestMain(m *testing.M) {
 	l := logger.New(logger.LevelTrace, "unity_bridge", "wrapper")
 
-	c, err := robomaster.NewWithModules(l, support.AnyAppID,
+	c, err := internal.NewClient(l,
 		module.TypeConnection|module.TypeRobot|module.TypeController|module.TypeChassis)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,93 @@
+package chassis
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+)
+
+func TestRoute(t *testing.T) {
+	recordSettings, err := chassisModule.RecordSettings()
+	if err != nil {
+		t.Fatalf("Failed to get record settings: %v", err)
+	}
+
+	if err := recordSettings.Validate(); err != nil {
+		t.Fatalf("Invalid record settings: %v", err)
+	}
+
+	if err := chassisModule.StartRecording(); err != nil {
+		t.Fatalf("Failed to start recording: %v", err)
+	}
+
+	recording, err := chassisModule.IsRecording()
+	if err != nil {
+		t.Fatalf("Failed to get recording state: %v", err)
+	}
+
+	if !recording {
+		t.Fatal("Not recording")
+	}
+
+	start := chassisModule.Position()
+
+	// Rotate back so the heading is kept for other tests.
+	setSpeed(500*time.Millisecond, 0.0, 0.0, 90.0)
+	setSpeed(500*time.Millisecond, 0.4, 0.0, 0.0)
+	setSpeed(500*time.Millisecond, 0.0, 0.0, -90.0)
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := chassisModule.StopRecording(); err != nil {
+		t.Fatalf("Failed to stop recording: %v", err)
+	}
+
+	recorded := chassisModule.Position()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var progress []chassis.PlaybackProgress
+	err = chassisModule.PlayRouteContext(ctx, chassis.PlaybackSettings{
+		Slot:  recordSettings.Slot,
+		Speed: 2,
+	}, func(p chassis.PlaybackProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatalf("Failed to play route: %v", err)
+	}
+
+	if len(progress) < 2 {
+		t.Fatalf("Not enough progress updates: %d", len(progress))
+	}
+
+	last := progress[len(progress)-1]
+	if last.State != chassis.PlaybackStateDone || last.Percent != 100 {
+		t.Fatalf("Unexpected final progress: %+v", last)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	// The route is replayed from the pose where the recording ended, so the
+	// chassis moves the same distance again.
+	moved := math.Hypot(recorded.X-start.X, recorded.Y-start.Y)
+	end := chassisModule.Position()
+	replayed := math.Hypot(end.X-recorded.X, end.Y-recorded.Y)
+	if math.Abs(moved-replayed) > 0.05 {
+		t.Fatalf("Unexpected replayed distance: %f != %f", replayed, moved)
+	}
+}
+
+func TestRouteEmptySlot(t *testing.T) {
+	err := chassisModule.PlayRoute(chassis.PlaybackSettings{
+		Slot:  9,
+		Speed: 1,
+	}, nil)
+	if err == nil {
+		t.Fatal("Expected error playing empty route")
+	}
+}
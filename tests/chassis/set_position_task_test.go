@@ -0,0 +1,89 @@
+package chassis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+	"github.com/brunoga/robomaster/module/robot"
+	"github.com/brunoga/robomaster/unitybridge/unity/task"
+)
+
+func TestSetPositionTask(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tsk, err := chassisModule.SetPositionTask(ctx,
+		chassis.ModeAngularVelocity, 0.5, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to start position task: %v", err)
+	}
+
+	if tsk.Type() != task.TypeChassisPosition {
+		t.Fatalf("Unexpected task type: %d", tsk.Type())
+	}
+
+	if err := tsk.Wait(ctx); err != nil {
+		t.Fatalf("Position task failed: %v", err)
+	}
+
+	if tsk.Status() != task.StatusSuccess {
+		t.Fatalf("Unexpected task status: %d", tsk.Status())
+	}
+
+	if tsk.Progress() < 99 {
+		t.Fatalf("Unexpected task progress: %f", tsk.Progress())
+	}
+}
+
+func TestSetPositionTaskCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tsk, err := chassisModule.SetPositionTask(ctx,
+		chassis.ModeAngularVelocity, 2, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to start position task: %v", err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	if err := tsk.Cancel(); err != nil {
+		t.Fatalf("Failed to cancel position task: %v", err)
+	}
+
+	if err := tsk.Wait(ctx); !errors.Is(err, robot.ErrTaskCanceled) {
+		t.Fatalf("Unexpected error waiting for canceled task: %v", err)
+	}
+}
+
+func TestSetPositionTaskSuperseded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	first, err := chassisModule.SetPositionTask(ctx,
+		chassis.ModeAngularVelocity, 2, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to start position task: %v", err)
+	}
+
+	second, err := chassisModule.SetPositionTask(ctx,
+		chassis.ModeAngularVelocity, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to start position task: %v", err)
+	}
+
+	if first.ID() == second.ID() {
+		t.Fatalf("Tasks have the same ID: %d", first.ID())
+	}
+
+	if err := first.Wait(ctx); !errors.Is(err, robot.ErrTaskSuperseded) {
+		t.Fatalf("Unexpected error waiting for superseded task: %v", err)
+	}
+
+	if err := second.Wait(ctx); err != nil {
+		t.Fatalf("Position task failed: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package chassis
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+)
+
+func TestSetWheelSpeed(t *testing.T) {
+	var updates atomic.Int32
+
+	token, err := chassisModule.AddMotorListener(func(chassis.MotorInfo) {
+		updates.Add(1)
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to add motor listener: %v", err)
+	}
+	defer chassisModule.RemoveMotorListener(token)
+
+	start := chassisModule.Position()
+
+	// Strafe right.
+	err = chassisModule.SetWheelSpeed(-60, 60, -60, 60)
+	if err != nil {
+		t.Fatalf("Failed to set wheel speed: %v", err)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	motors := chassisModule.Motors()
+
+	err = chassisModule.SetWheelSpeed(0, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to stop wheels: %v", err)
+	}
+
+	if updates.Load() == 0 {
+		t.Fatal("No motor updates received")
+	}
+
+	if motors[chassis.WheelFrontRight].Speed >= 0 ||
+		motors[chassis.WheelFrontLeft].Speed <= 0 {
+		t.Fatalf("Unexpected motor speeds: %+v", motors)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if chassisModule.Position().Y <= start.Y {
+		t.Fatalf("Chassis did not move right: %f <= %f",
+			chassisModule.Position().Y, start.Y)
+	}
+}
+
+func TestESCFirmwareVersions(t *testing.T) {
+	versions, err := chassisModule.ESCFirmwareVersions()
+	if err != nil {
+		t.Fatalf("Failed to get ESC firmware versions: %v", err)
+	}
+
+	for w, v := range versions {
+		if v == "" {
+			t.Fatalf("Empty firmware version for %s ESC", chassis.Wheel(w))
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package chassis
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+)
+
+func TestTelemetry(t *testing.T) {
+	var updates atomic.Int32
+
+	token, err := chassisModule.AddTelemetryListener(
+		func(chassis.Telemetry) {
+			updates.Add(1)
+		}, nil)
+	if err != nil {
+		t.Fatalf("Failed to add telemetry listener: %v", err)
+	}
+	defer chassisModule.RemoveTelemetryListener(token)
+
+	start := chassisModule.Position()
+
+	setSpeed(1*time.Second, 0.2, 0.0, 0.0)
+
+	if updates.Load() == 0 {
+		t.Fatal("No telemetry updates received")
+	}
+
+	telemetry := chassisModule.Telemetry()
+	if telemetry.Velocity.Time.IsZero() {
+		t.Fatal("No velocity received")
+	}
+
+	if !telemetry.Position.Time.After(start.Time) {
+		t.Fatal("Position was not updated")
+	}
+
+	if telemetry.Position.X <= start.X {
+		t.Fatalf("Chassis did not move forward: %f <= %f",
+			telemetry.Position.X, start.X)
+	}
+}
@@ -5,17 +5,16 @@ import (
 	"os"
 	"testing"
 
-	robomaster "github.com/brunoga/robomaster"
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/controller"
-	"github.com/brunoga/robomaster/support"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/tests/internal"
 )
 
 var controllerModule *controller.Controller
 
 func TestMain(m *testing.M) {
-	c, err := robomaster.NewWithModules(logger.New(slog.LevelDebug), support.AnyAppID,
+	c, err := internal.NewClient(logger.New(slog.LevelDebug),
 		module.TypeConnection|module.TypeRobot|module.TypeController)
 	if err != nil {
 		panic(err)
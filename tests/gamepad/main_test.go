@@ -4,19 +4,18 @@ import (
 	"os"
 	"testing"
 
-	"github.com/brunoga/robomaster"
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/gamepad"
 	"github.com/brunoga/robomaster/module/robot"
-	"github.com/brunoga/robomaster/support"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/tests/internal"
 )
 
 var gamepadModule *gamepad.GamePad
 var robotModule *robot.Robot
 
 func TestMain(m *testing.M) {
-	c, err := robomaster.NewWithModules(logger.New(logger.LevelTrace), support.AnyAppID,
+	c, err := internal.NewClient(logger.New(logger.LevelTrace),
 		module.TypeConnection|module.TypeRobot|module.TypeGamePad)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,71 @@
+package gimbal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/gimbal"
+)
+
+func TestAttitude(t *testing.T) {
+	err := gimbalModule.ResetPosition()
+	if err != nil {
+		t.Fatalf("ResetPosition() failed, got: %v", err)
+	}
+	defer func() {
+		err := gimbalModule.ResetPosition()
+		if err != nil {
+			panic(err)
+		}
+	}()
+
+	updates := make(chan gimbal.Attitude, 1)
+	tk, err := gimbalModule.AddAttitudeListener(func(a gimbal.Attitude) {
+		select {
+		case updates <- a:
+		default:
+		}
+	}, nil)
+	if err != nil {
+		t.Fatalf("AddAttitudeListener() failed, got: %v", err)
+	}
+
+	select {
+	case <-updates:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("No attitude update received")
+	}
+
+	err = gimbalModule.RemoveAttitudeListener(tk)
+	if err != nil {
+		t.Fatalf("RemoveAttitudeListener() failed, got: %v", err)
+	}
+
+	if gimbalModule.Attitude().Time.IsZero() {
+		t.Fatalf("Attitude() has no data")
+	}
+
+	err = gimbalModule.SetRotationSpeed(0, 30)
+	if err != nil {
+		t.Fatalf("SetRotationSpeed() failed, got: %v", err)
+	}
+	defer gimbalModule.StopRotation()
+
+	a, err := gimbalModule.WaitForAttitude(func(a gimbal.Attitude) bool {
+		return a.Yaw >= 20
+	}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForAttitude() failed, got: %v", err)
+	}
+
+	if a.YawSpeed <= 0 {
+		t.Fatalf("Unexpected yaw speed while rotating: %v", a.YawSpeed)
+	}
+
+	_, err = gimbalModule.WaitForAttitude(func(a gimbal.Attitude) bool {
+		return false
+	}, 100*time.Millisecond)
+	if err == nil {
+		t.Fatalf("WaitForAttitude() did not time out")
+	}
+}
@@ -0,0 +1,103 @@
+package gimbal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/gimbal"
+)
+
+func TestCalibrate(t *testing.T) {
+	var reports []gimbal.CalibrationProgress
+	r, err := gimbalModule.Calibrate(gimbal.CalibrationTypeAuto,
+		func(p gimbal.CalibrationProgress) {
+			reports = append(reports, p)
+		})
+	if err != nil {
+		t.Fatalf("Calibrate() failed, got: %v", err)
+	}
+
+	if r.Type != gimbal.CalibrationTypeAuto ||
+		r.Status != gimbal.CalibrationStatusSucceeded || r.Duration <= 0 {
+		t.Fatalf("Unexpected result: %+v", r)
+	}
+
+	if len(reports) < 2 {
+		t.Fatalf("Not enough progress reports: %+v", reports)
+	}
+
+	last := reports[len(reports)-1]
+	if last.Status != gimbal.CalibrationStatusSucceeded || last.Percent != 100 {
+		t.Fatalf("Unexpected last progress report: %+v", last)
+	}
+}
+
+func TestStartCalibration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := gimbalModule.StartCalibrationContext(ctx,
+		gimbal.CalibrationTypeManual)
+	if err != nil {
+		t.Fatalf("StartCalibrationContext() failed, got: %v", err)
+	}
+
+	var percent uint8
+	for p := range c.Progress() {
+		if p.Percent < percent {
+			t.Fatalf("Progress went backwards: %d -> %d", percent, p.Percent)
+		}
+
+		percent = p.Percent
+	}
+
+	r, err := c.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Wait() failed, got: %v", err)
+	}
+
+	if r.Type != gimbal.CalibrationTypeManual ||
+		r.Status != gimbal.CalibrationStatusSucceeded {
+		t.Fatalf("Unexpected result: %+v", r)
+	}
+
+	_, err = gimbalModule.StartCalibrationContext(ctx,
+		gimbal.CalibrationType(10))
+	if err == nil {
+		t.Fatalf("StartCalibrationContext() accepted an invalid calibration " +
+			"type")
+	}
+}
+
+func TestCancelCalibration(t *testing.T) {
+	c, err := gimbalModule.StartCalibration(gimbal.CalibrationTypeManual)
+	if err != nil {
+		t.Fatalf("StartCalibration() failed, got: %v", err)
+	}
+
+	// Only one calibration can run at a time.
+	_, err = gimbalModule.StartCalibration(gimbal.CalibrationTypeAuto)
+	if err == nil {
+		c.Cancel()
+		t.Fatalf("StartCalibration() succeeded while calibrating")
+	}
+
+	c.Cancel()
+
+	select {
+	case <-c.Done():
+	default:
+		t.Fatalf("Calibration not done after Cancel()")
+	}
+
+	_, err = c.Wait(context.Background())
+	if !errors.Is(err, gimbal.ErrCalibrationCanceled) {
+		t.Fatalf("Wait() returned unexpected error: %v", err)
+	}
+
+	// Wait for the simulated calibration to end so other tests are not
+	// affected.
+	time.Sleep(2 * time.Second)
+}
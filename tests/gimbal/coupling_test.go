@@ -0,0 +1,91 @@
+package gimbal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/gimbal"
+)
+
+func TestSetCoupling(t *testing.T) {
+	defer func() {
+		if err := gimbalModule.SetCoupling(gimbal.CouplingFree,
+			chassisModule); err != nil {
+			t.Fatalf("Error restoring coupling: %s", err)
+		}
+	}()
+
+	for _, c := range []gimbal.Coupling{
+		gimbal.CouplingChassisFollowsGimbal,
+		gimbal.CouplingGimbalFollowsChassis,
+		gimbal.CouplingFree,
+	} {
+		if err := gimbalModule.SetCoupling(c, chassisModule); err != nil {
+			t.Fatalf("Error setting coupling %s: %s", c, err)
+		}
+
+		got, err := gimbalModule.Coupling()
+		if err != nil {
+			t.Fatalf("Error getting coupling: %s", err)
+		}
+
+		if got != c {
+			t.Fatalf("Unexpected coupling: got %s, want %s", got, c)
+		}
+
+		if gimbalModule.ControlMode() != c.ControlMode() {
+			t.Fatalf("Unexpected control mode for coupling %s: %s", c,
+				gimbalModule.ControlMode())
+		}
+	}
+
+	if err := gimbalModule.SetCoupling(gimbal.Coupling(10),
+		chassisModule); err == nil {
+		t.Fatalf("SetCoupling() accepted an invalid coupling")
+	}
+}
+
+func TestYawRotationWithGimbalFollowingChassis(t *testing.T) {
+	err := gimbalModule.SetCoupling(gimbal.CouplingGimbalFollowsChassis,
+		chassisModule)
+	if err != nil {
+		t.Fatalf("Error setting coupling: %s", err)
+	}
+	defer func() {
+		if err := gimbalModule.SetCoupling(gimbal.CouplingFree,
+			chassisModule); err != nil {
+			t.Fatalf("Error restoring coupling: %s", err)
+		}
+	}()
+
+	err = gimbalModule.SetRelativeAngleRotation(30, gimbal.AxisYaw,
+		1*time.Second)
+	if err == nil {
+		t.Fatalf("Yaw rotation accepted while the gimbal follows the chassis")
+	}
+}
+
+func TestYawRotationWithoutCoupling(t *testing.T) {
+	// Setting the work mode directly invalidates the coupling.
+	if err := gimbalModule.SetWorkMode(gimbal.WorkModeFree); err != nil {
+		t.Fatalf("Error setting work mode: %s", err)
+	}
+	defer func() {
+		if err := gimbalModule.SetCoupling(gimbal.CouplingFree,
+			chassisModule); err != nil {
+			t.Fatalf("Error restoring coupling: %s", err)
+		}
+	}()
+
+	err := gimbalModule.SetRelativeAngleRotation(30, gimbal.AxisYaw,
+		1*time.Second)
+	if err == nil {
+		t.Fatalf("Yaw rotation accepted without a coupling")
+	}
+
+	err = gimbalModule.Move(gimbal.AxisYaw, 30, gimbal.FrameRelative,
+		gimbal.MoveOptions{Duration: time.Second})
+	if err == nil {
+		t.Fatalf("Relative yaw move accepted without a coupling")
+	}
+}
@@ -4,14 +4,13 @@ import (
 	"os"
 	"testing"
 
-	"github.com/brunoga/robomaster"
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/chassis"
 	"github.com/brunoga/robomaster/module/controller"
 	"github.com/brunoga/robomaster/module/gimbal"
 	"github.com/brunoga/robomaster/module/robot"
-	"github.com/brunoga/robomaster/support"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/tests/internal"
 )
 
 var gimbalModule *gimbal.Gimbal
@@ -20,7 +19,7 @@ var robotModule *robot.Robot
 var controllerModule *controller.Controller
 
 func TestMain(m *testing.M) {
-	c, err := robomaster.NewWithModules(logger.New(logger.LevelTrace), support.AnyAppID,
+	c, err := internal.NewClient(logger.New(logger.LevelTrace),
 		module.TypeConnection|module.TypeRobot|module.TypeController|module.TypeGimbal|module.TypeChassis)
 	if err != nil {
 		panic(err)
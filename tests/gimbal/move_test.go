@@ -0,0 +1,110 @@
+package gimbal
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/gimbal"
+	"github.com/brunoga/robomaster/unitybridge/unity/task"
+)
+
+func TestMove(t *testing.T) {
+	err := gimbalModule.ResetPosition()
+	if err != nil {
+		t.Fatalf("ResetPosition() failed, got: %v", err)
+	}
+	defer func() {
+		err := gimbalModule.ResetPosition()
+		if err != nil {
+			panic(err)
+		}
+	}()
+
+	err = gimbalModule.Move(gimbal.AxisPitch, 12.5, gimbal.FrameAbsolute,
+		gimbal.MoveOptions{Duration: 500 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Move() failed, got: %v", err)
+	}
+
+	// Give the attitude a chance to catch up with the final position.
+	time.Sleep(200 * time.Millisecond)
+
+	if pitch := gimbalModule.Attitude().Pitch; math.Abs(pitch-12.5) > 1 {
+		t.Fatalf("Unexpected pitch after absolute move: %f", pitch)
+	}
+
+	// Relative yaw moves require a coupling.
+	err = gimbalModule.SetCoupling(gimbal.CouplingFree, chassisModule)
+	if err != nil {
+		t.Fatalf("SetCoupling() failed, got: %v", err)
+	}
+
+	err = gimbalModule.Move(gimbal.AxisYaw, 45, gimbal.FrameRelative,
+		gimbal.MoveOptions{Speed: 90})
+	if err != nil {
+		t.Fatalf("Move() failed, got: %v", err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	if yaw := gimbalModule.Attitude().Yaw; math.Abs(yaw-45) > 1 {
+		t.Fatalf("Unexpected yaw after relative move: %f", yaw)
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	moveTask, err := gimbalModule.MoveTask(ctx, gimbal.AxisPitch, -10,
+		gimbal.FrameRelative, gimbal.MoveOptions{Speed: 30})
+	if err != nil {
+		t.Fatalf("MoveTask() failed, got: %v", err)
+	}
+
+	if moveTask.Type() != task.TypeGimbalAngle {
+		t.Fatalf("Unexpected task type: %d", moveTask.Type())
+	}
+
+	if err := moveTask.Wait(ctx); err != nil {
+		t.Fatalf("Move task failed: %v", err)
+	}
+
+	if err := gimbalModule.ResetPosition(); err != nil {
+		t.Fatalf("ResetPosition() failed, got: %v", err)
+	}
+}
+
+func TestMoveLimits(t *testing.T) {
+	for _, tc := range []struct {
+		axis  gimbal.Axis
+		angle float64
+		frame gimbal.Frame
+		opts  gimbal.MoveOptions
+	}{
+		{gimbal.AxisPitch, 40, gimbal.FrameAbsolute,
+			gimbal.MoveOptions{Duration: time.Second}},
+		{gimbal.AxisYaw, -260, gimbal.FrameAbsolute,
+			gimbal.MoveOptions{Duration: time.Second}},
+		{gimbal.AxisPitch, 61, gimbal.FrameRelative,
+			gimbal.MoveOptions{Duration: time.Second}},
+		{gimbal.AxisPitch, 10, gimbal.FrameRelative,
+			gimbal.MoveOptions{}},
+		{gimbal.AxisPitch, 10, gimbal.FrameRelative,
+			gimbal.MoveOptions{Speed: 10, Duration: time.Second}},
+		{gimbal.AxisPitch, 10, gimbal.FrameRelative,
+			gimbal.MoveOptions{Speed: 1000}},
+		{gimbal.AxisPitch, 10, gimbal.FrameRelative,
+			gimbal.MoveOptions{Duration: time.Minute}},
+		{gimbal.AxisYaw, 200, gimbal.FrameRelative,
+			gimbal.MoveOptions{Speed: 10}},
+	} {
+		err := gimbalModule.Move(tc.axis, tc.angle, tc.frame, tc.opts)
+		if err == nil {
+			t.Fatalf("Move(%s, %f, %s, %+v) did not fail", tc.axis,
+				tc.angle, tc.frame, tc.opts)
+		}
+	}
+}
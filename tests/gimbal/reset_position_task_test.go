@@ -0,0 +1,69 @@
+package gimbal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/gimbal"
+	"github.com/brunoga/robomaster/unitybridge/unity/task"
+)
+
+func TestResetPositionTask(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	angleTask, err := gimbalModule.SetAbsoluteAngleRotationTask(ctx, 20,
+		gimbal.AxisPitch, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to start angle task: %v", err)
+	}
+
+	if err := angleTask.Wait(ctx); err != nil {
+		t.Fatalf("Angle task failed: %v", err)
+	}
+
+	resetTask, err := gimbalModule.ResetPositionTask(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start reset task: %v", err)
+	}
+
+	if resetTask.Type() != task.TypeGimbalReset {
+		t.Fatalf("Unexpected task type: %d", resetTask.Type())
+	}
+
+	if err := resetTask.Wait(ctx); err != nil {
+		t.Fatalf("Reset task failed: %v", err)
+	}
+
+	if resetTask.Progress() < 99 {
+		t.Fatalf("Unexpected task progress: %f", resetTask.Progress())
+	}
+}
+
+func TestResetPositionTaskConcurrent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	first, err := gimbalModule.ResetPositionTask(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start reset task: %v", err)
+	}
+
+	if _, err := gimbalModule.ResetPositionTask(ctx); err == nil {
+		t.Fatalf("Expected error starting a second reset task")
+	}
+
+	if err := first.Wait(ctx); err != nil {
+		t.Fatalf("Reset task failed: %v", err)
+	}
+
+	second, err := gimbalModule.ResetPositionTask(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start reset task after the first one: %v", err)
+	}
+
+	if err := second.Wait(ctx); err != nil {
+		t.Fatalf("Reset task failed: %v", err)
+	}
+}
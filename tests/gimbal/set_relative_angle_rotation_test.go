@@ -8,7 +8,13 @@ import (
 )
 
 func TestSetRelativeAngleRotation(t *testing.T) {
-	err := gimbalModule.SetRelativeAngleRotation(90, gimbal.AxisYaw, 1*time.Second)
+	// Relative yaw rotations require a coupling.
+	err := gimbalModule.SetCoupling(gimbal.CouplingFree, chassisModule)
+	if err != nil {
+		t.Fatalf("Error setting coupling: %s", err)
+	}
+
+	err = gimbalModule.SetRelativeAngleRotation(90, gimbal.AxisYaw, 1*time.Second)
 	if err != nil {
 		t.Errorf("Error setting relative position: %s", err)
 	}
@@ -3,6 +3,8 @@ package gimbal
 import (
 	"fmt"
 	"testing"
+
+	"github.com/brunoga/robomaster/module/gimbal"
 )
 
 func TestSetWorkMode(t *testing.T) {
@@ -10,15 +12,15 @@ func TestSetWorkMode(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error getting work mode: %s", err)
 	}
-	defer func(wm uint64) {
+	defer func(wm gimbal.WorkMode) {
 		if err := gimbalModule.SetWorkMode(wm); err != nil {
 			t.Fatalf("Error setting work mode: %s", err)
 		}
 	}(wm)
 
-	fmt.Printf("Work Mode: %d\n", wm)
+	fmt.Printf("Work Mode: %s\n", wm)
 
-	wm2 := uint64(0)
+	wm2 := gimbal.WorkModeFree
 
 	if err := gimbalModule.SetWorkMode(wm2); err != nil {
 		t.Fatalf("Error setting work mode: %s", err)
@@ -30,6 +32,6 @@ func TestSetWorkMode(t *testing.T) {
 	}
 
 	if wm != wm2 {
-		t.Fatalf("Invalid work mode: %d", wm2)
+		t.Fatalf("Invalid work mode: %s", wm2)
 	}
 }
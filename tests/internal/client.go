@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"os"
+
+	"github.com/brunoga/robomaster"
+	"github.com/brunoga/robomaster/module"
+	"github.com/brunoga/robomaster/module/connection"
+	"github.com/brunoga/robomaster/support"
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/simulator"
+)
+
+// SimulatorEnv is the environment variable that, when set to a non-empty
+// value, makes tests run against the simulated robot instead of an actual
+// one.
+const SimulatorEnv = "ROBOMASTER_SIMULATOR"
+
+// NewClient returns a new client with the given modules enabled. The client
+// connects to the first robot detected or, if the SimulatorEnv environment
+// variable is set, to a simulated robot.
+func NewClient(l *logger.Logger, modules module.Type) (*robomaster.Client,
+	error) {
+	if os.Getenv(SimulatorEnv) == "" {
+		return robomaster.NewWithModules(l, support.AnyAppID, modules)
+	}
+
+	return robomaster.NewWithWrapper(l, 0, connection.TypeWiFiDirect, modules,
+		simulator.NewUnityBridgeWrapper(l))
+}
@@ -2,6 +2,8 @@ package robot
 
 import (
 	"testing"
+
+	"github.com/brunoga/robomaster/module/robot"
 )
 
 func TestChassisSpeedLevel(t *testing.T) {
@@ -16,30 +18,22 @@ func TestChassisSpeedLevel(t *testing.T) {
 		}
 	}()
 
-	err = robotModule.SetChassisSpeedLevel(1)
-	if err != nil {
-		t.Fatalf("Failed to set speed level to 1: %v", err)
-	}
-
-	speedLevel, err := robotModule.ChassisSpeedLevel()
-	if err != nil {
-		t.Fatalf("Failed to get speed level: %v", err)
-	}
-	if speedLevel != 1 {
-		t.Fatalf("Speed level is not 0: %v", speedLevel)
-	}
-
-	err = robotModule.SetChassisSpeedLevel(4)
-	if err != nil {
-		t.Fatalf("Failed to set speed level to 4: %v", err)
-	}
+	for _, level := range []robot.ChassisSpeedLevel{
+		robot.ChassisSpeedLevelMedium,
+		robot.ChassisSpeedLevelSlow,
+	} {
+		err = robotModule.SetChassisSpeedLevel(level)
+		if err != nil {
+			t.Fatalf("Failed to set speed level to %d: %v", level, err)
+		}
 
-	speedLevel, err = robotModule.ChassisSpeedLevel()
-	if err != nil {
-		t.Fatalf("Failed to get speed level: %v", err)
-	}
+		speedLevel, err := robotModule.ChassisSpeedLevel()
+		if err != nil {
+			t.Fatalf("Failed to get speed level: %v", err)
+		}
 
-	if speedLevel != 4 {
-		t.Fatalf("Speed level is not 4: %v", speedLevel)
+		if speedLevel != level {
+			t.Fatalf("Speed level is not %d: %v", level, speedLevel)
+		}
 	}
 }
@@ -0,0 +1,56 @@
+package robot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/robot"
+)
+
+func TestCalibrateIMU(t *testing.T) {
+	var sides []robot.IMUSide
+	var last robot.IMUCalibrationProgress
+
+	err := robotModule.CalibrateIMU(func(p robot.IMUCalibrationProgress) {
+		if len(sides) == 0 || sides[len(sides)-1] != p.Side {
+			sides = append(sides, p.Side)
+		}
+
+		last = p
+	})
+	if err != nil {
+		t.Fatalf("Failed to calibrate IMU: %v", err)
+	}
+
+	if len(sides) != int(robot.IMUSideRight)+1 {
+		t.Fatalf("Unexpected sides: %v", sides)
+	}
+	for i, side := range sides {
+		if side != robot.IMUSide(i) {
+			t.Fatalf("Unexpected sides: %v", sides)
+		}
+	}
+
+	if last.State != robot.IMUCalibrationStateSucceeded || last.Percent != 100 {
+		t.Fatalf("Unexpected last progress: %+v", last)
+	}
+}
+
+func TestCalibrateIMUCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		200*time.Millisecond)
+	defer cancel()
+
+	err := robotModule.CalibrateIMUContext(ctx, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A new calibration can be started after a canceled one.
+	err = robotModule.CalibrateIMU(nil)
+	if err != nil {
+		t.Fatalf("Failed to calibrate IMU: %v", err)
+	}
+}
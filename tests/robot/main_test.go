@@ -4,16 +4,15 @@ import (
 	"os"
 	"testing"
 
-	robomaster "github.com/brunoga/robomaster"
 	"github.com/brunoga/robomaster/module"
 	"github.com/brunoga/robomaster/module/robot"
-	"github.com/brunoga/robomaster/support"
+	"github.com/brunoga/robomaster/tests/internal"
 )
 
 var robotModule *robot.Robot
 
 func TestMain(m *testing.M) {
-	c, err := robomaster.NewWithModules(nil, support.AnyAppID,
+	c, err := internal.NewClient(nil,
 		module.TypeConnection|module.TypeRobot)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,47 @@
+package watchdog
+
+import (
+	"os"
+	"testing"
+
+	"github.com/brunoga/robomaster"
+	"github.com/brunoga/robomaster/module"
+	"github.com/brunoga/robomaster/module/controller"
+	"github.com/brunoga/robomaster/tests/internal"
+)
+
+var client *robomaster.Client
+
+func TestMain(m *testing.M) {
+	c, err := internal.NewClient(nil,
+		module.TypeConnection|module.TypeRobot|module.TypeController|
+			module.TypeChassis|module.TypeGimbal)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := c.Start(); err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := c.Stop(); err != nil {
+			panic(err)
+		}
+	}()
+
+	client = c
+
+	// Set controller mode to SDK for the tests here.
+	err = c.Controller().SetMode(controller.ModeSDK)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		err := c.Controller().SetMode(controller.ModeFPV)
+		if err != nil {
+			panic(err)
+		}
+	}()
+
+	os.Exit(m.Run())
+}
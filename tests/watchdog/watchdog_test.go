@@ -0,0 +1,186 @@
+package watchdog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/module/chassis"
+	"github.com/brunoga/robomaster/module/controller"
+	"github.com/brunoga/robomaster/module/watchdog"
+)
+
+const timeout = 300 * time.Millisecond
+
+func newWatchdog(t *testing.T) (*watchdog.Watchdog, <-chan watchdog.Reason) {
+	w, err := watchdog.New(nil, client.Connection(), client.Chassis(),
+		client.Gimbal(), client.Controller(), timeout)
+	if err != nil {
+		t.Fatalf("Failed to create watchdog: %v", err)
+	}
+
+	if err := w.Start(); err != nil {
+		t.Fatalf("Failed to start watchdog: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := w.Stop(); err != nil {
+			t.Fatalf("Failed to stop watchdog: %v", err)
+		}
+	})
+
+	trips := make(chan watchdog.Reason, 1)
+	_, err = w.AddTripListener(func(r watchdog.Reason) {
+		trips <- r
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to add trip listener: %v", err)
+	}
+
+	return w, trips
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	w, trips := newWatchdog(t)
+
+	err := client.Chassis().SetSpeed(chassis.ModeAngularVelocity, 0.2, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to set speed: %v", err)
+	}
+
+	if !w.Armed() {
+		t.Fatalf("Watchdog not armed after motion command")
+	}
+
+	select {
+	case r := <-trips:
+		if r != watchdog.ReasonTimeout {
+			t.Fatalf("Unexpected trip reason: %s", r)
+		}
+	case <-time.After(5 * timeout):
+		t.Fatalf("Watchdog did not trip")
+	}
+
+	if w.Armed() {
+		t.Fatalf("Watchdog still armed after tripping")
+	}
+
+	// Give the chassis time to report it stopped.
+	time.Sleep(200 * time.Millisecond)
+
+	if v := client.Chassis().Velocity(); v.X != 0 || v.Y != 0 || v.Yaw != 0 {
+		t.Fatalf("Chassis still moving after watchdog tripped: %+v", v)
+	}
+}
+
+func TestWatchdogWheelSpeedTimeout(t *testing.T) {
+	w, trips := newWatchdog(t)
+
+	err := client.Chassis().SetWheelSpeed(50, 50, 50, 50)
+	if err != nil {
+		t.Fatalf("Failed to set wheel speed: %v", err)
+	}
+
+	if !w.Armed() {
+		t.Fatalf("Watchdog not armed after wheel speed command")
+	}
+
+	select {
+	case r := <-trips:
+		if r != watchdog.ReasonTimeout {
+			t.Fatalf("Unexpected trip reason: %s", r)
+		}
+	case <-time.After(5 * timeout):
+		t.Fatalf("Watchdog did not trip")
+	}
+
+	// Give the chassis time to report it stopped.
+	time.Sleep(200 * time.Millisecond)
+
+	if v := client.Chassis().Velocity(); v.X != 0 || v.Y != 0 || v.Yaw != 0 {
+		t.Fatalf("Chassis still moving after watchdog tripped: %+v", v)
+	}
+}
+
+func TestWatchdogFed(t *testing.T) {
+	w, trips := newWatchdog(t)
+
+	for i := 0; i < 5; i++ {
+		err := client.Gimbal().SetRotationSpeed(0, 10)
+		if err != nil {
+			t.Fatalf("Failed to set rotation speed: %v", err)
+		}
+
+		time.Sleep(timeout / 2)
+	}
+
+	err := client.Gimbal().StopRotation()
+	if err != nil {
+		t.Fatalf("Failed to stop rotation: %v", err)
+	}
+
+	if w.Armed() {
+		t.Fatalf("Watchdog still armed after motion stopped")
+	}
+
+	select {
+	case r := <-trips:
+		t.Fatalf("Unexpected trip: %s", r)
+	case <-time.After(2 * timeout):
+	}
+}
+
+func TestWatchdogControllerTimeout(t *testing.T) {
+	_, trips := newWatchdog(t)
+
+	err := client.Controller().Move(&controller.StickPosition{Y: 0.5}, nil,
+		controller.ModeFPV)
+	if err != nil {
+		t.Fatalf("Failed to move: %v", err)
+	}
+
+	select {
+	case r := <-trips:
+		if r != watchdog.ReasonTimeout {
+			t.Fatalf("Unexpected trip reason: %s", r)
+		}
+	case <-time.After(5 * timeout):
+		t.Fatalf("Watchdog did not trip")
+	}
+
+	// Give the chassis time to report it stopped.
+	time.Sleep(200 * time.Millisecond)
+
+	if v := client.Chassis().Velocity(); v.X != 0 || v.Y != 0 || v.Yaw != 0 {
+		t.Fatalf("Chassis still moving after watchdog tripped: %+v", v)
+	}
+}
+
+func TestWatchdogGimbalTripLeavesChassis(t *testing.T) {
+	_, trips := newWatchdog(t)
+
+	commands := make(chan chassis.MotionCommand, 10)
+	ct, err := client.Chassis().AddMotionCommandListener(
+		func(mc chassis.MotionCommand) {
+			commands <- mc
+		})
+	if err != nil {
+		t.Fatalf("Failed to add chassis motion command listener: %v", err)
+	}
+	defer client.Chassis().RemoveMotionCommandListener(ct)
+
+	err = client.Gimbal().SetRotationSpeed(0, 10)
+	if err != nil {
+		t.Fatalf("Failed to set rotation speed: %v", err)
+	}
+
+	select {
+	case <-trips:
+	case <-time.After(5 * timeout):
+		t.Fatalf("Watchdog did not trip")
+	}
+
+	select {
+	case mc := <-commands:
+		t.Fatalf("Unexpected chassis command after gimbal trip: %+v", mc)
+	default:
+	}
+}
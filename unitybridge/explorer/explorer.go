@@ -0,0 +1,305 @@
+// Package explorer helps figuring out the format of key values that are not
+// known yet (keys with no result value type).
+//
+// The high level Unity Bridge API can not handle results for keys with
+// unknown value types, so an Explorer talks directly to a
+// wrapper.UnityBridge. It listens to and polls keys, collecting the raw JSON
+// values reported for them, and can infer candidate Go types from the
+// collected values.
+package explorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/wrapper"
+)
+
+// Source is how a sample was obtained.
+type Source uint8
+
+const (
+	// SourceGet samples are replies to explicit get requests.
+	SourceGet Source = iota
+
+	// SourceListen samples are updates sent to key listeners.
+	SourceListen
+)
+
+// String returns a string representation of the source.
+func (s Source) String() string {
+	switch s {
+	case SourceGet:
+		return "get"
+	case SourceListen:
+		return "listen"
+	}
+
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Source) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Sample is a raw key value reported at a specific time.
+type Sample struct {
+	Time      time.Time       `json:"time"`
+	Key       string          `json:"key"`
+	Source    Source          `json:"source"`
+	ErrorCode int64           `json:"error"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// HasValue returns true if the sample has an actual value (failed requests
+// and results with no value have an empty string as value).
+func (s *Sample) HasValue() bool {
+	return s.ErrorCode == 0 && len(s.Value) != 0 && string(s.Value) != `""`
+}
+
+// maxSamples is the maximum number of samples kept for each key. Older samples
+// are dropped when it is reached (but are still written out).
+const maxSamples = 1024
+
+// wireResult is the JSON representation of a result with the value left
+// undecoded.
+type wireResult struct {
+	Key   uint32          `json:"key"`
+	Tag   uint64          `json:"tag"`
+	Error int64           `json:"error"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Explorer collects raw values for keys.
+type Explorer struct {
+	uw wrapper.UnityBridge
+	l  *logger.Logger
+
+	m       sync.Mutex
+	started bool
+	tag     uint64
+	w       io.Writer
+	enc     *json.Encoder
+	samples map[*key.Key][]Sample
+}
+
+// New returns a new Explorer that uses the given wrapper.UnityBridge. If w
+// is not nil, all samples are also written to it as JSON lines.
+func New(uw wrapper.UnityBridge, w io.Writer, l *logger.Logger) *Explorer {
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	e := &Explorer{
+		uw:      uw,
+		l:       l.WithGroup("explorer"),
+		w:       w,
+		samples: make(map[*key.Key][]Sample),
+	}
+
+	if w != nil {
+		e.enc = json.NewEncoder(w)
+	}
+
+	return e
+}
+
+// Start initializes the Unity Bridge.
+func (e *Explorer) Start() error {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if e.started {
+		return fmt.Errorf("explorer already started")
+	}
+
+	e.uw.Create("Robomaster", false, "")
+	if !e.uw.Initialize() {
+		return fmt.Errorf("failed to initialize Unity Bridge library")
+	}
+
+	for _, et := range []event.Type{event.TypeGetValue,
+		event.TypeStartListening} {
+		e.uw.SetEventCallback(event.NewFromType(et).Code(), e.eventCallback)
+	}
+
+	e.started = true
+
+	return nil
+}
+
+// Stop uninitializes the Unity Bridge.
+func (e *Explorer) Stop() error {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if !e.started {
+		return fmt.Errorf("explorer not started")
+	}
+
+	for _, et := range []event.Type{event.TypeGetValue,
+		event.TypeStartListening} {
+		e.uw.SetEventCallback(event.NewFromType(et).Code(), nil)
+	}
+
+	e.uw.Uninitialize()
+	e.uw.Destroy()
+
+	e.started = false
+
+	return nil
+}
+
+// Connect connects to the robot at the given IP and port.
+func (e *Explorer) Connect(ip net.IP, port uint64) {
+	ev := event.NewFromType(event.TypeConnection)
+
+	ev.ResetSubType(1)
+	e.uw.SendEvent(ev.Code(), nil, 0)
+
+	ev.ResetSubType(2)
+	e.uw.SendEventWithString(ev.Code(), ip.String(), 0)
+
+	ev.ResetSubType(3)
+	e.uw.SendEventWithNumber(ev.Code(), port, 0)
+
+	ev.ResetSubType(0)
+	e.uw.SendEvent(ev.Code(), nil, 0)
+}
+
+// Explore listens to all the given keys and polls them at the given interval
+// until the given context is done. Only readable keys are considered. If
+// pollInterval is zero, keys are not polled.
+func (e *Explorer) Explore(ctx context.Context, keys []*key.Key,
+	pollInterval time.Duration) {
+	var readable []*key.Key
+	for _, k := range keys {
+		if k.AccessType()&key.AccessTypeRead != 0 {
+			readable = append(readable, k)
+		}
+	}
+
+	for _, k := range readable {
+		ev := event.NewFromTypeAndSubType(event.TypeStartListening, k.SubType())
+		e.uw.SendEvent(ev.Code(), nil, 0)
+	}
+
+	defer func() {
+		for _, k := range readable {
+			ev := event.NewFromTypeAndSubType(event.TypeStopListening,
+				k.SubType())
+			e.uw.SendEvent(ev.Code(), nil, 0)
+		}
+	}()
+
+	if pollInterval <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, k := range readable {
+			e.m.Lock()
+			e.tag++
+			tag := e.tag
+			e.m.Unlock()
+
+			ev := event.NewFromTypeAndSubType(event.TypeGetValue, k.SubType())
+			e.uw.SendEvent(ev.Code(), nil, tag)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Keys returns all keys with samples, sorted by sub-type.
+func (e *Explorer) Keys() []*key.Key {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	var keys []*key.Key
+	for _, k := range key.All() {
+		if _, ok := e.samples[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// Samples returns the samples collected for the given key. Only the most
+// recent maxSamples samples are kept.
+func (e *Explorer) Samples(k *key.Key) []Sample {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	return append([]Sample(nil), e.samples[k]...)
+}
+
+func (e *Explorer) eventCallback(eventCode uint64, data []byte, tag uint64) {
+	ev := event.NewFromCode(eventCode)
+
+	var wr wireResult
+	if err := json.Unmarshal(data, &wr); err != nil {
+		e.l.Warn("Error parsing result", "event", ev, "data", string(data),
+			"error", err)
+		return
+	}
+
+	k, err := key.FromSubType(wr.Key)
+	if err != nil {
+		e.l.Warn("Result for unknown key", "event", ev, "data", string(data))
+		return
+	}
+
+	source := SourceGet
+	if ev.Type() == event.TypeStartListening {
+		source = SourceListen
+	}
+
+	s := Sample{
+		Time:      time.Now(),
+		Key:       k.String(),
+		Source:    source,
+		ErrorCode: wr.Error,
+		Value:     wr.Value,
+	}
+
+	e.l.Info("Sample", "key", k, "source", source, "error", wr.Error,
+		"value", string(wr.Value))
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	samples := e.samples[k]
+	if len(samples) == maxSamples {
+		// Reuse the backing array instead of growing it.
+		copy(samples, samples[1:])
+		samples = samples[:len(samples)-1]
+	}
+
+	e.samples[k] = append(samples, s)
+
+	if e.enc != nil {
+		if err := e.enc.Encode(s); err != nil {
+			e.l.Warn("Error writing sample", "error", err)
+		}
+	}
+}
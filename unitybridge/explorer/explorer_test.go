@@ -0,0 +1,112 @@
+package explorer_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/unitybridge/explorer"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplorer(t *testing.T) {
+	var samples bytes.Buffer
+
+	e := explorer.New(simulator.NewUnityBridgeWrapper(nil), &samples, nil)
+	require.NoError(t, e.Start())
+	defer func() {
+		assert.NoError(t, e.Stop())
+	}()
+
+	e.Connect(net.ParseIP("127.0.0.1"), 10607)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		300*time.Millisecond)
+	defer cancel()
+
+	e.Explore(ctx, []*key.Key{
+		key.KeyRobomasterSystemSpeakerVolumn,
+		key.KeyGimbalAttitude,
+		key.KeyRobomasterSystemKill, // Not readable.
+	}, 100*time.Millisecond)
+
+	// Keys are sorted by sub-type.
+	assert.Equal(t, []*key.Key{
+		key.KeyGimbalAttitude,
+		key.KeyRobomasterSystemSpeakerVolumn,
+	}, e.Keys())
+
+	volumeSamples := e.Samples(key.KeyRobomasterSystemSpeakerVolumn)
+	require.NotEmpty(t, volumeSamples)
+	assert.Equal(t, explorer.SourceGet, volumeSamples[0].Source)
+	assert.True(t, volumeSamples[0].HasValue())
+
+	// All samples were also written out.
+	lines := strings.Split(strings.TrimSpace(samples.String()), "\n")
+	assert.Len(t, lines, len(volumeSamples)+len(e.Samples(
+		key.KeyGimbalAttitude)))
+
+	var report bytes.Buffer
+	require.NoError(t, e.Report(&report))
+	assert.Contains(t, report.String(), "KeyGimbalAttitude")
+	assert.Contains(t, report.String(), "known type: *value.GimbalAttitude")
+}
+
+func TestInferShape(t *testing.T) {
+	raw := func(values ...string) []json.RawMessage {
+		var r []json.RawMessage
+		for _, v := range values {
+			r = append(r, json.RawMessage(v))
+		}
+		return r
+	}
+
+	s, err := explorer.InferShape(raw(`{"value":1}`, `{"value":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, "value.Uint64", s.KnownValueType())
+
+	s, err = explorer.InferShape(raw(`{"value":1}`, `{"value":-2.5}`))
+	require.NoError(t, err)
+	assert.Equal(t, "value.Float64", s.KnownValueType())
+
+	s, err = explorer.InferShape(raw(`{"value":-1}`))
+	require.NoError(t, err)
+	assert.Equal(t, "", s.KnownValueType())
+	assert.Equal(t, "Value[int64]", s.GoType())
+
+	s, err = explorer.InferShape(raw(`{"list":[{"id":1}]}`, `{"list":[]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "List[struct {\nId uint64 `json:\"id\"`\n}]", s.GoType())
+
+	s, err = explorer.InferShape(raw(
+		`{"speed_x":0,"speedY":1,"on":true,"name":null}`,
+		`{"speed_x":0.5,"speedY":1,"on":false,"name":"a","extra":[1,2]}`))
+	require.NoError(t, err)
+	assert.Equal(t, explorer.KindObject, s.Kind)
+	assert.Equal(t, explorer.KindString, s.Fields["name"].Kind)
+
+	src, err := explorer.GenerateValueTypes(map[string]*explorer.Shape{
+		explorer.TypeName("KeyRobomasterChassisSpeed"): s,
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, string(src), "package value")
+	assert.Contains(t, string(src), "type RobomasterChassisSpeed struct {")
+	assert.Regexp(t, "SpeedX +float64 +`json:\"speed_x\"`", string(src))
+	assert.Regexp(t, "SpeedY +uint64 +`json:\"speedY\"`", string(src))
+	assert.Regexp(t, "Extra +\\[\\]uint64 +`json:\"extra\"`", string(src))
+
+	s, err = explorer.InferShape(raw(`{"value":1}`, `{"value":"a"}`))
+	require.NoError(t, err)
+	assert.Equal(t, explorer.KindMixed, s.Fields["value"].Kind)
+
+	_, err = explorer.InferShape(raw(`{`))
+	assert.Error(t, err)
+}
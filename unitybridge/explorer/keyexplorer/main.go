@@ -0,0 +1,136 @@
+// Command keyexplorer connects to a robot, listens to (and polls) readable
+// keys and reports the raw values seen for them, including candidate Go types
+// for keys with unknown value types.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	"github.com/brunoga/robomaster/support/finder"
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/unitybridge/explorer"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/wrapper"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/simulator"
+)
+
+var (
+	appID = flag.Uint64("appid", 0, "App ID to use when looking for a "+
+		"robot. If 0, any robot will do.")
+	router = flag.Bool("router", false, "Look for a robot in the network "+
+		"instead of using WiFi Direct.")
+	simulate = flag.Bool("simulator", false, "Use the simulated robot.")
+	all      = flag.Bool("all", false, "Also explore keys with known value "+
+		"types.")
+	duration = flag.Duration("duration", 30*time.Second, "How long to "+
+		"explore keys for.")
+	poll = flag.Duration("poll", 5*time.Second, "Interval between key "+
+		"polls. If 0, keys are only listened to.")
+	samplesFile = flag.String("samples", "", "File to write all samples to "+
+		"(as JSON lines).")
+	genFile = flag.String("gen", "", "File to write generated value types "+
+		"to.")
+	verbose = flag.Bool("verbose", false, "Log every sample.")
+)
+
+func main() {
+	flag.Parse()
+
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelInfo
+	}
+
+	l := logger.New(level)
+
+	if err := run(l); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(l *logger.Logger) error {
+	var uw wrapper.UnityBridge
+	if *simulate {
+		uw = simulator.NewUnityBridgeWrapper(l)
+	} else {
+		uw = wrapper.Get(l)
+	}
+
+	var w io.Writer
+	if *samplesFile != "" {
+		f, err := os.Create(*samplesFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	e := explorer.New(uw, w, l)
+
+	if err := e.Start(); err != nil {
+		return err
+	}
+	defer e.Stop()
+
+	ip := net.ParseIP("192.168.2.1")
+	if *router {
+		f := finder.New(*appID, l)
+
+		b, err := f.Find(time.Minute)
+		if err != nil {
+			return fmt.Errorf("error finding robot: %w", err)
+		}
+
+		f.SendACK(b.SourceIp(), b.AppId())
+
+		ip = b.SourceIp()
+	}
+
+	fmt.Println("Connecting to robot at", ip)
+
+	e.Connect(ip, 10607)
+
+	var keys []*key.Key
+	for _, k := range key.All() {
+		if *all || !k.HasResultValue() {
+			keys = append(keys, k)
+		}
+	}
+
+	fmt.Printf("Exploring %d keys for %s\n", len(keys), *duration)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+
+	e.Explore(ctx, keys, *poll)
+
+	if err := e.Report(os.Stdout); err != nil {
+		return err
+	}
+
+	if *genFile == "" {
+		return nil
+	}
+
+	shapes, err := e.Shapes()
+	if err != nil {
+		return err
+	}
+
+	src, err := explorer.GenerateValueTypes(shapes)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(*genFile, src, 0644)
+}
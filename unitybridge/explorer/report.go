@@ -0,0 +1,118 @@
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+)
+
+// maxDistinctValues is the maximum number of distinct values listed for each
+// key in a report.
+const maxDistinctValues = 5
+
+// Shapes returns the inferred shapes for all keys with unknown value types
+// that had at least one value reported, keyed by the type name to use for
+// them.
+func (e *Explorer) Shapes() (map[string]*Shape, error) {
+	shapes := make(map[string]*Shape)
+
+	for _, k := range e.Keys() {
+		if k.HasResultValue() {
+			continue
+		}
+
+		values := values(e.Samples(k))
+		if len(values) == 0 {
+			continue
+		}
+
+		s, err := InferShape(values)
+		if err != nil {
+			return nil, fmt.Errorf("error inferring shape for key %s: %w", k,
+				err)
+		}
+
+		shapes[TypeName(k.String())] = s
+	}
+
+	return shapes, nil
+}
+
+// Report writes a human readable report about all keys with samples to the
+// given io.Writer.
+func (e *Explorer) Report(w io.Writer) error {
+	for _, k := range e.Keys() {
+		if err := e.reportKey(w, k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Explorer) reportKey(w io.Writer, k *key.Key) error {
+	samples := e.Samples(k)
+
+	errors := 0
+	for _, s := range samples {
+		if s.ErrorCode != 0 {
+			errors++
+		}
+	}
+
+	values := values(samples)
+
+	fmt.Fprintf(w, "%s (%d)\n", k, k.SubType())
+	fmt.Fprintf(w, "  samples: %d (errors: %d, with value: %d)\n",
+		len(samples), errors, len(values))
+
+	if k.HasResultValue() {
+		fmt.Fprintf(w, "  known type: %T\n", k.ResultValue())
+	}
+
+	distinct := make(map[string]struct{})
+	for _, v := range values {
+		if len(distinct) == maxDistinctValues {
+			fmt.Fprintf(w, "  ...\n")
+			break
+		}
+
+		if _, ok := distinct[string(v)]; ok {
+			continue
+		}
+
+		distinct[string(v)] = struct{}{}
+
+		fmt.Fprintf(w, "  value: %s\n", v)
+	}
+
+	if len(values) != 0 && !k.HasResultValue() {
+		s, err := InferShape(values)
+		if err != nil {
+			return err
+		}
+
+		if known := s.KnownValueType(); known != "" {
+			fmt.Fprintf(w, "  candidate type: %s\n", known)
+		} else {
+			fmt.Fprintf(w, "  candidate type: %s\n", s.GoType())
+		}
+	}
+
+	_, err := fmt.Fprintln(w)
+
+	return err
+}
+
+func values(samples []Sample) []json.RawMessage {
+	var values []json.RawMessage
+	for _, s := range samples {
+		if s.HasValue() {
+			values = append(values, s.Value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,32 @@
+package explorer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplesCapped(t *testing.T) {
+	e := New(nil, nil, nil)
+
+	code := event.NewFromType(event.TypeGetValue).Code()
+	k := key.KeyRobomasterSystemSpeakerVolumn
+
+	for i := 0; i < maxSamples+10; i++ {
+		data := fmt.Sprintf(`{"key":%d,"tag":0,"error":0,"value":%d}`,
+			k.SubType(), i)
+		e.eventCallback(code, []byte(data), 0)
+	}
+
+	samples := e.Samples(k)
+	require.Len(t, samples, maxSamples)
+
+	// Oldest samples were dropped.
+	assert.Equal(t, "10", string(samples[0].Value))
+	assert.Equal(t, fmt.Sprint(maxSamples+9),
+		string(samples[len(samples)-1].Value))
+}
@@ -0,0 +1,287 @@
+package explorer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kind is the kind of a JSON value.
+type Kind uint8
+
+const (
+	KindUnknown Kind = iota
+	KindNull
+	KindBool
+	KindNumber
+	KindString
+	KindObject
+	KindArray
+
+	// KindMixed is used when different samples have different kinds.
+	KindMixed
+)
+
+// String returns a string representation of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindObject:
+		return "object"
+	case KindArray:
+		return "array"
+	case KindMixed:
+		return "mixed"
+	}
+
+	return "unknown"
+}
+
+// Shape is the inferred shape of a set of JSON values.
+type Shape struct {
+	Kind Kind
+
+	// Number specific information.
+	Negative   bool
+	Fractional bool
+
+	// Fields for objects.
+	Fields map[string]*Shape
+
+	// Elem is the shape of array elements (nil for empty arrays).
+	Elem *Shape
+}
+
+// InferShape returns the shape that fits all the given JSON values.
+func InferShape(values []json.RawMessage) (*Shape, error) {
+	s := &Shape{}
+
+	for _, data := range values {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+
+		var v any
+		if err := d.Decode(&v); err != nil {
+			return nil, fmt.Errorf("error decoding value %q: %w", data, err)
+		}
+
+		s.merge(v)
+	}
+
+	return s, nil
+}
+
+func (s *Shape) merge(v any) {
+	kind := kindOf(v)
+
+	switch {
+	case kind == KindNull:
+		if s.Kind == KindUnknown {
+			s.Kind = KindNull
+		}
+		return
+	case s.Kind == KindUnknown || s.Kind == KindNull:
+		s.Kind = kind
+	case s.Kind != kind:
+		s.Kind = KindMixed
+		return
+	}
+
+	switch value := v.(type) {
+	case json.Number:
+		str := value.String()
+		if strings.HasPrefix(str, "-") {
+			s.Negative = true
+		}
+		if strings.ContainsAny(str, ".eE") {
+			s.Fractional = true
+		}
+	case map[string]any:
+		if s.Fields == nil {
+			s.Fields = make(map[string]*Shape)
+		}
+		for name, fieldValue := range value {
+			field, ok := s.Fields[name]
+			if !ok {
+				field = &Shape{}
+				s.Fields[name] = field
+			}
+			field.merge(fieldValue)
+		}
+	case []any:
+		for _, elemValue := range value {
+			if s.Elem == nil {
+				s.Elem = &Shape{}
+			}
+			s.Elem.merge(elemValue)
+		}
+	}
+}
+
+func kindOf(v any) Kind {
+	switch v.(type) {
+	case nil:
+		return KindNull
+	case bool:
+		return KindBool
+	case json.Number:
+		return KindNumber
+	case string:
+		return KindString
+	case map[string]any:
+		return KindObject
+	case []any:
+		return KindArray
+	}
+
+	return KindUnknown
+}
+
+// KnownValueType returns the name of an existing type in the value package
+// that fits this shape or an empty string if there is none.
+func (s *Shape) KnownValueType() string {
+	if s.Kind != KindObject || len(s.Fields) != 1 {
+		return ""
+	}
+
+	if inner, ok := s.Fields["value"]; ok {
+		switch inner.Kind {
+		case KindBool:
+			return "value.Bool"
+		case KindString:
+			return "value.String"
+		case KindNumber:
+			if inner.Fractional {
+				return "value.Float64"
+			}
+			if !inner.Negative {
+				return "value.Uint64"
+			}
+		}
+	}
+
+	return ""
+}
+
+// GoType returns the Go type expression for the shape.
+func (s *Shape) GoType() string {
+	switch s.Kind {
+	case KindBool:
+		return "bool"
+	case KindString:
+		return "string"
+	case KindNumber:
+		if s.Fractional {
+			return "float64"
+		}
+		if s.Negative {
+			return "int64"
+		}
+		return "uint64"
+	case KindArray:
+		if s.Elem == nil {
+			return "[]any"
+		}
+		return "[]" + s.Elem.GoType()
+	case KindObject:
+		if len(s.Fields) == 1 {
+			if inner, ok := s.Fields["value"]; ok {
+				return "Value[" + inner.GoType() + "]"
+			}
+			if inner, ok := s.Fields["list"]; ok && inner.Kind == KindArray &&
+				inner.Elem != nil {
+				return "List[" + inner.Elem.GoType() + "]"
+			}
+		}
+
+		var b strings.Builder
+
+		b.WriteString("struct {\n")
+		for _, name := range s.fieldNames() {
+			fmt.Fprintf(&b, "%s %s `json:%q`\n", exportedName(name),
+				s.Fields[name].GoType(), name)
+		}
+		b.WriteString("}")
+
+		return b.String()
+	}
+
+	return "any"
+}
+
+func (s *Shape) fieldNames() []string {
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// exportedName returns an exported Go identifier for the given JSON field
+// name.
+func exportedName(name string) string {
+	var b strings.Builder
+
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		b.WriteRune(r)
+	}
+
+	if b.Len() == 0 || unicode.IsDigit([]rune(b.String())[0]) {
+		return "F" + b.String()
+	}
+
+	return b.String()
+}
+
+// TypeName returns the name used for the generated value type for the given
+// key name.
+func TypeName(keyName string) string {
+	return strings.TrimPrefix(keyName, "Key")
+}
+
+// GenerateValueTypes returns the (formatted) source code for a file in the
+// value package declaring the given types, keyed by type name.
+func GenerateValueTypes(shapes map[string]*Shape) ([]byte, error) {
+	names := make([]string, 0, len(shapes))
+	for name := range shapes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var b bytes.Buffer
+
+	b.WriteString("// Code generated by keyexplorer. Review before use.\n\n")
+	b.WriteString("package value\n")
+
+	for _, name := range names {
+		fmt.Fprintf(&b, "\ntype %s %s\n", name, shapes[name].GoType())
+	}
+
+	return format.Source(b.Bytes())
+}
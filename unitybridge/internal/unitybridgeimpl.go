@@ -2,13 +2,16 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"reflect"
 	"sync"
 	"time"
 
+	"github.com/brunoga/robomaster/support/dispatcher"
 	"github.com/brunoga/robomaster/support/logger"
 	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge/unity/event"
@@ -22,6 +25,34 @@ var (
 	voidType = reflect.TypeOf(&value.Void{})
 )
 
+// defaultSyncTimeout is the timeout used by synchronous operations when the
+// given context has no deadline.
+const defaultSyncTimeout = 5 * time.Second
+
+// defaultListenerOptions are the dispatcher options used for listeners when
+// none are given. The oldest results are dropped if a listener does not keep
+// up so a slow listener never stalls the delivery of events to others.
+var defaultListenerOptions = dispatcher.Options{
+	QueueSize:      64,
+	OverflowPolicy: dispatcher.OverflowPolicyDropOldest,
+}
+
+// listenerOptions returns the given options or defaultListenerOptions if o
+// is nil.
+func listenerOptions(o *dispatcher.Options) *dispatcher.Options {
+	if o == nil {
+		o = &defaultListenerOptions
+	}
+
+	return o
+}
+
+// keyDispatcher delivers results to a single key listener.
+type keyDispatcher = dispatcher.Dispatcher[*result.Result]
+
+// eventTypeDispatcher delivers events to a single event type listener.
+type eventTypeDispatcher = dispatcher.Dispatcher[event.Message]
+
 type UnityBridgeImpl struct {
 	uw               wrapper.UnityBridge
 	unityBridgeDebug bool
@@ -30,8 +61,8 @@ type UnityBridgeImpl struct {
 
 	m                  sync.RWMutex
 	started            bool
-	keyListeners       map[*key.Key]map[token.Token]result.Callback
-	eventTypeListeners map[event.Type]map[token.Token]event.TypeCallback
+	keyListeners       map[*key.Key]map[token.Token]*keyDispatcher
+	eventTypeListeners map[event.Type]map[token.Token]*eventTypeDispatcher
 	callbackListener   map[token.Token]result.Callback
 }
 
@@ -49,8 +80,8 @@ func NewUnityBridgeImpl(uw wrapper.UnityBridge,
 		unityBridgeDebug:   unityBridgeDebug,
 		l:                  l,
 		tg:                 token.NewGenerator(),
-		keyListeners:       make(map[*key.Key]map[token.Token]result.Callback),
-		eventTypeListeners: make(map[event.Type]map[token.Token]event.TypeCallback),
+		keyListeners:       make(map[*key.Key]map[token.Token]*keyDispatcher),
+		eventTypeListeners: make(map[event.Type]map[token.Token]*eventTypeDispatcher),
 		callbackListener:   make(map[token.Token]result.Callback),
 	}
 }
@@ -92,56 +123,73 @@ func (u *UnityBridgeImpl) Start() (err error) {
 }
 
 func (u *UnityBridgeImpl) AddKeyListener(k *key.Key, c result.Callback,
-	immediate bool) (t token.Token, err error) {
-	endTrace := u.l.Trace("AddKeyListener", "key", k, "callback", c,
-		"immediate", immediate)
+	immediate bool) (token.Token, error) {
+	return u.AddKeyListenerWithOptions(k, c, immediate, nil)
+}
+
+func (u *UnityBridgeImpl) AddKeyListenerWithOptions(k *key.Key,
+	c result.Callback, immediate bool,
+	o *dispatcher.Options) (t token.Token, err error) {
+	endTrace := u.l.Trace("AddKeyListenerWithOptions", "key", k, "callback",
+		c, "immediate", immediate, "options", o)
 	defer func() {
 		endTrace("token", t, "error", err)
 	}()
 
-	if k.AccessType()&key.AccessTypeRead == 0 {
-		return 0, fmt.Errorf("key %s is not readable", k)
-	}
-
 	if c == nil {
 		return 0, fmt.Errorf("callback cannot be nil")
 	}
 
-	t = u.tg.Next()
-
-	u.m.Lock()
-
-	if _, ok := u.keyListeners[k]; !ok {
-		u.keyListeners[k] = make(map[token.Token]result.Callback)
+	d, err := dispatcher.New(listenerOptions(o), func(r *result.Result) {
+		c(r)
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	if len(u.keyListeners[k]) == 0 {
-		ev := event.NewFromTypeAndSubType(event.TypeStartListening, k.SubType())
-		u.uw.SendEvent(ev.Code(), nil, 0)
+	t, err = u.addKeyDispatcher(k, d, immediate)
+	if err != nil {
+		d.Stop()
+		return 0, err
 	}
 
-	u.keyListeners[k][t] = c
+	return t, nil
+}
 
-	u.m.Unlock()
+func (u *UnityBridgeImpl) SubscribeKey(k *key.Key, immediate bool,
+	o *dispatcher.Options) (ch <-chan *result.Result, t token.Token,
+	err error) {
+	endTrace := u.l.Trace("SubscribeKey", "key", k, "immediate", immediate,
+		"options", o)
+	defer func() {
+		endTrace("token", t, "error", err)
+	}()
 
-	if !immediate {
-		return t, nil
-	}
+	rc := make(chan *result.Result)
 
-	r, err := u.GetCachedKeyValue(k)
+	var d *keyDispatcher
+	d, err = dispatcher.New(listenerOptions(o), func(r *result.Result) {
+		select {
+		case rc <- r:
+		case <-d.Stopped():
+		}
+	})
 	if err != nil {
-		return 0, err
+		return nil, 0, err
 	}
 
-	if r == nil {
-		// Nil error with nil result means no cached value. Just return the
-		// token.
-		return t, nil
-	}
+	go func() {
+		<-d.Done()
+		close(rc)
+	}()
 
-	go c(r)
+	t, err = u.addKeyDispatcher(k, d, immediate)
+	if err != nil {
+		d.Stop()
+		return nil, 0, err
+	}
 
-	return t, nil
+	return rc, t, nil
 }
 
 func (u *UnityBridgeImpl) RemoveKeyListener(k *key.Key,
@@ -160,13 +208,20 @@ func (u *UnityBridgeImpl) RemoveKeyListener(k *key.Key,
 
 	if t == 0 {
 		// Remove all listeners for the given key.
+		for _, d := range u.keyListeners[k] {
+			d.Stop()
+		}
+
 		delete(u.keyListeners, k)
 	} else {
-		if _, ok := u.keyListeners[k][t]; !ok {
+		d, ok := u.keyListeners[k][t]
+		if !ok {
 			return fmt.Errorf("no listener registered with token %d for key %s", t,
 				k)
 		}
 
+		d.Stop()
+
 		delete(u.keyListeners[k], t)
 	}
 
@@ -185,32 +240,20 @@ func (u *UnityBridgeImpl) GetKeyValue(k *key.Key, c result.Callback) (err error)
 		endTrace("error", err)
 	}()
 
-	defer u.l.Trace("GetKeyValue", []any{
-		"error", err,
-	}, "key", k, "callback", c)
-
-	if k.AccessType()&key.AccessTypeRead == 0 {
-		return fmt.Errorf("key %s is not readable", k)
-	}
+	_, err = u.getKeyValue(k, c)
 
-	ev := event.NewFromTypeAndSubType(event.TypeGetValue, k.SubType())
-
-	tag := u.tg.Next()
-
-	u.m.Lock()
-
-	u.callbackListener[tag] = c
-
-	u.m.Unlock()
-
-	u.uw.SendEvent(ev.Code(), nil, uint64(tag))
-
-	return nil
+	return err
 }
 
 func (u *UnityBridgeImpl) GetKeyValueSync(k *key.Key,
-	useCache bool) (r *result.Result, err error) {
-	endTrace := u.l.Trace("GetKeyValueSync", "key", k, "useCache", useCache)
+	useCache bool) (*result.Result, error) {
+	return u.GetKeyValueSyncContext(context.Background(), k, useCache)
+}
+
+func (u *UnityBridgeImpl) GetKeyValueSyncContext(ctx context.Context,
+	k *key.Key, useCache bool) (r *result.Result, err error) {
+	endTrace := u.l.Trace("GetKeyValueSyncContext", "key", k, "useCache",
+		useCache)
 	defer func() {
 		endTrace("result", r, "error", err)
 	}()
@@ -223,28 +266,29 @@ func (u *UnityBridgeImpl) GetKeyValueSync(k *key.Key,
 		}
 	}
 
-	done := make(chan struct{})
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
 
-	err = u.GetKeyValue(k, func(r2 *result.Result) {
-		if r2.ErrorCode() != 0 {
-			err = fmt.Errorf("error getting value for key %s: %s", k,
-				r2.ErrorDesc())
-		} else {
-			r = r2
-		}
+	c := make(chan *result.Result, 1)
 
-		close(done)
+	tag, err := u.getKeyValue(k, func(r *result.Result) {
+		c <- r
 	})
-
 	if err != nil {
 		return nil, err
 	}
 
 	select {
-	case <-done:
-		return r, err
-	case <-time.After(5 * time.Second):
-		return nil, fmt.Errorf("timeout getting value for key %s", k)
+	case r = <-c:
+		if r.ErrorCode() != 0 {
+			return nil, fmt.Errorf("error getting value for key %s: %s", k,
+				r.ErrorDesc())
+		}
+
+		return r, nil
+	case <-ctx.Done():
+		u.removeCallbackListener(tag)
+		return nil, contextError(ctx, "getting value for key %s", k)
 	}
 }
 
@@ -284,64 +328,45 @@ func (u *UnityBridgeImpl) SetKeyValue(k *key.Key, value any,
 		endTrace("error", err)
 	}()
 
-	if k.AccessType()&key.AccessTypeWrite == 0 {
-		return fmt.Errorf("key %s is not writable", k)
-	}
-
-	expectedKeyValue := k.ResultValue()
-
-	if reflect.TypeOf(value) != reflect.TypeOf(expectedKeyValue) {
-		return fmt.Errorf("value type %s does not match expected key %s type "+
-			"%s", reflect.TypeOf(value), k, reflect.TypeOf(expectedKeyValue))
-	}
-
-	data, err := json.Marshal(value)
-	if err != nil {
-		return err
-	}
-
-	ev := event.NewFromTypeAndSubType(event.TypeSetValue, k.SubType())
+	_, err = u.setKeyValue(k, value, c)
 
-	tag := u.tg.Next()
-
-	u.m.Lock()
-
-	u.callbackListener[tag] = c
-
-	u.m.Unlock()
-
-	u.uw.SendEventWithString(ev.Code(), string(data), uint64(tag))
+	return err
+}
 
-	return nil
+func (u *UnityBridgeImpl) SetKeyValueSync(k *key.Key, value any) error {
+	return u.SetKeyValueSyncContext(context.Background(), k, value)
 }
 
-func (u *UnityBridgeImpl) SetKeyValueSync(k *key.Key, value any) (err error) {
-	endTrace := u.l.Trace("SetKeyValueSync", "key", k, "value", value)
+func (u *UnityBridgeImpl) SetKeyValueSyncContext(ctx context.Context,
+	k *key.Key, value any) (err error) {
+	endTrace := u.l.Trace("SetKeyValueSyncContext", "key", k, "value", value)
 	defer func() {
 		endTrace("error", err)
 	}()
 
-	u.l.Trace("SetKeyValueSync", "key", k, "value", value)
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
 
-	done := make(chan struct{})
+	c := make(chan *result.Result, 1)
 
-	err = u.SetKeyValue(k, value, func(r *result.Result) {
-		if r.ErrorCode() != 0 {
-			err = fmt.Errorf("error setting value for key %s: %s", k, r.ErrorDesc())
-		}
-
-		close(done)
+	tag, err := u.setKeyValue(k, value, func(r *result.Result) {
+		c <- r
 	})
-
 	if err != nil {
 		return err
 	}
 
 	select {
-	case <-done:
-		return err
-	case <-time.After(5 * time.Second):
-		return fmt.Errorf("timeout setting value for key %s", k)
+	case r := <-c:
+		if r.ErrorCode() != 0 {
+			return fmt.Errorf("error setting value for key %s: %s", k,
+				r.ErrorDesc())
+		}
+
+		return nil
+	case <-ctx.Done():
+		u.removeCallbackListener(tag)
+		return contextError(ctx, "setting value for key %s", k)
 	}
 }
 
@@ -353,78 +378,47 @@ func (u *UnityBridgeImpl) PerformActionForKey(k *key.Key, value any,
 		endTrace("error", err)
 	}()
 
-	if k.AccessType()&key.AccessTypeAction == 0 {
-		return fmt.Errorf("key %s is not an action", k)
-	}
-
-	expectedKeyValue := k.ResultValue()
-	expectedType := reflect.TypeOf(expectedKeyValue)
-	actualType := reflect.TypeOf(value)
-
-	if expectedType == voidType {
-		if value != nil {
-			return fmt.Errorf("key %s is void type but value is not nil", k)
-		}
-	} else if actualType != expectedType {
-		return fmt.Errorf("value type %s does not match expected key %s type "+
-			"%s", actualType, k, expectedType)
-	}
-
-	var data []byte
-
-	if value != nil {
-		data, err = json.Marshal(value)
-		if err != nil {
-			return err
-		}
-	}
-
-	ev := event.NewFromTypeAndSubType(event.TypePerformAction, k.SubType())
-
-	tag := u.tg.Next()
+	_, err = u.performActionForKey(k, value, c)
 
-	if c != nil {
-		u.m.Lock()
-		u.callbackListener[tag] = c
-		u.m.Unlock()
-	}
-
-	if value != nil {
-		u.uw.SendEventWithString(ev.Code(), string(data), uint64(tag))
-	} else {
-		u.uw.SendEvent(ev.Code(), nil, uint64(tag))
-	}
-
-	return nil
+	return err
 }
 
 func (u *UnityBridgeImpl) PerformActionForKeySync(k *key.Key,
-	value any) (err error) {
-	endTrace := u.l.Trace("PerformActionForKeySync", "key", k, "value", value)
+	value any) error {
+	return u.PerformActionForKeySyncContext(context.Background(), k, value)
+}
+
+func (u *UnityBridgeImpl) PerformActionForKeySyncContext(ctx context.Context,
+	k *key.Key, value any) (err error) {
+	endTrace := u.l.Trace("PerformActionForKeySyncContext", "key", k,
+		"value", value)
 	defer func() {
 		endTrace("error", err)
 	}()
 
-	done := make(chan struct{})
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
 
-	err = u.PerformActionForKey(k, value, func(r *result.Result) {
-		if r.ErrorCode() != 0 {
-			err = fmt.Errorf("error performing action for key %s: %s", k,
-				r.ErrorDesc())
-		}
+	c := make(chan *result.Result, 1)
 
-		close(done)
+	tag, err := u.performActionForKey(k, value, func(r *result.Result) {
+		c <- r
 	})
-
 	if err != nil {
 		return err
 	}
 
 	select {
-	case <-done:
-		return err
-	case <-time.After(5 * time.Second):
-		return fmt.Errorf("timeout performing action for key %s", k)
+	case r := <-c:
+		if r.ErrorCode() != 0 {
+			return fmt.Errorf("error performing action for key %s: %s", k,
+				r.ErrorDesc())
+		}
+
+		return nil
+	case <-ctx.Done():
+		u.removeCallbackListener(tag)
+		return contextError(ctx, "performing action for key %s", k)
 	}
 }
 
@@ -481,9 +475,14 @@ func (u *UnityBridgeImpl) SendEventWithUint64(ev *event.Event,
 }
 
 func (u *UnityBridgeImpl) AddEventTypeListener(et event.Type,
-	c event.TypeCallback) (t token.Token, err error) {
-	endTrace := u.l.Trace("AddEventTypeListener", "eventType", et, "callback",
-		c)
+	c event.TypeCallback) (token.Token, error) {
+	return u.AddEventTypeListenerWithOptions(et, c, nil)
+}
+
+func (u *UnityBridgeImpl) AddEventTypeListenerWithOptions(et event.Type,
+	c event.TypeCallback, o *dispatcher.Options) (t token.Token, err error) {
+	endTrace := u.l.Trace("AddEventTypeListenerWithOptions", "eventType", et,
+		"callback", c, "options", o)
 	defer func() {
 		endTrace("token", t, "error", err)
 	}()
@@ -492,19 +491,43 @@ func (u *UnityBridgeImpl) AddEventTypeListener(et event.Type,
 		return 0, fmt.Errorf("callback cannot be nil")
 	}
 
-	tk := u.tg.Next()
+	d, err := dispatcher.New(listenerOptions(o), func(m event.Message) {
+		c(m.Event, m.Data, m.DataType)
+	})
+	if err != nil {
+		return 0, err
+	}
 
-	u.m.Lock()
+	return u.addEventTypeDispatcher(et, d), nil
+}
 
-	if _, ok := u.eventTypeListeners[et]; !ok {
-		u.eventTypeListeners[et] = make(map[token.Token]event.TypeCallback)
-	}
+func (u *UnityBridgeImpl) SubscribeEventType(et event.Type,
+	o *dispatcher.Options) (ch <-chan event.Message, t token.Token,
+	err error) {
+	endTrace := u.l.Trace("SubscribeEventType", "eventType", et, "options", o)
+	defer func() {
+		endTrace("token", t, "error", err)
+	}()
 
-	u.eventTypeListeners[et][tk] = c
+	mc := make(chan event.Message)
 
-	u.m.Unlock()
+	var d *eventTypeDispatcher
+	d, err = dispatcher.New(listenerOptions(o), func(m event.Message) {
+		select {
+		case mc <- m:
+		case <-d.Stopped():
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
 
-	return tk, nil
+	go func() {
+		<-d.Done()
+		close(mc)
+	}()
+
+	return mc, u.addEventTypeDispatcher(et, d), nil
 }
 
 func (u *UnityBridgeImpl) RemoveEventTypeListener(t event.Type,
@@ -523,13 +546,20 @@ func (u *UnityBridgeImpl) RemoveEventTypeListener(t event.Type,
 
 	if tk == 0 {
 		// Delete all listeners for the given event type.
+		for _, d := range u.eventTypeListeners[t] {
+			d.Stop()
+		}
+
 		delete(u.eventTypeListeners, t)
 	} else {
-		if _, ok := u.eventTypeListeners[t][tk]; !ok {
+		d, ok := u.eventTypeListeners[t][tk]
+		if !ok {
 			return fmt.Errorf("no listener registered with token %d for event type %s",
 				tk, t)
 		}
 
+		d.Stop()
+
 		delete(u.eventTypeListeners[t], tk)
 	}
 
@@ -630,16 +660,31 @@ func (u *UnityBridgeImpl) notifyEventTypeListeners(e *event.Event,
 
 	u.m.RLock()
 
-	if _, ok := u.eventTypeListeners[e.Type()]; ok {
-		for _, c := range u.eventTypeListeners[e.Type()] {
-			go c(e, data, dataType)
-		}
-	} else {
+	ds := make([]*eventTypeDispatcher, 0, len(u.eventTypeListeners[e.Type()]))
+	for _, d := range u.eventTypeListeners[e.Type()] {
+		ds = append(ds, d)
+	}
+
+	u.m.RUnlock()
+
+	if len(ds) == 0 {
 		u.l.Warn("No listeners registered for event type", "eventType",
 			e.Type(), "event", e, "data", data)
+		return
 	}
 
-	u.m.RUnlock()
+	// Dispatch without holding the lock as dispatching might block (depending
+	// on the listener overflow policy) and listeners might want to remove
+	// themselves in the meantime.
+	m := event.Message{
+		Event:    e,
+		Data:     data,
+		DataType: dataType,
+	}
+
+	for _, d := range ds {
+		d.Dispatch(m)
+	}
 }
 
 func (u *UnityBridgeImpl) notifyKeyListeners(k *key.Key, data []byte) {
@@ -648,17 +693,24 @@ func (u *UnityBridgeImpl) notifyKeyListeners(k *key.Key, data []byte) {
 
 	u.m.RLock()
 
-	r := result.NewFromJSON(data)
-
-	if _, ok := u.keyListeners[k]; ok {
-		for _, c := range u.keyListeners[k] {
-			go c(r)
-		}
-	} else {
-		u.l.Warn("No listeners registered for key", "key", k, "data", string(data))
+	ds := make([]*keyDispatcher, 0, len(u.keyListeners[k]))
+	for _, d := range u.keyListeners[k] {
+		ds = append(ds, d)
 	}
 
 	u.m.RUnlock()
+
+	if len(ds) == 0 {
+		u.l.Warn("No listeners registered for key", "key", k, "data",
+			string(data))
+		return
+	}
+
+	r := result.NewFromJSON(data)
+
+	for _, d := range ds {
+		d.Dispatch(r)
+	}
 }
 
 func (u *UnityBridgeImpl) notifyCallbacks(data []byte, tag uint64) {
@@ -668,6 +720,9 @@ func (u *UnityBridgeImpl) notifyCallbacks(data []byte, tag uint64) {
 	u.m.Lock()
 	if c, ok := u.callbackListener[token.Token(tag)]; ok {
 		if c != nil {
+			// Callbacks for requests are called only once so there is nothing
+			// to order. They get their own goroutine as they might issue (and
+			// wait for) other requests.
 			go c(result.NewFromJSON(data))
 		}
 		delete(u.callbackListener, token.Token(tag))
@@ -677,3 +732,198 @@ func (u *UnityBridgeImpl) notifyCallbacks(data []byte, tag uint64) {
 
 	u.m.Unlock()
 }
+
+func (u *UnityBridgeImpl) getKeyValue(k *key.Key,
+	c result.Callback) (token.Token, error) {
+	if k.AccessType()&key.AccessTypeRead == 0 {
+		return 0, fmt.Errorf("key %s is not readable", k)
+	}
+
+	ev := event.NewFromTypeAndSubType(event.TypeGetValue, k.SubType())
+
+	tag := u.tg.Next()
+
+	u.m.Lock()
+
+	u.callbackListener[tag] = c
+
+	u.m.Unlock()
+
+	u.uw.SendEvent(ev.Code(), nil, uint64(tag))
+
+	return tag, nil
+}
+
+func (u *UnityBridgeImpl) setKeyValue(k *key.Key, value any,
+	c result.Callback) (token.Token, error) {
+	if k.AccessType()&key.AccessTypeWrite == 0 {
+		return 0, fmt.Errorf("key %s is not writable", k)
+	}
+
+	expectedKeyValue := k.ResultValue()
+
+	if reflect.TypeOf(value) != reflect.TypeOf(expectedKeyValue) {
+		return 0, fmt.Errorf("value type %s does not match expected key %s "+
+			"type %s", reflect.TypeOf(value), k,
+			reflect.TypeOf(expectedKeyValue))
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0, err
+	}
+
+	ev := event.NewFromTypeAndSubType(event.TypeSetValue, k.SubType())
+
+	tag := u.tg.Next()
+
+	u.m.Lock()
+
+	u.callbackListener[tag] = c
+
+	u.m.Unlock()
+
+	u.uw.SendEventWithString(ev.Code(), string(data), uint64(tag))
+
+	return tag, nil
+}
+
+func (u *UnityBridgeImpl) performActionForKey(k *key.Key, value any,
+	c result.Callback) (token.Token, error) {
+	if k.AccessType()&key.AccessTypeAction == 0 {
+		return 0, fmt.Errorf("key %s is not an action", k)
+	}
+
+	expectedKeyValue := k.ResultValue()
+	expectedType := reflect.TypeOf(expectedKeyValue)
+	actualType := reflect.TypeOf(value)
+
+	if expectedType == voidType {
+		if value != nil {
+			return 0, fmt.Errorf("key %s is void type but value is not nil", k)
+		}
+	} else if actualType != expectedType {
+		return 0, fmt.Errorf("value type %s does not match expected key %s "+
+			"type %s", actualType, k, expectedType)
+	}
+
+	var data []byte
+
+	if value != nil {
+		var err error
+		data, err = json.Marshal(value)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	ev := event.NewFromTypeAndSubType(event.TypePerformAction, k.SubType())
+
+	tag := u.tg.Next()
+
+	if c != nil {
+		u.m.Lock()
+		u.callbackListener[tag] = c
+		u.m.Unlock()
+	}
+
+	if value != nil {
+		u.uw.SendEventWithString(ev.Code(), string(data), uint64(tag))
+	} else {
+		u.uw.SendEvent(ev.Code(), nil, uint64(tag))
+	}
+
+	return tag, nil
+}
+
+// addKeyDispatcher registers the given dispatcher as a listener for the
+// given key. If immediate is true, any cached value for the key is
+// dispatched before any other results.
+func (u *UnityBridgeImpl) addKeyDispatcher(k *key.Key, d *keyDispatcher,
+	immediate bool) (token.Token, error) {
+	if k.AccessType()&key.AccessTypeRead == 0 {
+		return 0, fmt.Errorf("key %s is not readable", k)
+	}
+
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	if immediate {
+		// Getting the cached value with the lock held guarantees no newer
+		// result is dispatched before it.
+		r, err := u.GetCachedKeyValue(k)
+		if err != nil {
+			return 0, err
+		}
+
+		if r != nil {
+			d.Dispatch(r)
+		}
+	}
+
+	t := u.tg.Next()
+
+	if _, ok := u.keyListeners[k]; !ok {
+		u.keyListeners[k] = make(map[token.Token]*keyDispatcher)
+	}
+
+	if len(u.keyListeners[k]) == 0 {
+		ev := event.NewFromTypeAndSubType(event.TypeStartListening, k.SubType())
+		u.uw.SendEvent(ev.Code(), nil, 0)
+	}
+
+	u.keyListeners[k][t] = d
+
+	return t, nil
+}
+
+// addEventTypeDispatcher registers the given dispatcher as a listener for the
+// given event type.
+func (u *UnityBridgeImpl) addEventTypeDispatcher(et event.Type,
+	d *eventTypeDispatcher) token.Token {
+	t := u.tg.Next()
+
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	if _, ok := u.eventTypeListeners[et]; !ok {
+		u.eventTypeListeners[et] = make(map[token.Token]*eventTypeDispatcher)
+	}
+
+	u.eventTypeListeners[et][t] = d
+
+	return t
+}
+
+// removeCallbackListener removes the callback registered for the given tag
+// (if any). This is used when a synchronous operation is abandoned so late
+// replies do not keep callbacks alive forever.
+func (u *UnityBridgeImpl) removeCallbackListener(tag token.Token) {
+	u.m.Lock()
+	delete(u.callbackListener, tag)
+	u.m.Unlock()
+}
+
+// withDefaultTimeout returns a context derived from the given one that has
+// the default timeout for synchronous operations applied if the given one
+// has no deadline.
+func withDefaultTimeout(ctx context.Context) (context.Context,
+	context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, defaultSyncTimeout)
+}
+
+// contextError returns an error describing why the operation described by
+// the given format and arguments was interrupted by the given context.
+func contextError(ctx context.Context, format string, args ...any) error {
+	prefix := "canceled"
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		prefix = "timeout"
+	}
+
+	return fmt.Errorf("%s %s: %w", prefix, fmt.Sprintf(format, args...),
+		ctx.Err())
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/support/dispatcher"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	wrapper_mock "github.com/brunoga/robomaster/unitybridge/wrapper/mock"
+)
+
+func TestGetKeyValueSyncContext_Canceled(t *testing.T) {
+	uw, ub := setupUnityBridgeImpl(t)
+	defer cleanupUnityBridgeImpl(t, uw, ub)
+
+	// Requests are never replied to.
+	uw.On("SendEvent", mock.Anything, mock.Anything, mock.Anything)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := ub.GetKeyValueSyncContext(ctx, key.KeyRobomasterBatteryPowerPercent,
+		false)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assertNoCallbackListeners(t, ub)
+}
+
+func TestSetKeyValueSyncContext_Deadline(t *testing.T) {
+	uw, ub := setupUnityBridgeImpl(t)
+	defer cleanupUnityBridgeImpl(t, uw, ub)
+
+	uw.On("SendEventWithString", mock.Anything, mock.Anything, mock.Anything)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+
+	err := ub.SetKeyValueSyncContext(ctx, key.KeyRobomasterSystemSpeakerVolumn,
+		&value.Uint64{Value: 10})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	assertNoCallbackListeners(t, ub)
+}
+
+func TestPerformActionForKeySyncContext_Success(t *testing.T) {
+	uw, ub := setupUnityBridgeImpl(t)
+	defer cleanupUnityBridgeImpl(t, uw, ub)
+
+	k := key.KeyGimbalResetPosition
+	ev := event.NewFromTypeAndSubType(event.TypePerformAction, k.SubType())
+
+	uw.On("SendEvent", ev.Code(), []byte(nil), mock.Anything).Run(
+		func(args mock.Arguments) {
+			tag := args.Get(2).(uint64)
+			go uw.GenerateEvent(ev.Code(), []byte(`{"key":`+
+				uint64String(uint64(k.SubType()))+`,"tag":`+uint64String(tag)+
+				`,"error":0,"value":""}`), tag)
+		})
+
+	err := ub.PerformActionForKeySyncContext(context.Background(), k, nil)
+	assert.NoError(t, err)
+
+	assertNoCallbackListeners(t, ub)
+}
+
+func TestSubscribeKey_Order(t *testing.T) {
+	uw, ub := setupUnityBridgeImpl(t)
+	defer cleanupUnityBridgeImpl(t, uw, ub)
+
+	k := key.KeyRobomasterSystemSpeakerVolumn
+	ev := event.NewFromTypeAndSubType(event.TypeStartListening, k.SubType())
+
+	uw.On("SendEvent", mock.Anything, mock.Anything, mock.Anything)
+
+	// Results are not dropped with a blocking policy so all of them must be
+	// delivered in order.
+	c, tk, err := ub.SubscribeKey(k, false, &dispatcher.Options{
+		QueueSize:      16,
+		OverflowPolicy: dispatcher.OverflowPolicyBlock,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, uw.GenerateEvent(ev.Code(), []byte(`{"key":`+
+			uint64String(uint64(k.SubType()))+`,"tag":0,"error":0,"value":`+
+			`{"value":`+uint64String(uint64(i))+`}}`), 0))
+	}
+
+	for i := 0; i < 100; i++ {
+		select {
+		case r := <-c:
+			require.True(t, r.Succeeded())
+			assert.Equal(t, uint64(i), r.Value().(*value.Uint64).Value)
+		case <-time.After(time.Second):
+			t.Fatal("result not delivered")
+		}
+	}
+
+	require.NoError(t, ub.RemoveKeyListener(k, tk))
+
+	select {
+	case _, ok := <-c:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed")
+	}
+}
+
+func setupUnityBridgeImpl(t *testing.T) (*wrapper_mock.UnityBridge,
+	*UnityBridgeImpl) {
+	uw := wrapper_mock.NewUnityBridgeWrapper()
+	ub := NewUnityBridgeImpl(uw, false, nil)
+
+	uw.On("Create", "Robomaster", false, "")
+	uw.On("Initialize").Return(true)
+	uw.On("SetEventCallback", mock.Anything, mock.Anything)
+
+	require.NoError(t, ub.Start())
+
+	uw.ExpectedCalls = nil
+
+	return uw, ub
+}
+
+func cleanupUnityBridgeImpl(t *testing.T, uw *wrapper_mock.UnityBridge,
+	ub *UnityBridgeImpl) {
+	uw.ExpectedCalls = nil
+
+	uw.On("SetEventCallback", mock.Anything, mock.Anything)
+	uw.On("Uninitialize")
+	uw.On("Destroy")
+
+	assert.NoError(t, ub.Stop())
+}
+
+func assertNoCallbackListeners(t *testing.T, ub *UnityBridgeImpl) {
+	ub.m.RLock()
+	defer ub.m.RUnlock()
+
+	assert.Empty(t, ub.callbackListener)
+}
+
+func uint64String(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
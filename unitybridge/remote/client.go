@@ -0,0 +1,845 @@
+package remote
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/dispatcher"
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
+	"github.com/brunoga/robomaster/unitybridge"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+)
+
+// defaultSyncTimeout is the timeout used by synchronous operations when the
+// given context has no deadline (the same one used by local UnityBridges).
+const defaultSyncTimeout = 5 * time.Second
+
+// dialTimeout is how long Start waits for the connection to the server.
+const dialTimeout = 10 * time.Second
+
+// defaultListenerOptions are the dispatcher options used for listeners when
+// none are given (the same ones used by local UnityBridges).
+var defaultListenerOptions = dispatcher.Options{
+	QueueSize:      64,
+	OverflowPolicy: dispatcher.OverflowPolicyDropOldest,
+}
+
+// listenerOptions returns the given options or defaultListenerOptions if o
+// is nil. Results are dispatched from the connection read loop so blocking
+// listeners are rejected as they would stall all replies from the server.
+func listenerOptions(o *dispatcher.Options) (*dispatcher.Options, error) {
+	if o == nil {
+		return &defaultListenerOptions, nil
+	}
+
+	if o.OverflowPolicy == dispatcher.OverflowPolicyBlock {
+		return nil, fmt.Errorf("blocking listeners are not supported by " +
+			"remote clients")
+	}
+
+	return o, nil
+}
+
+// keyListener is a key listener registered with a Client.
+type keyListener struct {
+	k *key.Key
+	d *dispatcher.Dispatcher[*result.Result]
+}
+
+// eventTypeListener is an event type listener registered with a Client.
+type eventTypeListener struct {
+	et event.Type
+	d  *dispatcher.Dispatcher[event.Message]
+}
+
+// Client is a unitybridge.UnityBridge implementation that forwards all
+// operations to a Server. Start connects to the server (it does not start the
+// remote UnityBridge, which is controlled by the Server owner) and Stop
+// disconnects from it.
+//
+// Listener results are dispatched from the connection read loop, so listeners
+// default to dropping the oldest results and dispatcher.OverflowPolicyBlock
+// is not supported.
+type Client struct {
+	addr string
+	l    *logger.Logger
+	tg   *token.Generator
+
+	wm  sync.Mutex
+	enc *gob.Encoder
+
+	m                  sync.Mutex
+	c                  net.Conn
+	pending            map[uint64]chan *message
+	keyListeners       map[token.Token]*keyListener
+	eventTypeListeners map[token.Token]*eventTypeListener
+	done               chan struct{}
+}
+
+var _ unitybridge.UnityBridge = (*Client)(nil)
+
+// NewClient returns a new Client that connects to the Server at the given
+// TCP address.
+func NewClient(addr string, l *logger.Logger) *Client {
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	return &Client{
+		addr:               addr,
+		l:                  l.WithGroup("remote_client"),
+		tg:                 token.NewGenerator(),
+		pending:            make(map[uint64]chan *message),
+		keyListeners:       make(map[token.Token]*keyListener),
+		eventTypeListeners: make(map[token.Token]*eventTypeListener),
+	}
+}
+
+// Start implements unitybridge.UnityBridge. It connects to the server.
+func (c *Client) Start() (err error) {
+	endTrace := c.l.Trace("Start", "addr", c.addr)
+	defer func() {
+		endTrace("error", err)
+	}()
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.c != nil {
+		return fmt.Errorf("client already started")
+	}
+
+	conn, err := net.DialTimeout("tcp", c.addr, dialTimeout)
+	if err != nil {
+		return err
+	}
+
+	c.c = conn
+	c.enc = gob.NewEncoder(conn)
+	c.done = make(chan struct{})
+
+	go c.readLoop(conn, c.done)
+
+	return nil
+}
+
+// Stop implements unitybridge.UnityBridge. It disconnects from the server
+// (which removes all listeners registered through this client).
+func (c *Client) Stop() (err error) {
+	endTrace := c.l.Trace("Stop")
+	defer func() {
+		endTrace("error", err)
+	}()
+
+	c.m.Lock()
+
+	if c.c == nil {
+		c.m.Unlock()
+		return fmt.Errorf("client not started")
+	}
+
+	conn, done := c.c, c.done
+	c.c = nil
+
+	// The server removes all listeners when we disconnect.
+	for t, kl := range c.keyListeners {
+		kl.d.Stop()
+		delete(c.keyListeners, t)
+	}
+
+	for t, etl := range c.eventTypeListeners {
+		etl.d.Stop()
+		delete(c.eventTypeListeners, t)
+	}
+
+	c.m.Unlock()
+
+	err = conn.Close()
+
+	<-done
+
+	return err
+}
+
+// AddKeyListener implements unitybridge.UnityBridge.
+func (c *Client) AddKeyListener(k *key.Key, cb result.Callback,
+	immediate bool) (token.Token, error) {
+	return c.AddKeyListenerWithOptions(k, cb, immediate, nil)
+}
+
+// AddKeyListenerWithOptions implements unitybridge.UnityBridge. The options
+// are used both locally and for the listener created in the server.
+func (c *Client) AddKeyListenerWithOptions(k *key.Key, cb result.Callback,
+	immediate bool, o *dispatcher.Options) (t token.Token, err error) {
+	endTrace := c.l.Trace("AddKeyListenerWithOptions", "key", k, "immediate",
+		immediate, "options", o)
+	defer func() {
+		endTrace("token", t, "error", err)
+	}()
+
+	if cb == nil {
+		return 0, fmt.Errorf("callback cannot be nil")
+	}
+
+	o, err = listenerOptions(o)
+	if err != nil {
+		return 0, err
+	}
+
+	d, err := dispatcher.New(o, func(r *result.Result) {
+		cb(r)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	t, err = c.addKeyListener(k, d, immediate, o)
+	if err != nil {
+		d.Stop()
+		return 0, err
+	}
+
+	return t, nil
+}
+
+// SubscribeKey implements unitybridge.UnityBridge.
+func (c *Client) SubscribeKey(k *key.Key, immediate bool,
+	o *dispatcher.Options) (ch <-chan *result.Result, t token.Token,
+	err error) {
+	endTrace := c.l.Trace("SubscribeKey", "key", k, "immediate", immediate,
+		"options", o)
+	defer func() {
+		endTrace("token", t, "error", err)
+	}()
+
+	o, err = listenerOptions(o)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rc := make(chan *result.Result)
+
+	var d *dispatcher.Dispatcher[*result.Result]
+	d, err = dispatcher.New(o, func(r *result.Result) {
+		select {
+		case rc <- r:
+		case <-d.Stopped():
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	go func() {
+		<-d.Done()
+		close(rc)
+	}()
+
+	t, err = c.addKeyListener(k, d, immediate, o)
+	if err != nil {
+		d.Stop()
+		return nil, 0, err
+	}
+
+	return rc, t, nil
+}
+
+// RemoveKeyListener implements unitybridge.UnityBridge.
+func (c *Client) RemoveKeyListener(k *key.Key, t token.Token) (err error) {
+	endTrace := c.l.Trace("RemoveKeyListener", "key", k, "token", t)
+	defer func() {
+		endTrace("error", err)
+	}()
+
+	c.m.Lock()
+
+	var ts []token.Token
+	for lt, kl := range c.keyListeners {
+		if kl.k == k && (t == 0 || lt == t) {
+			ts = append(ts, lt)
+			kl.d.Stop()
+			delete(c.keyListeners, lt)
+		}
+	}
+
+	c.m.Unlock()
+
+	if len(ts) == 0 {
+		if t == 0 {
+			return fmt.Errorf("no listeners registered for key %s", k)
+		}
+
+		return fmt.Errorf("no listener registered with token %d for key %s", t,
+			k)
+	}
+
+	for _, lt := range ts {
+		_, err = c.request(context.Background(), &message{
+			Op:    opRemoveKeyListener,
+			Token: uint64(lt),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetKeyValue implements unitybridge.UnityBridge.
+func (c *Client) GetKeyValue(k *key.Key, cb result.Callback) error {
+	if k.AccessType()&key.AccessTypeRead == 0 {
+		return fmt.Errorf("key %s is not readable", k)
+	}
+
+	go func() {
+		r, err := c.GetKeyValueSync(k, false)
+		if err != nil {
+			r = requestResult(k, err)
+		}
+
+		if cb != nil {
+			cb(r)
+		}
+	}()
+
+	return nil
+}
+
+// GetKeyValueSync implements unitybridge.UnityBridge.
+func (c *Client) GetKeyValueSync(k *key.Key,
+	useCache bool) (*result.Result, error) {
+	return c.GetKeyValueSyncContext(context.Background(), k, useCache)
+}
+
+// GetKeyValueSyncContext implements unitybridge.UnityBridge.
+func (c *Client) GetKeyValueSyncContext(ctx context.Context, k *key.Key,
+	useCache bool) (r *result.Result, err error) {
+	endTrace := c.l.Trace("GetKeyValueSyncContext", "key", k, "useCache",
+		useCache)
+	defer func() {
+		endTrace("result", r, "error", err)
+	}()
+
+	if k.AccessType()&key.AccessTypeRead == 0 {
+		return nil, fmt.Errorf("key %s is not readable", k)
+	}
+
+	reply, err := c.syncRequest(ctx, &message{
+		Op:       opGetKeyValue,
+		Key:      k.SubType(),
+		UseCache: useCache,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResult(reply.Result), nil
+}
+
+// GetCachedKeyValue implements unitybridge.UnityBridge.
+func (c *Client) GetCachedKeyValue(k *key.Key) (r *result.Result, err error) {
+	endTrace := c.l.Trace("GetCachedKeyValue", "key", k)
+	defer func() {
+		endTrace("result", r, "error", err)
+	}()
+
+	if k.AccessType()&key.AccessTypeRead == 0 {
+		return nil, fmt.Errorf("key %s is not readable", k)
+	}
+
+	reply, err := c.syncRequest(context.Background(), &message{
+		Op:  opGetCachedKeyValue,
+		Key: k.SubType(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResult(reply.Result), nil
+}
+
+// SetKeyValue implements unitybridge.UnityBridge.
+func (c *Client) SetKeyValue(k *key.Key, value any,
+	cb result.Callback) error {
+	if k.AccessType()&key.AccessTypeWrite == 0 {
+		return fmt.Errorf("key %s is not writable", k)
+	}
+
+	data, err := encodeValue(k, value, false)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := c.setKeyValue(context.Background(), k, data)
+		if cb != nil {
+			cb(requestResult(k, err))
+		}
+	}()
+
+	return nil
+}
+
+// SetKeyValueSync implements unitybridge.UnityBridge.
+func (c *Client) SetKeyValueSync(k *key.Key, value any) error {
+	return c.SetKeyValueSyncContext(context.Background(), k, value)
+}
+
+// SetKeyValueSyncContext implements unitybridge.UnityBridge.
+func (c *Client) SetKeyValueSyncContext(ctx context.Context, k *key.Key,
+	value any) (err error) {
+	endTrace := c.l.Trace("SetKeyValueSyncContext", "key", k, "value", value)
+	defer func() {
+		endTrace("error", err)
+	}()
+
+	if k.AccessType()&key.AccessTypeWrite == 0 {
+		return fmt.Errorf("key %s is not writable", k)
+	}
+
+	data, err := encodeValue(k, value, false)
+	if err != nil {
+		return err
+	}
+
+	return c.setKeyValue(ctx, k, data)
+}
+
+// PerformActionForKey implements unitybridge.UnityBridge.
+func (c *Client) PerformActionForKey(k *key.Key, value any,
+	cb result.Callback) error {
+	if k.AccessType()&key.AccessTypeAction == 0 {
+		return fmt.Errorf("key %s is not an action", k)
+	}
+
+	data, err := encodeValue(k, value, true)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := c.performActionForKey(context.Background(), k, data)
+		if cb != nil {
+			cb(requestResult(k, err))
+		}
+	}()
+
+	return nil
+}
+
+// PerformActionForKeySync implements unitybridge.UnityBridge.
+func (c *Client) PerformActionForKeySync(k *key.Key, value any) error {
+	return c.PerformActionForKeySyncContext(context.Background(), k, value)
+}
+
+// PerformActionForKeySyncContext implements unitybridge.UnityBridge.
+func (c *Client) PerformActionForKeySyncContext(ctx context.Context,
+	k *key.Key, value any) (err error) {
+	endTrace := c.l.Trace("PerformActionForKeySyncContext", "key", k,
+		"value", value)
+	defer func() {
+		endTrace("error", err)
+	}()
+
+	if k.AccessType()&key.AccessTypeAction == 0 {
+		return fmt.Errorf("key %s is not an action", k)
+	}
+
+	data, err := encodeValue(k, value, true)
+	if err != nil {
+		return err
+	}
+
+	return c.performActionForKey(ctx, k, data)
+}
+
+// DirectSendKeyValue implements unitybridge.UnityBridge.
+func (c *Client) DirectSendKeyValue(k *key.Key, value uint64) error {
+	_, err := c.request(context.Background(), &message{
+		Op:     opDirectSendKeyValue,
+		Key:    k.SubType(),
+		Number: value,
+	})
+
+	return err
+}
+
+// SendEvent implements unitybridge.UnityBridge.
+func (c *Client) SendEvent(ev *event.Event) error {
+	_, err := c.request(context.Background(), &message{
+		Op:        opSendEvent,
+		EventCode: ev.Code(),
+	})
+
+	return err
+}
+
+// SendEventWithString implements unitybridge.UnityBridge.
+func (c *Client) SendEventWithString(ev *event.Event, data string) error {
+	_, err := c.request(context.Background(), &message{
+		Op:        opSendEventWithString,
+		EventCode: ev.Code(),
+		Data:      []byte(data),
+	})
+
+	return err
+}
+
+// SendEventWithUint64 implements unitybridge.UnityBridge.
+func (c *Client) SendEventWithUint64(ev *event.Event, data uint64) error {
+	_, err := c.request(context.Background(), &message{
+		Op:        opSendEventWithUint64,
+		EventCode: ev.Code(),
+		Number:    data,
+	})
+
+	return err
+}
+
+// AddEventTypeListener implements unitybridge.UnityBridge.
+func (c *Client) AddEventTypeListener(et event.Type,
+	cb event.TypeCallback) (token.Token, error) {
+	return c.AddEventTypeListenerWithOptions(et, cb, nil)
+}
+
+// AddEventTypeListenerWithOptions implements unitybridge.UnityBridge. The
+// options are used both locally and for the listener created in the server.
+func (c *Client) AddEventTypeListenerWithOptions(et event.Type,
+	cb event.TypeCallback, o *dispatcher.Options) (t token.Token, err error) {
+	endTrace := c.l.Trace("AddEventTypeListenerWithOptions", "eventType", et,
+		"options", o)
+	defer func() {
+		endTrace("token", t, "error", err)
+	}()
+
+	if cb == nil {
+		return 0, fmt.Errorf("callback cannot be nil")
+	}
+
+	o, err = listenerOptions(o)
+	if err != nil {
+		return 0, err
+	}
+
+	d, err := dispatcher.New(o, func(m event.Message) {
+		cb(m.Event, m.Data, m.DataType)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	t, err = c.addEventTypeListener(et, d, o)
+	if err != nil {
+		d.Stop()
+		return 0, err
+	}
+
+	return t, nil
+}
+
+// SubscribeEventType implements unitybridge.UnityBridge.
+func (c *Client) SubscribeEventType(et event.Type,
+	o *dispatcher.Options) (ch <-chan event.Message, t token.Token,
+	err error) {
+	endTrace := c.l.Trace("SubscribeEventType", "eventType", et, "options", o)
+	defer func() {
+		endTrace("token", t, "error", err)
+	}()
+
+	o, err = listenerOptions(o)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	mc := make(chan event.Message)
+
+	var d *dispatcher.Dispatcher[event.Message]
+	d, err = dispatcher.New(o, func(m event.Message) {
+		select {
+		case mc <- m:
+		case <-d.Stopped():
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	go func() {
+		<-d.Done()
+		close(mc)
+	}()
+
+	t, err = c.addEventTypeListener(et, d, o)
+	if err != nil {
+		d.Stop()
+		return nil, 0, err
+	}
+
+	return mc, t, nil
+}
+
+// RemoveEventTypeListener implements unitybridge.UnityBridge.
+func (c *Client) RemoveEventTypeListener(et event.Type,
+	t token.Token) (err error) {
+	endTrace := c.l.Trace("RemoveEventTypeListener", "eventType", et, "token",
+		t)
+	defer func() {
+		endTrace("error", err)
+	}()
+
+	c.m.Lock()
+
+	var ts []token.Token
+	for lt, etl := range c.eventTypeListeners {
+		if etl.et == et && (t == 0 || lt == t) {
+			ts = append(ts, lt)
+			etl.d.Stop()
+			delete(c.eventTypeListeners, lt)
+		}
+	}
+
+	c.m.Unlock()
+
+	if len(ts) == 0 {
+		if t == 0 {
+			return fmt.Errorf("no listeners registered for event type %s", et)
+		}
+
+		return fmt.Errorf("no listener registered with token %d for event "+
+			"type %s", t, et)
+	}
+
+	for _, lt := range ts {
+		_, err = c.request(context.Background(), &message{
+			Op:    opRemoveEventTypeListener,
+			Token: uint64(lt),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenderNextFrame implements unitybridge.UnityBridge.
+func (c *Client) RenderNextFrame() {
+	_, err := c.request(context.Background(), &message{
+		Op: opRenderNextFrame,
+	})
+	if err != nil {
+		c.l.Error("Error rendering next frame", "error", err)
+	}
+}
+
+func (c *Client) addKeyListener(k *key.Key,
+	d *dispatcher.Dispatcher[*result.Result], immediate bool,
+	o *dispatcher.Options) (token.Token, error) {
+	if k.AccessType()&key.AccessTypeRead == 0 {
+		return 0, fmt.Errorf("key %s is not readable", k)
+	}
+
+	// Register the listener before the server knows about it so no results
+	// are missed.
+	t := c.tg.Next()
+
+	c.m.Lock()
+	c.keyListeners[t] = &keyListener{k: k, d: d}
+	c.m.Unlock()
+
+	_, err := c.request(context.Background(), &message{
+		Op:        opAddKeyListener,
+		Key:       k.SubType(),
+		Token:     uint64(t),
+		Immediate: immediate,
+		Options:   o,
+	})
+	if err != nil {
+		c.m.Lock()
+		delete(c.keyListeners, t)
+		c.m.Unlock()
+
+		return 0, err
+	}
+
+	return t, nil
+}
+
+func (c *Client) addEventTypeListener(et event.Type,
+	d *dispatcher.Dispatcher[event.Message],
+	o *dispatcher.Options) (token.Token, error) {
+	t := c.tg.Next()
+
+	c.m.Lock()
+	c.eventTypeListeners[t] = &eventTypeListener{et: et, d: d}
+	c.m.Unlock()
+
+	_, err := c.request(context.Background(), &message{
+		Op:        opAddEventTypeListener,
+		EventCode: event.NewFromType(et).Code(),
+		Token:     uint64(t),
+		Options:   o,
+	})
+	if err != nil {
+		c.m.Lock()
+		delete(c.eventTypeListeners, t)
+		c.m.Unlock()
+
+		return 0, err
+	}
+
+	return t, nil
+}
+
+func (c *Client) setKeyValue(ctx context.Context, k *key.Key,
+	data []byte) error {
+	_, err := c.syncRequest(ctx, &message{
+		Op:   opSetKeyValue,
+		Key:  k.SubType(),
+		Data: data,
+	})
+
+	return err
+}
+
+func (c *Client) performActionForKey(ctx context.Context, k *key.Key,
+	data []byte) error {
+	_, err := c.syncRequest(ctx, &message{
+		Op:   opPerformActionForKey,
+		Key:  k.SubType(),
+		Data: data,
+	})
+
+	return err
+}
+
+// syncRequest is like request but applies the default timeout if the given
+// context has no deadline and asks the server to use the same timeout.
+func (c *Client) syncRequest(ctx context.Context,
+	msg *message) (*message, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultSyncTimeout)
+		defer cancel()
+	}
+
+	deadline, _ := ctx.Deadline()
+	msg.Timeout = time.Until(deadline)
+
+	return c.request(ctx, msg)
+}
+
+// request sends the given request to the server and waits for the reply.
+func (c *Client) request(ctx context.Context, msg *message) (*message,
+	error) {
+	rc := make(chan *message, 1)
+
+	c.m.Lock()
+
+	if c.c == nil {
+		c.m.Unlock()
+		return nil, fmt.Errorf("client not started")
+	}
+
+	msg.ID = uint64(c.tg.Next())
+	c.pending[msg.ID] = rc
+	done := c.done
+
+	c.m.Unlock()
+
+	defer func() {
+		c.m.Lock()
+		delete(c.pending, msg.ID)
+		c.m.Unlock()
+	}()
+
+	c.wm.Lock()
+	err := c.enc.Encode(msg)
+	c.wm.Unlock()
+
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+
+	select {
+	case reply := <-rc:
+		if reply.Error != "" {
+			return nil, errors.New(reply.Error)
+		}
+
+		return reply, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for reply: %w", ctx.Err())
+	case <-done:
+		return nil, fmt.Errorf("connection to server closed")
+	}
+}
+
+func (c *Client) readLoop(conn net.Conn, done chan struct{}) {
+	defer close(done)
+
+	dec := gob.NewDecoder(conn)
+
+	for {
+		var msg message
+		if err := dec.Decode(&msg); err != nil {
+			c.l.Debug("Connection to server closed", "error", err)
+			return
+		}
+
+		switch msg.Op {
+		case opReply:
+			c.m.Lock()
+			rc, ok := c.pending[msg.ID]
+			c.m.Unlock()
+
+			if ok {
+				rc <- &msg
+			}
+		case opKeyResult:
+			c.m.Lock()
+			kl, ok := c.keyListeners[token.Token(msg.Token)]
+			c.m.Unlock()
+
+			if ok {
+				kl.d.Dispatch(decodeResult(msg.Result))
+			}
+		case opEvent:
+			c.m.Lock()
+			etl, ok := c.eventTypeListeners[token.Token(msg.Token)]
+			c.m.Unlock()
+
+			if ok {
+				etl.d.Dispatch(event.Message{
+					Event:    event.NewFromCode(msg.EventCode),
+					Data:     msg.Data,
+					DataType: msg.DataType,
+				})
+			}
+		default:
+			c.l.Warn("Unexpected message from server", "op", msg.Op)
+		}
+	}
+}
+
+// requestResult returns the result passed to callbacks of asynchronous
+// requests that failed (error is not nil) or that have no value.
+func requestResult(k *key.Key, err error) *result.Result {
+	errorCode := 0
+	if err != nil {
+		errorCode = -1
+	}
+
+	return result.NewFromJSON([]byte(fmt.Sprintf(
+		`{"key":%d,"tag":0,"error":%d,"value":""}`, k.SubType(), errorCode)))
+}
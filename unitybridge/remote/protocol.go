@@ -0,0 +1,165 @@
+// Package remote allows sharing a UnityBridge over the network.
+//
+// A Server exposes a local UnityBridge (usually running on a machine close to
+// the robot) and any number of Clients, possibly in other processes or
+// machines, connect to it. Client implements unitybridge.UnityBridge so it
+// can be used anywhere a local UnityBridge would.
+//
+// The protocol is a stream of gob encoded messages over a TCP connection.
+// Results are transferred in the same JSON format used by the Unity Bridge
+// library itself.
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/brunoga/robomaster/support/dispatcher"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+)
+
+// op is the operation associated with a message.
+type op uint8
+
+const (
+	// Requests (client to server).
+	opGetKeyValue op = iota + 1
+	opGetCachedKeyValue
+	opSetKeyValue
+	opPerformActionForKey
+	opAddKeyListener
+	opRemoveKeyListener
+	opDirectSendKeyValue
+	opSendEvent
+	opSendEventWithString
+	opSendEventWithUint64
+	opAddEventTypeListener
+	opRemoveEventTypeListener
+	opRenderNextFrame
+
+	// Reply to a request (server to client).
+	opReply
+
+	// Notifications (server to client).
+	opKeyResult
+	opEvent
+)
+
+// message is the single message type exchanged between clients and servers.
+// Only the fields relevant to the specific op are set.
+type message struct {
+	Op op
+
+	// ID identifies a request. Replies have the same ID as the request they
+	// are replying to.
+	ID uint64
+
+	// Key is the key sub-type for key related operations.
+	Key uint32
+
+	// EventCode is the event code for event related operations.
+	EventCode uint64
+
+	// Token is the client chosen token that identifies a listener.
+	Token uint64
+
+	Immediate bool
+	UseCache  bool
+	Options   *dispatcher.Options
+
+	// Timeout is how long the server waits for a synchronous operation to
+	// complete. Zero means the operation is asynchronous.
+	Timeout time.Duration
+
+	Data     []byte
+	Number   uint64
+	DataType event.DataType
+
+	// Result is a JSON encoded result.
+	Result []byte
+
+	// Error is the error message for failed requests.
+	Error string
+}
+
+// wireResult is the JSON representation of a result.
+type wireResult struct {
+	Key   uint32 `json:"key"`
+	Tag   uint64 `json:"tag"`
+	Error int64  `json:"error"`
+	Value any    `json:"value"`
+}
+
+// encodeResult returns the JSON representation of the given result. Results
+// with no value use an empty string as value (as the Unity Bridge does).
+func encodeResult(r *result.Result) ([]byte, error) {
+	if r == nil {
+		return nil, nil
+	}
+
+	var v any = ""
+	if r.Value() != nil {
+		v = r.Value()
+	}
+
+	return json.Marshal(wireResult{
+		Key:   r.Key().SubType(),
+		Tag:   r.Tag(),
+		Error: r.ErrorCode(),
+		Value: v,
+	})
+}
+
+// decodeResult returns the result encoded in the given JSON data. Nil or
+// empty data means no result.
+func decodeResult(data []byte) *result.Result {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return result.NewFromJSON(data)
+}
+
+// encodeValue returns the JSON representation of a value to be set or used
+// as an action parameter for the given key. It validates the value type as a
+// local UnityBridge would.
+func encodeValue(k *key.Key, v any, allowNil bool) ([]byte, error) {
+	if v == nil && allowNil {
+		return nil, nil
+	}
+
+	if !k.HasResultValue() {
+		return json.Marshal(v)
+	}
+
+	expected := reflect.TypeOf(k.ResultValue())
+	if reflect.TypeOf(v) != expected {
+		return nil, fmt.Errorf("value type %s does not match expected key %s "+
+			"type %s", reflect.TypeOf(v), k, expected)
+	}
+
+	return json.Marshal(v)
+}
+
+// decodeValue returns the value for the given key encoded in the given JSON
+// data. Nil or empty data means a nil value.
+func decodeValue(k *key.Key, data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	if !k.HasResultValue() {
+		return nil, fmt.Errorf("key %s has an unknown value type", k)
+	}
+
+	v := k.ResultValue()
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
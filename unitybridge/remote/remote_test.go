@@ -0,0 +1,160 @@
+package remote_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/support/dispatcher"
+	"github.com/brunoga/robomaster/unitybridge"
+	"github.com/brunoga/robomaster/unitybridge/remote"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientServer(t *testing.T) {
+	ub := unitybridge.Get(simulator.NewUnityBridgeWrapper(nil), false, nil)
+	require.NoError(t, ub.Start())
+	defer func() {
+		assert.NoError(t, ub.Stop())
+	}()
+
+	s := remote.NewServer(ub, nil)
+	require.NoError(t, s.Start("127.0.0.1:0"))
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	c1 := startClient(t, s)
+	c2 := startClient(t, s)
+
+	k := key.KeyRobomasterSystemSpeakerVolumn
+
+	// Values set by one client are seen by the other.
+	vc, tk, err := c2.SubscribeKey(k, false, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, c1.SetKeyValueSync(k, &value.Uint64{Value: 33}))
+
+	r, err := c2.GetKeyValueSync(k, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(33), r.Value().(*value.Uint64).Value)
+
+	select {
+	case r := <-vc:
+		assert.Equal(t, uint64(33), r.Value().(*value.Uint64).Value)
+	case <-time.After(time.Second):
+		t.Fatal("subscription did not get value")
+	}
+
+	require.NoError(t, c2.RemoveKeyListener(k, tk))
+
+	// Validation happens locally.
+	assert.Error(t, c1.SetKeyValueSync(k, &value.Bool{Value: true}))
+	assert.Error(t, c1.SetKeyValueSync(key.KeyCameraIsRecording,
+		&value.Bool{Value: true}))
+
+	// Open the (simulated) connection to the robot.
+	require.NoError(t, c1.SendEvent(event.NewFromTypeAndSubType(
+		event.TypeConnection, 0)))
+
+	// Actions.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, c1.PerformActionForKeySyncContext(ctx,
+		key.KeyCameraStartRecordVideo, nil))
+
+	// Video frames.
+	frames, ftk, err := c1.SubscribeEventType(event.TypeVideoDataRecv,
+		&dispatcher.Options{
+			QueueSize:      1,
+			OverflowPolicy: dispatcher.OverflowPolicyCoalesceLatest,
+		})
+	require.NoError(t, err)
+
+	require.NoError(t, c1.SendEvent(event.NewFromType(event.TypeStartVideo)))
+
+	select {
+	case m := <-frames:
+		assert.Equal(t, event.TypeVideoDataRecv, m.Event.Type())
+		assert.Len(t, m.Data, 1280*720*3)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no video frame received")
+	}
+
+	require.NoError(t, c1.SendEvent(event.NewFromType(event.TypeStopVideo)))
+	require.NoError(t, c1.RemoveEventTypeListener(event.TypeVideoDataRecv,
+		ftk))
+}
+
+func TestClient_NotStarted(t *testing.T) {
+	c := remote.NewClient("127.0.0.1:1", nil)
+
+	_, err := c.GetKeyValueSync(key.KeyRobomasterSystemSpeakerVolumn, false)
+	assert.Error(t, err)
+
+	assert.Error(t, c.Stop())
+}
+
+func TestClient_SlowListener(t *testing.T) {
+	ub := unitybridge.Get(simulator.NewUnityBridgeWrapper(nil), false, nil)
+	require.NoError(t, ub.Start())
+	defer func() {
+		assert.NoError(t, ub.Stop())
+	}()
+
+	s := remote.NewServer(ub, nil)
+	require.NoError(t, s.Start("127.0.0.1:0"))
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	c := startClient(t, s)
+
+	k := key.KeyRobomasterSystemSpeakerVolumn
+
+	_, _, err := c.SubscribeKey(k, false, &dispatcher.Options{
+		OverflowPolicy: dispatcher.OverflowPolicyBlock,
+	})
+	assert.Error(t, err)
+
+	// Never read from the channel. Results must be dropped instead of
+	// stalling replies to other requests.
+	_, tk, err := c.SubscribeKey(k, false, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, c.SetKeyValueSync(k,
+			&value.Uint64{Value: uint64(i % 100)}))
+	}
+
+	require.NoError(t, c.RemoveKeyListener(k, tk))
+}
+
+func startClient(t *testing.T, s *remote.Server) *remote.Client {
+	c := remote.NewClient(s.Addr().String(), nil)
+	require.NoError(t, c.Start())
+
+	t.Cleanup(func() {
+		assert.NoError(t, c.Stop())
+	})
+
+	return c
+}
+
+func TestServerDefaultsToLoopback(t *testing.T) {
+	s := remote.NewServer(nil, nil)
+	require.NoError(t, s.Start(":0"))
+	defer func() {
+		assert.NoError(t, s.Stop())
+	}()
+
+	addr, ok := s.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+	assert.True(t, addr.IP.IsLoopback())
+}
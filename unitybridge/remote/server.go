@@ -0,0 +1,541 @@
+package remote
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+
+	"github.com/brunoga/robomaster/support/dispatcher"
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/support/token"
+	"github.com/brunoga/robomaster/unitybridge"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+)
+
+const (
+	// sendQueueSize is the maximum number of messages waiting to be sent to
+	// a client. Clients that fall further behind are disconnected.
+	sendQueueSize = 256
+
+	// maxListenerQueueSize is the maximum listener queue size a client can
+	// request.
+	maxListenerQueueSize = 1024
+)
+
+// Server exposes a UnityBridge to remote Clients. The UnityBridge must be
+// started (and stopped) by the Server owner. Listeners added by a Client are
+// removed when it disconnects.
+//
+// There is no authentication or encryption: any client that can reach the
+// listening address has full control of the exposed UnityBridge (and so of
+// the robot). Only listen on trusted networks.
+type Server struct {
+	ub unitybridge.UnityBridge
+	l  *logger.Logger
+
+	m     sync.Mutex
+	ln    net.Listener
+	conns map[*serverConn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewServer returns a new Server that exposes the given UnityBridge.
+func NewServer(ub unitybridge.UnityBridge, l *logger.Logger) *Server {
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	return &Server{
+		ub:    ub,
+		l:     l.WithGroup("remote_server"),
+		conns: make(map[*serverConn]struct{}),
+	}
+}
+
+// Start starts listening for clients at the given TCP address (for example,
+// "127.0.0.1:0" to listen on a random loopback port). If the address has no
+// host (for example, ":0"), the server only listens on the loopback
+// interface. Listening on other interfaces must be explicitly requested (for
+// example, "0.0.0.0:0").
+func (s *Server) Start(addr string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.ln != nil {
+		return fmt.Errorf("server already started")
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	if host == "" {
+		addr = net.JoinHostPort("127.0.0.1", port)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.ln = ln
+
+	s.wg.Add(1)
+	go s.acceptLoop(ln)
+
+	return nil
+}
+
+// Addr returns the address the server is listening at or nil if it is not
+// started.
+func (s *Server) Addr() net.Addr {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.ln == nil {
+		return nil
+	}
+
+	return s.ln.Addr()
+}
+
+// Stop stops listening for clients and disconnects all connected clients.
+func (s *Server) Stop() error {
+	s.m.Lock()
+
+	if s.ln == nil {
+		s.m.Unlock()
+		return fmt.Errorf("server not started")
+	}
+
+	err := s.ln.Close()
+	s.ln = nil
+
+	for sc := range s.conns {
+		sc.c.Close()
+	}
+
+	s.m.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+func (s *Server) acceptLoop(ln net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			s.l.Debug("Stopped accepting connections", "error", err)
+			return
+		}
+
+		sc := newServerConn(s, c)
+
+		s.m.Lock()
+		s.conns[sc] = struct{}{}
+		s.m.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+
+			sc.serve()
+
+			s.m.Lock()
+			delete(s.conns, sc)
+			s.m.Unlock()
+		}()
+	}
+}
+
+// listenerID identifies a listener added by a client.
+type listenerID struct {
+	k  *key.Key
+	et event.Type
+	t  token.Token
+}
+
+// serverConn is a connection from a single client.
+type serverConn struct {
+	s *Server
+	c net.Conn
+	l *logger.Logger
+
+	out    chan *message
+	closed chan struct{}
+	once   sync.Once
+
+	m sync.Mutex
+	// Maps client tokens to tokens in the exposed UnityBridge.
+	keyListeners       map[token.Token]listenerID
+	eventTypeListeners map[token.Token]listenerID
+
+	wg sync.WaitGroup
+}
+
+func newServerConn(s *Server, c net.Conn) *serverConn {
+	return &serverConn{
+		s:                  s,
+		c:                  c,
+		l:                  s.l.With("client", c.RemoteAddr().String()),
+		out:                make(chan *message, sendQueueSize),
+		closed:             make(chan struct{}),
+		keyListeners:       make(map[token.Token]listenerID),
+		eventTypeListeners: make(map[token.Token]listenerID),
+	}
+}
+
+func (sc *serverConn) serve() {
+	sc.l.Debug("Client connected")
+
+	sc.wg.Add(1)
+	go func() {
+		defer sc.wg.Done()
+		sc.writeLoop()
+	}()
+
+	dec := gob.NewDecoder(sc.c)
+
+	for {
+		var msg message
+		if err := dec.Decode(&msg); err != nil {
+			sc.l.Debug("Client disconnected", "error", err)
+			break
+		}
+
+		// Requests might block for a while so each one is handled in its own
+		// goroutine. Listener management is done inline so listener operations
+		// are applied in the order they were sent.
+		switch msg.Op {
+		case opAddKeyListener, opRemoveKeyListener, opAddEventTypeListener,
+			opRemoveEventTypeListener:
+			sc.handle(&msg)
+		default:
+			sc.wg.Add(1)
+			go func() {
+				defer sc.wg.Done()
+				sc.handle(&msg)
+			}()
+		}
+	}
+
+	sc.close()
+
+	sc.wg.Wait()
+
+	sc.removeListeners()
+}
+
+// close closes the connection and stops the write loop. It is safe to call it
+// more than once.
+func (sc *serverConn) close() {
+	sc.once.Do(func() {
+		close(sc.closed)
+		sc.c.Close()
+	})
+}
+
+// writeLoop sends queued messages to the client until the connection is
+// closed.
+func (sc *serverConn) writeLoop() {
+	enc := gob.NewEncoder(sc.c)
+
+	for {
+		select {
+		case msg := <-sc.out:
+			if err := enc.Encode(msg); err != nil {
+				sc.l.Debug("Error sending message", "op", msg.Op, "error",
+					err)
+				sc.close()
+				return
+			}
+		case <-sc.closed:
+			return
+		}
+	}
+}
+
+func (sc *serverConn) handle(msg *message) {
+	reply := &message{
+		Op: opReply,
+		ID: msg.ID,
+	}
+
+	var err error
+
+	switch msg.Op {
+	case opGetKeyValue:
+		err = sc.getKeyValue(msg, reply)
+	case opGetCachedKeyValue:
+		err = sc.getCachedKeyValue(msg, reply)
+	case opSetKeyValue:
+		err = sc.setKeyValue(msg, reply)
+	case opPerformActionForKey:
+		err = sc.performActionForKey(msg, reply)
+	case opAddKeyListener:
+		err = sc.addKeyListener(msg)
+	case opRemoveKeyListener:
+		err = sc.removeKeyListener(msg)
+	case opDirectSendKeyValue:
+		var k *key.Key
+		k, err = key.FromSubType(msg.Key)
+		if err == nil {
+			err = sc.s.ub.DirectSendKeyValue(k, msg.Number)
+		}
+	case opSendEvent:
+		err = sc.s.ub.SendEvent(event.NewFromCode(msg.EventCode))
+	case opSendEventWithString:
+		err = sc.s.ub.SendEventWithString(event.NewFromCode(msg.EventCode),
+			string(msg.Data))
+	case opSendEventWithUint64:
+		err = sc.s.ub.SendEventWithUint64(event.NewFromCode(msg.EventCode),
+			msg.Number)
+	case opAddEventTypeListener:
+		err = sc.addEventTypeListener(msg)
+	case opRemoveEventTypeListener:
+		err = sc.removeEventTypeListener(msg)
+	case opRenderNextFrame:
+		sc.s.ub.RenderNextFrame()
+	default:
+		err = fmt.Errorf("unknown operation: %d", msg.Op)
+	}
+
+	if err != nil {
+		reply.Error = err.Error()
+	}
+
+	sc.send(reply)
+}
+
+// withTimeout returns a context with the timeout requested in the given
+// message.
+func withTimeout(msg *message) (context.Context, context.CancelFunc) {
+	if msg.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	return context.WithTimeout(context.Background(), msg.Timeout)
+}
+
+func (sc *serverConn) getKeyValue(msg, reply *message) error {
+	k, err := key.FromSubType(msg.Key)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(msg)
+	defer cancel()
+
+	r, err := sc.s.ub.GetKeyValueSyncContext(ctx, k, msg.UseCache)
+	if err != nil {
+		return err
+	}
+
+	reply.Result, err = encodeResult(r)
+
+	return err
+}
+
+func (sc *serverConn) getCachedKeyValue(msg, reply *message) error {
+	k, err := key.FromSubType(msg.Key)
+	if err != nil {
+		return err
+	}
+
+	r, err := sc.s.ub.GetCachedKeyValue(k)
+	if err != nil {
+		return err
+	}
+
+	reply.Result, err = encodeResult(r)
+
+	return err
+}
+
+func (sc *serverConn) setKeyValue(msg, reply *message) error {
+	k, err := key.FromSubType(msg.Key)
+	if err != nil {
+		return err
+	}
+
+	v, err := decodeValue(k, msg.Data)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(msg)
+	defer cancel()
+
+	return sc.s.ub.SetKeyValueSyncContext(ctx, k, v)
+}
+
+func (sc *serverConn) performActionForKey(msg, reply *message) error {
+	k, err := key.FromSubType(msg.Key)
+	if err != nil {
+		return err
+	}
+
+	v, err := decodeValue(k, msg.Data)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(msg)
+	defer cancel()
+
+	return sc.s.ub.PerformActionForKeySyncContext(ctx, k, v)
+}
+
+func (sc *serverConn) addKeyListener(msg *message) error {
+	k, err := key.FromSubType(msg.Key)
+	if err != nil {
+		return err
+	}
+
+	clientToken := msg.Token
+
+	t, err := sc.s.ub.AddKeyListenerWithOptions(k, func(r *result.Result) {
+		data, err := encodeResult(r)
+		if err != nil {
+			sc.l.Error("Error encoding result", "result", r, "error", err)
+			return
+		}
+
+		sc.send(&message{
+			Op:     opKeyResult,
+			Token:  clientToken,
+			Result: data,
+		})
+	}, msg.Immediate, clampOptions(msg.Options))
+	if err != nil {
+		return err
+	}
+
+	sc.m.Lock()
+	sc.keyListeners[token.Token(clientToken)] = listenerID{k: k, t: t}
+	sc.m.Unlock()
+
+	return nil
+}
+
+func (sc *serverConn) removeKeyListener(msg *message) error {
+	sc.m.Lock()
+	id, ok := sc.keyListeners[token.Token(msg.Token)]
+	delete(sc.keyListeners, token.Token(msg.Token))
+	sc.m.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no listener registered with token %d", msg.Token)
+	}
+
+	return sc.s.ub.RemoveKeyListener(id.k, id.t)
+}
+
+func (sc *serverConn) addEventTypeListener(msg *message) error {
+	et := event.NewFromCode(msg.EventCode).Type()
+	clientToken := msg.Token
+
+	t, err := sc.s.ub.AddEventTypeListenerWithOptions(et,
+		func(e *event.Event, data []byte, dataType event.DataType) {
+			sc.send(&message{
+				Op:        opEvent,
+				Token:     clientToken,
+				EventCode: e.Code(),
+				Data:      data,
+				DataType:  dataType,
+			})
+		}, clampOptions(msg.Options))
+	if err != nil {
+		return err
+	}
+
+	sc.m.Lock()
+	sc.eventTypeListeners[token.Token(clientToken)] = listenerID{et: et, t: t}
+	sc.m.Unlock()
+
+	return nil
+}
+
+func (sc *serverConn) removeEventTypeListener(msg *message) error {
+	sc.m.Lock()
+	id, ok := sc.eventTypeListeners[token.Token(msg.Token)]
+	delete(sc.eventTypeListeners, token.Token(msg.Token))
+	sc.m.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no listener registered with token %d", msg.Token)
+	}
+
+	return sc.s.ub.RemoveEventTypeListener(id.et, id.t)
+}
+
+// removeListeners removes all listeners added by the client.
+func (sc *serverConn) removeListeners() {
+	sc.m.Lock()
+	defer sc.m.Unlock()
+
+	for ct, id := range sc.keyListeners {
+		if err := sc.s.ub.RemoveKeyListener(id.k, id.t); err != nil {
+			sc.l.Warn("Error removing key listener", "key", id.k, "error", err)
+		}
+
+		delete(sc.keyListeners, ct)
+	}
+
+	for ct, id := range sc.eventTypeListeners {
+		if err := sc.s.ub.RemoveEventTypeListener(id.et, id.t); err != nil {
+			sc.l.Warn("Error removing event type listener", "eventType", id.et,
+				"error", err)
+		}
+
+		delete(sc.eventTypeListeners, ct)
+	}
+}
+
+// send queues the given message to be sent to the client. It never blocks:
+// if the client is not keeping up and the queue is full, it is disconnected
+// (which also causes the connection read loop to end).
+func (sc *serverConn) send(msg *message) {
+	select {
+	case <-sc.closed:
+		return
+	default:
+	}
+
+	select {
+	case sc.out <- msg:
+	default:
+		sc.l.Warn("Client fell behind, disconnecting", "op", msg.Op)
+		sc.close()
+	}
+}
+
+// clampOptions returns listener options requested by a client adjusted so
+// they can not affect the exposed UnityBridge: the queue size is limited to
+// maxListenerQueueSize and listeners never block.
+func clampOptions(o *dispatcher.Options) *dispatcher.Options {
+	if o == nil {
+		return nil
+	}
+
+	clamped := *o
+
+	if clamped.QueueSize < 0 || clamped.QueueSize > maxListenerQueueSize {
+		clamped.QueueSize = maxListenerQueueSize
+	}
+
+	if clamped.OverflowPolicy != dispatcher.OverflowPolicyCoalesceLatest {
+		clamped.OverflowPolicy = dispatcher.OverflowPolicyDropOldest
+	}
+
+	return &clamped
+}
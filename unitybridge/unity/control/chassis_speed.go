@@ -0,0 +1,110 @@
+package control
+
+import (
+	"fmt"
+	"math"
+)
+
+// Chassis speed limits.
+const (
+	ChassisMaxSpeed    = 3.5   // m/s.
+	ChassisMaxYawSpeed = 360.0 // Degrees/s.
+)
+
+// Chassis speed control word layout. Speeds are sent with 0.1 resolution and
+// offset so they are always positive.
+//
+//	bit  0     : enabled (0 stops movement)
+//	bit  1     : unused
+//	bits 2-8   : x * 10 + 35
+//	bits 9-15  : y * 10 + 35
+//	bits 16-28 : z * 10 + 3600
+const (
+	chassisSpeedEnabledBit = 0
+
+	chassisSpeedXShift = 2
+	chassisSpeedYShift = 9
+	chassisSpeedZShift = 16
+
+	chassisSpeedXYMask = 0x7f
+	chassisSpeedZMask  = 0x1fff
+
+	chassisSpeedXYOffset = 35
+	chassisSpeedZOffset  = 3600
+)
+
+// ChassisSpeed is the control word sent with the
+// KeyMainControllerChassisSpeedMode and KeyMainControllerChassisFollowMode
+// keys.
+type ChassisSpeed struct {
+	Enabled bool
+	X       float64 // Forward, m/s ([-3.5, 3.5]).
+	Y       float64 // Right, m/s ([-3.5, 3.5]).
+	Z       float64 // Yaw, degrees/s ([-360, 360]).
+}
+
+// Validate returns an error if any of the ChassisSpeed fields is out of range.
+func (c ChassisSpeed) Validate() error {
+	if c.X < -ChassisMaxSpeed || c.X > ChassisMaxSpeed {
+		return fmt.Errorf("invalid x speed %f, should be between %.1f and "+
+			"%.1f m/s", c.X, -ChassisMaxSpeed, ChassisMaxSpeed)
+	}
+
+	if c.Y < -ChassisMaxSpeed || c.Y > ChassisMaxSpeed {
+		return fmt.Errorf("invalid y speed %f, should be between %.1f and "+
+			"%.1f m/s", c.Y, -ChassisMaxSpeed, ChassisMaxSpeed)
+	}
+
+	if c.Z < -ChassisMaxYawSpeed || c.Z > ChassisMaxYawSpeed {
+		return fmt.Errorf("invalid z speed %f, should be between %.0f and "+
+			"%.0f degrees/s", c.Z, -ChassisMaxYawSpeed, ChassisMaxYawSpeed)
+	}
+
+	return nil
+}
+
+// Encode returns the packed control word for the ChassisSpeed. Out of range
+// fields are clamped (use Validate to check for them) and fields are truncated
+// (towards zero) to the 0.1 resolution.
+func (c ChassisSpeed) Encode() uint64 {
+	x := clamp(c.X, -ChassisMaxSpeed, ChassisMaxSpeed)
+	y := clamp(c.Y, -ChassisMaxSpeed, ChassisMaxSpeed)
+	z := clamp(c.Z, -ChassisMaxYawSpeed, ChassisMaxYawSpeed)
+
+	var v uint64
+	if c.Enabled {
+		v |= 1 << chassisSpeedEnabledBit
+	}
+
+	v |= uint64(int64(x*10)+chassisSpeedXYOffset) << chassisSpeedXShift
+	v |= uint64(int64(y*10)+chassisSpeedXYOffset) << chassisSpeedYShift
+	v |= uint64(int64(z*10)+chassisSpeedZOffset) << chassisSpeedZShift
+
+	return v
+}
+
+// Decode sets the ChassisSpeed fields from the given packed control word. It
+// returns an error if the decoded fields are out of range.
+func (c *ChassisSpeed) Decode(v uint64) error {
+	d := ChassisSpeed{
+		Enabled: v&(1<<chassisSpeedEnabledBit) != 0,
+		X: float64(int64((v>>chassisSpeedXShift)&chassisSpeedXYMask)-
+			chassisSpeedXYOffset) / 10,
+		Y: float64(int64((v>>chassisSpeedYShift)&chassisSpeedXYMask)-
+			chassisSpeedXYOffset) / 10,
+		Z: float64(int64((v>>chassisSpeedZShift)&chassisSpeedZMask)-
+			chassisSpeedZOffset) / 10,
+	}
+
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	*c = d
+
+	return nil
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
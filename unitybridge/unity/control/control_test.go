@@ -0,0 +1,122 @@
+package control
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChassisSpeedStopped(t *testing.T) {
+	// Values historically used to enable/disable chassis movement.
+	assert.Equal(t, uint64(1|140|17920|235929600),
+		ChassisSpeed{Enabled: true}.Encode())
+	assert.Equal(t, uint64(0|140|17920|235929600),
+		ChassisSpeed{}.Encode())
+}
+
+func TestChassisSpeedRoundTrip(t *testing.T) {
+	for _, c := range []ChassisSpeed{
+		{},
+		{Enabled: true},
+		{Enabled: true, X: 0.3, Y: -0.7, Z: 12.5},
+		{Enabled: true, X: ChassisMaxSpeed, Y: ChassisMaxSpeed,
+			Z: ChassisMaxYawSpeed},
+		{Enabled: true, X: -ChassisMaxSpeed, Y: -ChassisMaxSpeed,
+			Z: -ChassisMaxYawSpeed},
+	} {
+		require.NoError(t, c.Validate())
+
+		var d ChassisSpeed
+		require.NoError(t, d.Decode(c.Encode()))
+
+		assert.Equal(t, c.Enabled, d.Enabled)
+		assert.InDelta(t, c.X, d.X, 1e-9)
+		assert.InDelta(t, c.Y, d.Y, 1e-9)
+		assert.InDelta(t, c.Z, d.Z, 1e-9)
+	}
+}
+
+func TestChassisSpeedTruncation(t *testing.T) {
+	var d ChassisSpeed
+	require.NoError(t, d.Decode(ChassisSpeed{X: 0.39, Y: -0.39,
+		Z: -12.59}.Encode()))
+	assert.InDelta(t, 0.3, d.X, 1e-9)
+	assert.InDelta(t, -0.3, d.Y, 1e-9)
+	assert.InDelta(t, -12.5, d.Z, 1e-9)
+}
+
+func TestChassisSpeedValidation(t *testing.T) {
+	assert.Error(t, ChassisSpeed{X: 3.6}.Validate())
+	assert.Error(t, ChassisSpeed{Y: -3.6}.Validate())
+	assert.Error(t, ChassisSpeed{Z: 361}.Validate())
+
+	// Out of range values are clamped when encoding.
+	var d ChassisSpeed
+	require.NoError(t, d.Decode(ChassisSpeed{X: 10, Z: -1000}.Encode()))
+	assert.Equal(t, ChassisMaxSpeed, d.X)
+	assert.Equal(t, -ChassisMaxYawSpeed, d.Z)
+
+	// X field with a value above 70 (3.5 m/s).
+	d = ChassisSpeed{X: 1}
+	assert.Error(t, d.Decode(0x7f<<2))
+	assert.Equal(t, 1.0, d.X)
+}
+
+func TestVirtualStickRoundTrip(t *testing.T) {
+	for _, v := range []VirtualStick{
+		{
+			Chassis: Stick{X: StickCenterValue, Y: StickCenterValue},
+			Gimbal:  Stick{X: StickCenterValue, Y: StickCenterValue},
+		},
+		{
+			Chassis:        Stick{X: StickMinValue, Y: StickMaxValue},
+			Gimbal:         Stick{X: StickMaxValue, Y: StickMinValue},
+			ChassisEnabled: true,
+			GimbalEnabled:  true,
+			Mode:           1,
+		},
+		{
+			Chassis:        Stick{X: 1354, Y: 700},
+			ChassisEnabled: true,
+		},
+	} {
+		require.NoError(t, v.Validate())
+
+		w := v.Encode()
+		assert.Zero(t, w>>47)
+
+		var d VirtualStick
+		require.NoError(t, d.Decode(w))
+		assert.Equal(t, v, d)
+	}
+}
+
+func TestVirtualStickValidation(t *testing.T) {
+	assert.Error(t, VirtualStick{
+		Chassis:        Stick{X: StickMaxValue + 1, Y: StickCenterValue},
+		ChassisEnabled: true,
+	}.Validate())
+	assert.Error(t, VirtualStick{
+		Gimbal:        Stick{X: StickCenterValue, Y: StickMinValue - 1},
+		GimbalEnabled: true,
+	}.Validate())
+	assert.Error(t, VirtualStick{Mode: 2}.Validate())
+
+	// Disabled sticks are not validated.
+	assert.NoError(t, VirtualStick{}.Validate())
+
+	var d VirtualStick
+	assert.Error(t, d.Decode(1<<44))
+}
+
+func TestStickValues(t *testing.T) {
+	assert.Equal(t, uint16(StickCenterValue), RawStickValue(0))
+	assert.Equal(t, uint16(StickMinValue), RawStickValue(-2))
+	assert.Equal(t, uint16(StickMaxValue), RawStickValue(1))
+
+	assert.Equal(t, 0.0, NormalizedStickValue(StickCenterValue))
+	assert.Equal(t, -1.0, NormalizedStickValue(0))
+	assert.Equal(t, 1.0, NormalizedStickValue(StickMaxValue))
+	assert.InDelta(t, 0.5, NormalizedStickValue(RawStickValue(0.5)), 1e-3)
+}
@@ -0,0 +1,5 @@
+// Package control provides types for the packed control words that are sent
+// directly (see UnityBridge.DirectSendKeyValue) to the robot. Each type
+// documents its wire layout and can be encoded to and decoded from the uint64
+// value that is actually sent.
+package control
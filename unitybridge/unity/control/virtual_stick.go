@@ -0,0 +1,153 @@
+package control
+
+import (
+	"fmt"
+	"math"
+)
+
+// Raw stick values. The center value is the neutral position.
+const (
+	StickMinValue    = 364
+	StickCenterValue = 1024
+	StickMaxValue    = 1684
+)
+
+// Virtual stick control word layout.
+//
+//	bits  0-10 : chassis stick y
+//	bits 11-21 : chassis stick x
+//	bits 22-32 : gimbal stick y
+//	bits 33-43 : gimbal stick x
+//	bit  44    : chassis stick enabled
+//	bit  45    : gimbal stick enabled
+//	bit  46    : mode
+const (
+	virtualStickChassisYShift       = 0
+	virtualStickChassisXShift       = 11
+	virtualStickGimbalYShift        = 22
+	virtualStickGimbalXShift        = 33
+	virtualStickChassisEnabledShift = 44
+	virtualStickGimbalEnabledShift  = 45
+	virtualStickModeShift           = 46
+
+	virtualStickValueMask = 0x7ff
+	virtualStickModeMask  = 0x1
+)
+
+// Stick is the raw position of a stick. Values are in the [StickMinValue,
+// StickMaxValue] range.
+type Stick struct {
+	X uint16
+	Y uint16
+}
+
+// Validate returns an error if any of the Stick fields is out of range.
+func (s Stick) Validate() error {
+	if s.X < StickMinValue || s.X > StickMaxValue {
+		return fmt.Errorf("invalid stick x value %d, should be between %d "+
+			"and %d", s.X, StickMinValue, StickMaxValue)
+	}
+
+	if s.Y < StickMinValue || s.Y > StickMaxValue {
+		return fmt.Errorf("invalid stick y value %d, should be between %d "+
+			"and %d", s.Y, StickMinValue, StickMaxValue)
+	}
+
+	return nil
+}
+
+// NormalizedStickValue returns the normalized ([-1, 1]) value for the given
+// raw stick value. Out of range values are clamped.
+func NormalizedStickValue(v uint16) float64 {
+	c := clamp(float64(v), StickMinValue, StickMaxValue)
+
+	return (c - StickCenterValue) / (StickMaxValue - StickCenterValue)
+}
+
+// RawStickValue returns the raw stick value for the given normalized ([-1, 1])
+// value. Out of range values are clamped.
+func RawStickValue(v float64) uint16 {
+	c := clamp(v, -1, 1)
+
+	return uint16(math.Round(StickCenterValue +
+		c*(StickMaxValue-StickCenterValue)))
+}
+
+// VirtualStick is the control word sent with the
+// KeyMainControllerVirtualStick key. Positions for disabled sticks are still
+// sent but are ignored by the robot.
+type VirtualStick struct {
+	Chassis        Stick
+	Gimbal         Stick
+	ChassisEnabled bool
+	GimbalEnabled  bool
+	Mode           uint8 // Controller mode (0 is FPV and 1 is SDK).
+}
+
+// Validate returns an error if any of the VirtualStick fields is out of
+// range. Positions of disabled sticks are not checked.
+func (v VirtualStick) Validate() error {
+	if v.ChassisEnabled {
+		if err := v.Chassis.Validate(); err != nil {
+			return fmt.Errorf("chassis: %w", err)
+		}
+	}
+
+	if v.GimbalEnabled {
+		if err := v.Gimbal.Validate(); err != nil {
+			return fmt.Errorf("gimbal: %w", err)
+		}
+	}
+
+	if v.Mode > virtualStickModeMask {
+		return fmt.Errorf("invalid mode %d", v.Mode)
+	}
+
+	return nil
+}
+
+// Encode returns the packed control word for the VirtualStick. Out of range
+// fields are truncated to their bit width (use Validate to check for them).
+func (v VirtualStick) Encode() uint64 {
+	w := uint64(v.Chassis.Y&virtualStickValueMask)<<virtualStickChassisYShift |
+		uint64(v.Chassis.X&virtualStickValueMask)<<virtualStickChassisXShift |
+		uint64(v.Gimbal.Y&virtualStickValueMask)<<virtualStickGimbalYShift |
+		uint64(v.Gimbal.X&virtualStickValueMask)<<virtualStickGimbalXShift |
+		uint64(v.Mode&virtualStickModeMask)<<virtualStickModeShift
+
+	if v.ChassisEnabled {
+		w |= 1 << virtualStickChassisEnabledShift
+	}
+
+	if v.GimbalEnabled {
+		w |= 1 << virtualStickGimbalEnabledShift
+	}
+
+	return w
+}
+
+// Decode sets the VirtualStick fields from the given packed control word. It
+// returns an error if the decoded fields are out of range.
+func (v *VirtualStick) Decode(w uint64) error {
+	d := VirtualStick{
+		Chassis: Stick{
+			X: uint16((w >> virtualStickChassisXShift) & virtualStickValueMask),
+			Y: uint16((w >> virtualStickChassisYShift) & virtualStickValueMask),
+		},
+		Gimbal: Stick{
+			X: uint16((w >> virtualStickGimbalXShift) & virtualStickValueMask),
+			Y: uint16((w >> virtualStickGimbalYShift) & virtualStickValueMask),
+		},
+		ChassisEnabled: (w>>virtualStickChassisEnabledShift)&1 != 0,
+		GimbalEnabled:  (w>>virtualStickGimbalEnabledShift)&1 != 0,
+		Mode:           uint8((w >> virtualStickModeShift) & virtualStickModeMask),
+	}
+
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	*v = d
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package event
+
+// Message is an event received from the Unity Bridge together with its
+// associated data. It is what channel based event type subscriptions deliver
+// (callbacks get the same information as parameters).
+type Message struct {
+	Event    *Event
+	Data     []byte
+	DataType DataType
+}
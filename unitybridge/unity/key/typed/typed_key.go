@@ -0,0 +1,226 @@
+// Package typed provides type-safe access to Unity Bridge keys.
+//
+// Keys carry a prototype of the values associated with them, but the
+// UnityBridge API deals with untyped values (any) so every caller has to
+// unwrap and validate results by hand. A TypedKey binds a key to a
+// UnityBridge and to the Go type of its values, validating both once when it
+// is created. The Reader, Writer, ReadWriter and Performer interfaces expose
+// only the operations the key access type allows, so trying to, for example,
+// set a read-only key fails at compile time.
+package typed
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/brunoga/robomaster/support/token"
+	"github.com/brunoga/robomaster/unitybridge"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+// Reader is a typed key that can be read and watched.
+type Reader[T any] interface {
+	// Key returns the underlying key.
+	Key() *key.Key
+
+	// Get returns the current value for the key. A cached value is used if
+	// available.
+	Get(ctx context.Context) (T, error)
+
+	// Refresh is like Get but always requests the value from the robot.
+	Refresh(ctx context.Context) (T, error)
+
+	// Watch calls the given callback with the current value for the key (if
+	// any) and whenever it changes. Returns a token that can be used to stop
+	// watching with Unwatch.
+	Watch(c func(T)) (token.Token, error)
+
+	// Unwatch removes the callback associated with the given token.
+	Unwatch(t token.Token) error
+}
+
+// Writer is a typed key that can be written.
+type Writer[T any] interface {
+	// Key returns the underlying key.
+	Key() *key.Key
+
+	// Set sets the value for the key.
+	Set(ctx context.Context, v T) error
+}
+
+// ReadWriter is a typed key that can be read, watched and written.
+type ReadWriter[T any] interface {
+	Reader[T]
+	Writer[T]
+}
+
+// Performer is a typed key that is an action.
+type Performer[T any] interface {
+	// Key returns the underlying key.
+	Key() *key.Key
+
+	// Perform performs the action associated with the key using the given
+	// value as parameter. Parameters of type value.Void are not sent.
+	Perform(ctx context.Context, v T) error
+}
+
+// TypedKey is a key bound to a UnityBridge and to the type (T) of the values
+// associated with it. T is the value type itself (for example, value.Uint64),
+// not a pointer to it. TypedKeys are usually obtained through NewReader,
+// NewWriter, NewReadWriter or NewPerformer, which restrict the available
+// operations to the ones supported by the key.
+type TypedKey[T any] struct {
+	ub unitybridge.UnityBridge
+	k  *key.Key
+}
+
+var (
+	_ ReadWriter[value.Uint64] = (*TypedKey[value.Uint64])(nil)
+	_ Performer[value.Void]    = (*TypedKey[value.Void])(nil)
+)
+
+// New returns a new TypedKey for the given key and UnityBridge. It returns an
+// error if T does not match the type of the values associated with the key or
+// if the key does not support all of the given access types.
+func New[T any](ub unitybridge.UnityBridge, k *key.Key,
+	accessType key.AccessType) (*TypedKey[T], error) {
+	if ub == nil {
+		return nil, fmt.Errorf("unity bridge cannot be nil")
+	}
+
+	if k == nil {
+		return nil, fmt.Errorf("key cannot be nil")
+	}
+
+	if !k.HasResultValue() {
+		return nil, fmt.Errorf("key %s has an unknown value type", k)
+	}
+
+	want := reflect.TypeOf(k.ResultValue())
+	if got := reflect.TypeOf((*T)(nil)); got != want {
+		return nil, fmt.Errorf("type %s does not match key %s value type %s",
+			got.Elem(), k, want.Elem())
+	}
+
+	if k.AccessType()&accessType != accessType {
+		return nil, fmt.Errorf("key %s does not support access type %d", k,
+			accessType)
+	}
+
+	return &TypedKey[T]{
+		ub: ub,
+		k:  k,
+	}, nil
+}
+
+// NewReader returns a new Reader for the given key and UnityBridge.
+func NewReader[T any](ub unitybridge.UnityBridge,
+	k *key.Key) (Reader[T], error) {
+	return New[T](ub, k, key.AccessTypeRead)
+}
+
+// NewWriter returns a new Writer for the given key and UnityBridge.
+func NewWriter[T any](ub unitybridge.UnityBridge,
+	k *key.Key) (Writer[T], error) {
+	return New[T](ub, k, key.AccessTypeWrite)
+}
+
+// NewReadWriter returns a new ReadWriter for the given key and UnityBridge.
+func NewReadWriter[T any](ub unitybridge.UnityBridge,
+	k *key.Key) (ReadWriter[T], error) {
+	return New[T](ub, k, key.AccessTypeRead|key.AccessTypeWrite)
+}
+
+// NewPerformer returns a new Performer for the given key and UnityBridge.
+func NewPerformer[T any](ub unitybridge.UnityBridge,
+	k *key.Key) (Performer[T], error) {
+	return New[T](ub, k, key.AccessTypeAction)
+}
+
+// Key returns the underlying key.
+func (tk *TypedKey[T]) Key() *key.Key {
+	return tk.k
+}
+
+// Get returns the current value for the key. A cached value is used if
+// available.
+func (tk *TypedKey[T]) Get(ctx context.Context) (T, error) {
+	return tk.get(ctx, true)
+}
+
+// Refresh is like Get but always requests the value from the robot.
+func (tk *TypedKey[T]) Refresh(ctx context.Context) (T, error) {
+	return tk.get(ctx, false)
+}
+
+// Set sets the value for the key.
+func (tk *TypedKey[T]) Set(ctx context.Context, v T) error {
+	return tk.ub.SetKeyValueSyncContext(ctx, tk.k, &v)
+}
+
+// Perform performs the action associated with the key using the given value
+// as parameter. Parameters of type value.Void are not sent.
+func (tk *TypedKey[T]) Perform(ctx context.Context, v T) error {
+	var param any = &v
+	if _, ok := param.(*value.Void); ok {
+		param = nil
+	}
+
+	return tk.ub.PerformActionForKeySyncContext(ctx, tk.k, param)
+}
+
+// Watch calls the given callback with the current value for the key (if any)
+// and whenever it changes. Failed results and results without a value are
+// ignored. Returns a token that can be used to stop watching with Unwatch.
+func (tk *TypedKey[T]) Watch(c func(T)) (token.Token, error) {
+	if c == nil {
+		return 0, fmt.Errorf("callback cannot be nil")
+	}
+
+	return tk.ub.AddKeyListener(tk.k, func(r *result.Result) {
+		if !r.Succeeded() {
+			return
+		}
+
+		v, err := tk.unwrap(r)
+		if err != nil {
+			return
+		}
+
+		c(v)
+	}, true)
+}
+
+// Unwatch removes the callback associated with the given token.
+func (tk *TypedKey[T]) Unwatch(t token.Token) error {
+	return tk.ub.RemoveKeyListener(tk.k, t)
+}
+
+func (tk *TypedKey[T]) get(ctx context.Context, useCache bool) (T, error) {
+	r, err := tk.ub.GetKeyValueSyncContext(ctx, tk.k, useCache)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return tk.unwrap(r)
+}
+
+func (tk *TypedKey[T]) unwrap(r *result.Result) (T, error) {
+	var zero T
+
+	if r == nil || r.Value() == nil {
+		return zero, fmt.Errorf("no value for key %s", tk.k)
+	}
+
+	v, ok := r.Value().(*T)
+	if !ok {
+		return zero, fmt.Errorf("unexpected value type for key %s: %T", tk.k,
+			r.Value())
+	}
+
+	return *v, nil
+}
@@ -0,0 +1,72 @@
+package typed_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster/unitybridge"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/key/typed"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_Validation(t *testing.T) {
+	ub := unitybridge.Get(simulator.NewUnityBridgeWrapper(nil), false, nil)
+
+	// Wrong value type.
+	_, err := typed.NewReader[value.Bool](ub,
+		key.KeyRobomasterSystemSpeakerVolumn)
+	assert.Error(t, err)
+
+	// Key is not writable.
+	_, err = typed.NewWriter[value.Bool](ub, key.KeyCameraIsRecording)
+	assert.Error(t, err)
+
+	// Unknown value type.
+	_, err = typed.NewPerformer[value.Void](ub, key.KeyArmorEnterResetID)
+	assert.Error(t, err)
+
+	_, err = typed.NewReadWriter[value.Uint64](ub,
+		key.KeyRobomasterSystemSpeakerVolumn)
+	assert.NoError(t, err)
+}
+
+func TestTypedKey(t *testing.T) {
+	ub := unitybridge.Get(simulator.NewUnityBridgeWrapper(nil), false, nil)
+	require.NoError(t, ub.Start())
+	defer func() {
+		assert.NoError(t, ub.Stop())
+	}()
+
+	volume, err := typed.NewReadWriter[value.Uint64](ub,
+		key.KeyRobomasterSystemSpeakerVolumn)
+	require.NoError(t, err)
+
+	watched := make(chan uint64, 10)
+	tk, err := volume.Watch(func(v value.Uint64) {
+		watched <- v.Value
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, volume.Unwatch(tk))
+	}()
+
+	ctx := context.Background()
+
+	require.NoError(t, volume.Set(ctx, value.Uint64{Value: 42}))
+
+	v, err := volume.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), v.Value)
+
+	select {
+	case got := <-watched:
+		assert.Equal(t, uint64(42), got)
+	case <-time.After(time.Second):
+		t.Fatal("watch callback not called")
+	}
+}
@@ -0,0 +1,11 @@
+package value
+
+// AttitudeInfo is the robot (chassis) attitude in degrees.
+//
+// The field names mirror the ones in GimbalAttitude but were not checked
+// against values reported by a robot.
+type AttitudeInfo struct {
+	Pitch float32 `json:"pitch"`
+	Roll  float32 `json:"roll"`
+	Yaw   float32 `json:"yaw"`
+}
@@ -0,0 +1,12 @@
+package value
+
+// ChassisRelativePosition is the chassis position relative to where it was
+// when the robot was turned on.
+//
+// Unverified: the JSON field names (and units) are assumptions that still
+// need to be confirmed with real robot output.
+type ChassisRelativePosition struct {
+	X float32 `json:"positionX"`   // Meters.
+	Y float32 `json:"positionY"`   // Meters.
+	Z float32 `json:"positionYaw"` // Degrees.
+}
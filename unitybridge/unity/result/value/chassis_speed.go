@@ -0,0 +1,11 @@
+package value
+
+// ChassisSpeed is the chassis speed relative to its own heading.
+//
+// The JSON field names are unverified guesses (no robot output was captured
+// for this key yet) and might need to be fixed.
+type ChassisSpeed struct {
+	X float32 `json:"speedX"`   // Forward, m/s.
+	Y float32 `json:"speedY"`   // Right, m/s.
+	Z float32 `json:"speedYaw"` // Degrees/s.
+}
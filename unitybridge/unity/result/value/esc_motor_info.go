@@ -0,0 +1,12 @@
+package value
+
+// ESCMotorInfo is the information reported by a chassis motor ESC.
+//
+// Field names, types and units are unverified assumptions. They were not
+// checked against values captured from a robot.
+type ESCMotorInfo struct {
+	Speed     int16  `json:"speed"`     // RPM.
+	Angle     uint16 `json:"angle"`     // Encoder position ([0, 32767]).
+	TimeStamp uint32 `json:"timeStamp"` // Milliseconds.
+	State     uint8  `json:"state"`
+}
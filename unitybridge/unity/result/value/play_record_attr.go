@@ -0,0 +1,13 @@
+package value
+
+// PlayRecordAttr is the drive-path playback state. Writing it with State set
+// to 1 starts the playback and with State set to 0 stops it. The robot pushes
+// it while playing.
+//
+// Neither the layout nor the state values are verified. They have not been
+// checked against a robot yet.
+type PlayRecordAttr struct {
+	Index   uint8   `json:"index"`   // Recording slot.
+	State   uint8   `json:"state"`   // 0: idle, 1: playing, 2: done, 3: failed.
+	Percent float64 `json:"percent"` // Progress.
+}
@@ -0,0 +1,11 @@
+package value
+
+// PlayRecordSetting is the drive-path playback setting.
+//
+// Unverified: field names, types and units are guesses that still need to be
+// confirmed against a robot.
+type PlayRecordSetting struct {
+	Index  uint8   `json:"index"`  // Recording slot.
+	Speed  float64 `json:"speed"`  // Playback speed multiplier.
+	Repeat uint8   `json:"repeat"` // Number of times to play (0 is once).
+}
@@ -0,0 +1,11 @@
+package value
+
+// RecordSetting is the drive-path recording setting.
+//
+// The layout is unverified: it was not checked against values read from a
+// robot (the key explorer can be used for that).
+type RecordSetting struct {
+	Index          uint8  `json:"index"`          // Recording slot.
+	MaxDuration    uint32 `json:"maxDuration"`    // Seconds.
+	SampleInterval uint32 `json:"sampleInterval"` // Milliseconds.
+}
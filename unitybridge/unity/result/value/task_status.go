@@ -2,6 +2,11 @@ package value
 
 import "github.com/brunoga/robomaster/unitybridge/unity/task"
 
+// TaskStatus is a task status push.
+//
+// What the robot reports in the taskId field is unverified. It is assumed to
+// be the task type (the same value sent in the taskId field of
+// ChassisPosition) so task statuses can only be correlated by type.
 type TaskStatus struct {
 	TaskType task.Type   `json:"taskId"` // JSON name seems mismatched. Check.
 	Percent  float64     `json:"percent"`
@@ -0,0 +1,13 @@
+package value
+
+// WheelSpeed is the speed of each chassis wheel in RPM. Positive values move
+// the robot forward.
+//
+// The JSON field names are not verified yet. If they do not match what the
+// robot sends, all wheel speeds will decode as zero.
+type WheelSpeed struct {
+	FrontRight int16 `json:"w1Spd"`
+	FrontLeft  int16 `json:"w2Spd"`
+	RearLeft   int16 `json:"w3Spd"`
+	RearRight  int16 `json:"w4Spd"`
+}
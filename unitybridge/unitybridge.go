@@ -1,6 +1,9 @@
 package unitybridge
 
 import (
+	"context"
+
+	"github.com/brunoga/robomaster/support/dispatcher"
 	"github.com/brunoga/robomaster/support/logger"
 	"github.com/brunoga/robomaster/support/token"
 	"github.com/brunoga/robomaster/unitybridge/internal"
@@ -20,9 +23,27 @@ type UnityBridge interface {
 	// immediate is true, the callback will be called immediatelly with any
 	// cached value associated with the key. Returns a token that can be used
 	// to remove the listener later.
+	//
+	// Results are delivered to each listener in order, from a goroutine
+	// dedicated to it. Up to 64 results are queued and the oldest ones are
+	// dropped if the listener does not keep up.
 	AddKeyListener(k *key.Key, c result.Callback,
 		immediate bool) (token.Token, error)
 
+	// AddKeyListenerWithOptions is like AddKeyListener but allows setting
+	// the queue size and the overflow policy used when the callback does not
+	// keep up with the results. If o is nil, the same options used by
+	// AddKeyListener are used. Note that using dispatcher.OverflowPolicyBlock
+	// makes a slow listener delay the delivery of all events.
+	AddKeyListenerWithOptions(k *key.Key, c result.Callback, immediate bool,
+		o *dispatcher.Options) (token.Token, error)
+
+	// SubscribeKey is like AddKeyListenerWithOptions but results are sent to
+	// the returned channel instead of to a callback. The channel is closed
+	// when the subscription is removed with RemoveKeyListener.
+	SubscribeKey(k *key.Key, immediate bool,
+		o *dispatcher.Options) (<-chan *result.Result, token.Token, error)
+
 	// RemoveKeyListener removes the listener associated with the given token
 	// for events on the given key.
 	RemoveKeyListener(key *key.Key, token token.Token) error
@@ -35,6 +56,12 @@ type UnityBridge interface {
 	// key. This is a synchronous version of GetKeyValue..
 	GetKeyValueSync(k *key.Key, useCache bool) (*result.Result, error)
 
+	// GetKeyValueSyncContext is like GetKeyValueSync but it gives up waiting
+	// for the value when the given context is done. If the context has no
+	// deadline, the same default timeout used by GetKeyValueSync applies.
+	GetKeyValueSyncContext(ctx context.Context, k *key.Key,
+		useCache bool) (*result.Result, error)
+
 	// GetCachedKeyValue returns the Unity Bridge cached value associated
 	// with the given key.
 	GetCachedKeyValue(k *key.Key) (*result.Result, error)
@@ -46,6 +73,12 @@ type UnityBridge interface {
 	// key. This is a synchronous version of SetKeyValue.
 	SetKeyValueSync(k *key.Key, value any) error
 
+	// SetKeyValueSyncContext is like SetKeyValueSync but it gives up waiting
+	// for the operation to complete when the given context is done. If the
+	// context has no deadline, the same default timeout used by
+	// SetKeyValueSync applies.
+	SetKeyValueSyncContext(ctx context.Context, k *key.Key, value any) error
+
 	// PerformActionForKey performs the Unity Bridge action associated with the
 	// given key with the given value as parameter.
 	PerformActionForKey(k *key.Key, value any, c result.Callback) error
@@ -55,6 +88,13 @@ type UnityBridge interface {
 	// version of PerformActionForKey.
 	PerformActionForKeySync(k *key.Key, value any) error
 
+	// PerformActionForKeySyncContext is like PerformActionForKeySync but it
+	// gives up waiting for the action to complete when the given context is
+	// done. If the context has no deadline, the same default timeout used by
+	// PerformActionForKeySync applies.
+	PerformActionForKeySyncContext(ctx context.Context, k *key.Key,
+		value any) error
+
 	// DirectSendKeyValue sends the given value to the Unity Bridge for the
 	// given key. This is a low level function that should be used with care.
 	DirectSendKeyValue(k *key.Key, value uint64) error
@@ -75,9 +115,29 @@ type UnityBridge interface {
 
 	// AddEventTypeListener adds a listener for events of the given type. Returns
 	// a token that can be used to remove the listener later.
+	//
+	// Events are delivered to each listener in order, from a goroutine
+	// dedicated to it. Up to 64 events are queued and the oldest ones are
+	// dropped if the listener does not keep up.
 	AddEventTypeListener(t event.Type,
 		c event.TypeCallback) (token.Token, error)
 
+	// AddEventTypeListenerWithOptions is like AddEventTypeListener but allows
+	// setting the queue size and the overflow policy used when the callback
+	// does not keep up with the events. If o is nil, the same options used
+	// by AddEventTypeListener are used. Note that using
+	// dispatcher.OverflowPolicyBlock makes a slow listener delay the delivery
+	// of all events.
+	AddEventTypeListenerWithOptions(t event.Type, c event.TypeCallback,
+		o *dispatcher.Options) (token.Token, error)
+
+	// SubscribeEventType is like AddEventTypeListenerWithOptions but events
+	// are sent to the returned channel instead of to a callback. The channel
+	// is closed when the subscription is removed with
+	// RemoveEventTypeListener.
+	SubscribeEventType(t event.Type,
+		o *dispatcher.Options) (<-chan event.Message, token.Token, error)
+
 	// RemoveEventTypeListener removes the listener associated with the given
 	// token for events of the given type.
 	RemoveEventTypeListener(t event.Type, token token.Token) error
@@ -7,7 +7,9 @@ import (
 	"log/slog"
 	"sync"
 
+	"github.com/brunoga/robomaster/support/dispatcher"
 	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
 	"github.com/brunoga/robomaster/unitybridge/wrapper/callback"
 )
 
@@ -22,6 +24,32 @@ type Manager struct {
 
 	m                 sync.RWMutex
 	eventCodeCallback map[uint32]callback.Callback
+	dispatchers       map[uint32]*dispatcher.Dispatcher[pendingCallback]
+}
+
+// pendingCallback is a callback waiting to be run with its parameters.
+type pendingCallback struct {
+	c         callback.Callback
+	eventCode uint64
+	data      []byte
+	tag       uint64
+}
+
+// queueOptions are the options used to queue events waiting for their
+// callbacks to run. Each event type has its own queue so a slow callback for
+// one event type does not delay the others. Run never blocks (it is called
+// from the Unity Bridge threads) so the oldest events are dropped if a
+// callback can not keep up.
+var queueOptions = dispatcher.Options{
+	QueueSize:      1024,
+	OverflowPolicy: dispatcher.OverflowPolicyDropOldest,
+}
+
+// mediaQueueOptions are like queueOptions but for audio and video events,
+// which are big and frequent enough that only a few of them should be queued.
+var mediaQueueOptions = dispatcher.Options{
+	QueueSize:      8,
+	OverflowPolicy: dispatcher.OverflowPolicyDropOldest,
 }
 
 // NewManager returns a new Manager instance. It is lazely allocated singleton
@@ -30,21 +58,31 @@ type Manager struct {
 // instance (and the logger parameter is be ignored).
 func NewManager(l *logger.Logger) *Manager {
 	once.Do(func() {
-		if l == nil {
-			l = logger.New(slog.LevelError)
-		}
-
-		l = l.WithGroup("event_callback_manager")
-
-		instance = &Manager{
-			l:                 l,
-			eventCodeCallback: make(map[uint32]callback.Callback),
-		}
+		instance = NewLocalManager(l)
 	})
 
 	return instance
 }
 
+// NewLocalManager returns a new Manager instance that is not shared with any
+// other caller. It can only be used by wrappers that run callbacks themselves
+// (events sent from the Unity Bridge library always go to the singleton
+// returned by NewManager).
+func NewLocalManager(l *logger.Logger) *Manager {
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	l = l.WithGroup("event_callback_manager")
+
+	return &Manager{
+		l:                 l,
+		eventCodeCallback: make(map[uint32]callback.Callback),
+		dispatchers: make(
+			map[uint32]*dispatcher.Dispatcher[pendingCallback]),
+	}
+}
+
 // Set sets the callback for the given event type code. If the callback is nil,
 // the callback for the given event type code is removed.
 func (m *Manager) Set(eventCode uint64, c callback.Callback) error {
@@ -59,6 +97,29 @@ func (m *Manager) Set(eventCode uint64, c callback.Callback) error {
 			return fmt.Errorf("no callback for event type %d found", eventTypeCode)
 		}
 
+		if _, ok := m.dispatchers[eventTypeCode]; !ok {
+			// Callbacks for each event type are run one at a time, in the
+			// order events were received, from a goroutine dedicated to the
+			// event type. Using a goroutine per event (as we used to) meant
+			// events for the same key could be delivered out of order.
+			o := queueOptions
+			switch event.Type(eventTypeCode) {
+			case event.TypeVideoDataRecv, event.TypeAudioDataRecv:
+				o = mediaQueueOptions
+			}
+
+			d, err := dispatcher.New(&o, func(pc pendingCallback) {
+				pc.c(pc.eventCode, pc.data, pc.tag)
+			})
+			if err != nil {
+				return err
+			}
+
+			// Dispatchers are kept around (even if the callback is removed)
+			// as events that were already queued still need to be delivered.
+			m.dispatchers[eventTypeCode] = d
+		}
+
 		m.eventCodeCallback[eventTypeCode] = c
 	} else {
 		if c == nil {
@@ -72,7 +133,8 @@ func (m *Manager) Set(eventCode uint64, c callback.Callback) error {
 	return nil
 }
 
-// Run runs the callback for the given event code.
+// Run queues the callback for the given event code to be run. It never
+// blocks.
 func (m *Manager) Run(eventCode uint64, data []byte, tag uint64) error {
 	eventTypeCode := getEventType(eventCode)
 
@@ -82,14 +144,15 @@ func (m *Manager) Run(eventCode uint64, data []byte, tag uint64) error {
 		m.m.RUnlock()
 		return fmt.Errorf("no handlers for event type code %d", eventTypeCode)
 	}
+	d := m.dispatchers[eventTypeCode]
 	m.m.RUnlock()
 
 	// Make a copy of the data so we can:
 	//
 	// 1. Move the data out of the C side of things into the Go realm (so we)
 	//    can benefit of our garbage collector.
-	// 2. Allow us doing things in goroutines (data will not disappear under
-	//	  us).
+	// 2. Allow us doing things in other goroutines (data will not disappear
+	//	  under us).
 	// 3. We can also return faster to the C side of things which unblocks the
 	//    Unity Bridge code and might also help speeding thing up.
 	//
@@ -100,7 +163,12 @@ func (m *Manager) Run(eventCode uint64, data []byte, tag uint64) error {
 	dataCopy := make([]byte, len(data))
 	copy(dataCopy, data)
 
-	go c(eventCode, dataCopy, tag)
+	d.Dispatch(pendingCallback{
+		c:         c,
+		eventCode: eventCode,
+		data:      dataCopy,
+		tag:       tag,
+	})
 
 	return nil
 }
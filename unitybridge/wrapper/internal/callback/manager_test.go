@@ -0,0 +1,57 @@
+package callback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerRunNeverBlocks(t *testing.T) {
+	m := NewManager(nil)
+
+	// Unused event types so they do not clash with any other callback.
+	const blockedEventCode = uint64(0xfff0) << 32
+	const otherEventCode = uint64(0xfff1) << 32
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	require.NoError(t, m.Set(blockedEventCode, func(uint64, []byte,
+		uint64) {
+		<-unblock
+	}))
+	defer m.Set(blockedEventCode, nil)
+
+	delivered := make(chan uint64, 1)
+	require.NoError(t, m.Set(otherEventCode, func(_ uint64, _ []byte,
+		tag uint64) {
+		delivered <- tag
+	}))
+	defer m.Set(otherEventCode, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 2*queueOptions.QueueSize; i++ {
+			assert.NoError(t, m.Run(blockedEventCode, nil, uint64(i)))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run blocked on a stalled callback")
+	}
+
+	require.NoError(t, m.Run(otherEventCode, nil, 42))
+
+	select {
+	case tag := <-delivered:
+		assert.Equal(t, uint64(42), tag)
+	case <-time.After(time.Second):
+		t.Fatal("stalled callback delayed other event types")
+	}
+}
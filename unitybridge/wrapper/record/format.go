@@ -0,0 +1,248 @@
+package record
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Format versions. Readers reject recordings with a version they do not know
+// about.
+const (
+	// Version1 recordings have the SendEvent output data in the
+	// EntryTypeSendEvent entry, which is written after the call returns.
+	Version1 uint16 = 1
+
+	// Version2 recordings write EntryTypeSendEvent entries before the call
+	// is forwarded (so they always precede any reply to them) and the output
+	// data in a separate EntryTypeSendEventOutput entry.
+	Version2 uint16 = 2
+
+	// CurrentVersion is the version used when writing new recordings.
+	CurrentVersion = Version2
+)
+
+// magic identifies a Unity Bridge recording. It is followed by the format
+// version (a big endian uint16).
+var magic = [4]byte{'R', 'M', 'U', 'B'}
+
+// maxDataSize is the maximum size of the data associated with a single entry
+// (enough for a raw 1280x720 RGB video frame with plenty to spare).
+const maxDataSize = 16 * 1024 * 1024
+
+// EntryType is the type of a recorded entry.
+type EntryType uint8
+
+const (
+	// EntryTypeSendEvent is a SendEvent call. In Version1 recordings, the
+	// entry data is the content of the output buffer after the call returned
+	// (up to the first NUL byte), if any. In later versions it has no data.
+	EntryTypeSendEvent EntryType = iota + 1
+
+	// EntryTypeSendEventWithString is a SendEventWithString call. The entry
+	// data is the string sent.
+	EntryTypeSendEventWithString
+
+	// EntryTypeSendEventWithNumber is a SendEventWithNumber call. The entry
+	// data is the number sent (as a big endian uint64).
+	EntryTypeSendEventWithNumber
+
+	// EntryTypeCallback is an event callback. The entry data is the data
+	// passed to the callback.
+	EntryTypeCallback
+
+	// EntryTypeSendEventOutput is the output of a SendEvent call that was
+	// given an output buffer. The entry data is the content of the output
+	// buffer after the call returned (up to the first NUL byte). It has the
+	// same event code and tag as the EntryTypeSendEvent entry it belongs to.
+	EntryTypeSendEventOutput
+)
+
+// Valid returns true if the entry type is a known one.
+func (et EntryType) Valid() bool {
+	return et >= EntryTypeSendEvent && et <= EntryTypeSendEventOutput
+}
+
+func (et EntryType) String() string {
+	switch et {
+	case EntryTypeSendEvent:
+		return "SendEvent"
+	case EntryTypeSendEventWithString:
+		return "SendEventWithString"
+	case EntryTypeSendEventWithNumber:
+		return "SendEventWithNumber"
+	case EntryTypeCallback:
+		return "Callback"
+	case EntryTypeSendEventOutput:
+		return "SendEventOutput"
+	}
+
+	return fmt.Sprintf("Unknown(%d)", et)
+}
+
+// Entry is a single recorded event.
+type Entry struct {
+	Type EntryType
+
+	// Time since the recording started.
+	Time time.Duration
+
+	EventCode uint64
+	Tag       uint64
+	Data      []byte
+}
+
+// Number returns the number associated with an EntryTypeSendEventWithNumber
+// entry.
+func (e *Entry) Number() uint64 {
+	if len(e.Data) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(e.Data)
+}
+
+// entryHeader is the fixed size part of an encoded entry. It is followed by
+// DataSize bytes of data.
+type entryHeader struct {
+	Type      EntryType
+	Time      int64
+	EventCode uint64
+	Tag       uint64
+	DataSize  uint32
+}
+
+// Writer writes entries in the recording format to an underlying io.Writer.
+// Entries are written as they come so recordings can be arbitrarily long. It
+// is not safe for concurrent use.
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter returns a new Writer that writes to the given io.Writer. The
+// recording header is written immediately.
+func NewWriter(w io.Writer) (*Writer, error) {
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.Write(magic[:]); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(bw, binary.BigEndian, CurrentVersion); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		w: bw,
+	}, nil
+}
+
+// Write writes the given entry.
+func (w *Writer) Write(e *Entry) error {
+	if !e.Type.Valid() {
+		return fmt.Errorf("invalid entry type: %s", e.Type)
+	}
+
+	if len(e.Data) > maxDataSize {
+		return fmt.Errorf("entry data too big: %d bytes", len(e.Data))
+	}
+
+	err := binary.Write(w.w, binary.BigEndian, entryHeader{
+		Type:      e.Type,
+		Time:      int64(e.Time),
+		EventCode: e.EventCode,
+		Tag:       e.Tag,
+		DataSize:  uint32(len(e.Data)),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.w.Write(e.Data)
+
+	return err
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader reads entries in the recording format from an underlying io.Reader.
+type Reader struct {
+	r       *bufio.Reader
+	version uint16
+}
+
+// NewReader returns a new Reader that reads from the given io.Reader. The
+// recording header is read and validated immediately.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	var m [4]byte
+	if _, err := io.ReadFull(br, m[:]); err != nil {
+		return nil, fmt.Errorf("error reading recording header: %w", err)
+	}
+
+	if m != magic {
+		return nil, fmt.Errorf("not a unity bridge recording")
+	}
+
+	var version uint16
+	if err := binary.Read(br, binary.BigEndian, &version); err != nil {
+		return nil, fmt.Errorf("error reading recording version: %w", err)
+	}
+
+	if version == 0 || version > CurrentVersion {
+		return nil, fmt.Errorf("unsupported recording version: %d", version)
+	}
+
+	return &Reader{
+		r:       br,
+		version: version,
+	}, nil
+}
+
+// Version returns the format version of the recording being read.
+func (r *Reader) Version() uint16 {
+	return r.version
+}
+
+// Next returns the next entry in the recording. It returns io.EOF when there
+// are no more entries.
+func (r *Reader) Next() (*Entry, error) {
+	var h entryHeader
+	if err := binary.Read(r.r, binary.BigEndian, &h); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated entry: %w", err)
+		}
+
+		return nil, err
+	}
+
+	if !h.Type.Valid() {
+		return nil, fmt.Errorf("invalid entry type: %s", h.Type)
+	}
+
+	if h.DataSize > maxDataSize {
+		return nil, fmt.Errorf("entry data too big: %d bytes", h.DataSize)
+	}
+
+	var data []byte
+	if h.DataSize > 0 {
+		data = make([]byte, h.DataSize)
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			return nil, fmt.Errorf("truncated entry data: %w", err)
+		}
+	}
+
+	return &Entry{
+		Type:      h.Type,
+		Time:      time.Duration(h.Time),
+		EventCode: h.EventCode,
+		Tag:       h.Tag,
+		Data:      data,
+	}, nil
+}
@@ -0,0 +1,187 @@
+package record_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster"
+	"github.com/brunoga/robomaster/module"
+	"github.com/brunoga/robomaster/module/connection"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/wrapper"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/record"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterReader(t *testing.T) {
+	entries := []*record.Entry{
+		{
+			Type:      record.EntryTypeSendEvent,
+			Time:      time.Millisecond,
+			EventCode: 1,
+			Tag:       2,
+		},
+		{
+			Type:      record.EntryTypeSendEventWithString,
+			Time:      2 * time.Millisecond,
+			EventCode: 3,
+			Tag:       4,
+			Data:      []byte("192.168.2.1"),
+		},
+		{
+			Type:      record.EntryTypeCallback,
+			Time:      3 * time.Millisecond,
+			EventCode: 5,
+			Tag:       6,
+			Data:      []byte(`{"key":1,"tag":6,"error":0,"value":""}`),
+		},
+	}
+
+	var b bytes.Buffer
+
+	w, err := record.NewWriter(&b)
+	require.NoError(t, err)
+
+	for _, e := range entries {
+		require.NoError(t, w.Write(e))
+	}
+
+	require.NoError(t, w.Flush())
+
+	r, err := record.NewReader(&b)
+	require.NoError(t, err)
+	assert.Equal(t, record.CurrentVersion, r.Version())
+
+	for _, e := range entries {
+		got, err := r.Next()
+		require.NoError(t, err)
+		assert.Equal(t, e, got)
+	}
+
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReader_InvalidHeader(t *testing.T) {
+	_, err := record.NewReader(bytes.NewReader([]byte("XXXX\x00\x01")))
+	assert.Error(t, err)
+
+	// Unknown version.
+	_, err = record.NewReader(bytes.NewReader([]byte("RMUB\x00\xff")))
+	assert.Error(t, err)
+}
+
+func TestRecordReplay(t *testing.T) {
+	var b bytes.Buffer
+
+	rec, err := record.NewRecorder(simulator.NewUnityBridgeWrapper(nil), &b,
+		nil)
+	require.NoError(t, err)
+
+	recorded := runSession(t, rec, 30)
+	require.NoError(t, rec.Err())
+
+	rep, err := record.NewReplayer(nil, &b, &record.ReplayOptions{
+		MatchTimeout: time.Second,
+	})
+	require.NoError(t, err)
+
+	// The replayed session sets a different volume but reads the recorded
+	// one back.
+	replayed := runSession(t, rep, 70)
+	assert.Equal(t, recorded, replayed)
+
+	select {
+	case <-rep.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("replay did not finish")
+	}
+
+	assert.NoError(t, rep.Err())
+}
+
+func runSession(t *testing.T, uw wrapper.UnityBridge, volume uint8) uint8 {
+	c, err := robomaster.NewWithWrapper(nil, 0, connection.TypeWiFiDirect,
+		module.TypeConnection|module.TypeRobot, uw)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Start())
+	defer func() {
+		require.NoError(t, c.Stop())
+	}()
+
+	require.NoError(t, c.Robot().SetSpeakerVolume(volume))
+
+	v, err := c.Robot().SpeakerVolume()
+	require.NoError(t, err)
+
+	return v
+}
+
+func TestReplayer_TagsAndOutput(t *testing.T) {
+	// Unused event type so it does not clash with any other callback.
+	const eventCode = uint64(0xfffe) << 32
+
+	entries := []*record.Entry{
+		{
+			Type:      record.EntryTypeSendEvent,
+			EventCode: eventCode,
+			Tag:       7,
+		},
+		{
+			Type:      record.EntryTypeCallback,
+			EventCode: eventCode,
+			Tag:       uint64(event.DataTypeUint64)<<56 | 7,
+			Data:      []byte("reply"),
+		},
+		{
+			Type:      record.EntryTypeSendEventOutput,
+			EventCode: eventCode,
+			Tag:       7,
+			Data:      []byte("output"),
+		},
+	}
+
+	var b bytes.Buffer
+
+	w, err := record.NewWriter(&b)
+	require.NoError(t, err)
+
+	for _, e := range entries {
+		require.NoError(t, w.Write(e))
+	}
+
+	require.NoError(t, w.Flush())
+
+	rep, err := record.NewReplayer(nil, &b, &record.ReplayOptions{
+		MatchTimeout: time.Second,
+	})
+	require.NoError(t, err)
+
+	tags := make(chan uint64, 1)
+	rep.SetEventCallback(eventCode, func(_ uint64, data []byte,
+		tag uint64) {
+		tags <- tag
+	})
+	defer rep.SetEventCallback(eventCode, nil)
+
+	require.True(t, rep.Initialize())
+	defer rep.Uninitialize()
+
+	output := make([]byte, 16)
+	rep.SendEvent(eventCode, output, 42)
+
+	assert.Equal(t, "output", string(output[:bytes.IndexByte(output, 0)]))
+
+	select {
+	case tag := <-tags:
+		// The data type is kept.
+		assert.Equal(t, uint64(event.DataTypeUint64)<<56|42, tag)
+	case <-time.After(time.Second):
+		t.Fatal("reply not delivered")
+	}
+}
@@ -0,0 +1,184 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/unitybridge/wrapper"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/callback"
+)
+
+// Recorder is a wrapper.UnityBridge decorator that forwards all calls to
+// another wrapper.UnityBridge and records every event sent and every event
+// callback received to an io.Writer. The recording can later be played back
+// with a Replayer.
+type Recorder struct {
+	uw wrapper.UnityBridge
+	l  *logger.Logger
+
+	m      sync.Mutex
+	w      *Writer
+	start  time.Time
+	err    error
+	closed bool
+}
+
+var _ wrapper.UnityBridge = (*Recorder)(nil)
+
+// NewRecorder returns a new Recorder that forwards calls to the given
+// wrapper.UnityBridge and records them to the given io.Writer.
+func NewRecorder(uw wrapper.UnityBridge, w io.Writer,
+	l *logger.Logger) (*Recorder, error) {
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	rw, err := NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		uw:    uw,
+		l:     l.WithGroup("recorder"),
+		w:     rw,
+		start: time.Now(),
+	}, nil
+}
+
+// Create implements wrapper.UnityBridge.
+func (r *Recorder) Create(name string, debuggable bool, logPath string) {
+	r.uw.Create(name, debuggable, logPath)
+}
+
+// Initialize implements wrapper.UnityBridge.
+func (r *Recorder) Initialize() bool {
+	return r.uw.Initialize()
+}
+
+// SetEventCallback implements wrapper.UnityBridge. Events sent to the given
+// callback are recorded before being forwarded to it.
+func (r *Recorder) SetEventCallback(eventTypeCode uint64,
+	c callback.Callback) {
+	if c == nil {
+		r.uw.SetEventCallback(eventTypeCode, nil)
+		return
+	}
+
+	r.uw.SetEventCallback(eventTypeCode, func(eventCode uint64, data []byte,
+		tag uint64) {
+		r.record(EntryTypeCallback, eventCode, tag, data)
+
+		c(eventCode, data, tag)
+	})
+}
+
+// SendEvent implements wrapper.UnityBridge. The event is recorded before it is
+// forwarded so it always precedes any reply to it in the recording. Any data
+// returned in output is recorded in a separate entry.
+func (r *Recorder) SendEvent(eventCode uint64, output []byte, tag uint64) {
+	r.record(EntryTypeSendEvent, eventCode, tag, nil)
+
+	r.uw.SendEvent(eventCode, output, tag)
+
+	if len(output) == 0 {
+		return
+	}
+
+	data := output
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+
+	r.record(EntryTypeSendEventOutput, eventCode, tag, data)
+}
+
+// SendEventWithString implements wrapper.UnityBridge.
+func (r *Recorder) SendEventWithString(eventCode uint64, data string,
+	tag uint64) {
+	r.record(EntryTypeSendEventWithString, eventCode, tag, []byte(data))
+
+	r.uw.SendEventWithString(eventCode, data, tag)
+}
+
+// SendEventWithNumber implements wrapper.UnityBridge.
+func (r *Recorder) SendEventWithNumber(eventCode uint64, data,
+	tag uint64) {
+	r.record(EntryTypeSendEventWithNumber, eventCode, tag,
+		binary.BigEndian.AppendUint64(nil, data))
+
+	r.uw.SendEventWithNumber(eventCode, data, tag)
+}
+
+// GetSecurityKeyByKeyChainIndex implements wrapper.UnityBridge.
+func (r *Recorder) GetSecurityKeyByKeyChainIndex(index int) string {
+	return r.uw.GetSecurityKeyByKeyChainIndex(index)
+}
+
+// Uninitialize implements wrapper.UnityBridge. Nothing else is recorded after
+// it returns (even if callbacks are still being delivered) so the underlying
+// io.Writer can be used by its owner.
+func (r *Recorder) Uninitialize() {
+	r.uw.Uninitialize()
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.closed {
+		return
+	}
+
+	r.closed = true
+
+	if err := r.w.Flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Destroy implements wrapper.UnityBridge.
+func (r *Recorder) Destroy() {
+	r.uw.Destroy()
+}
+
+// Err returns the first error that happened while recording (if any). Once
+// an error happens, nothing else is recorded (but calls are still forwarded).
+func (r *Recorder) Err() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.err
+}
+
+func (r *Recorder) record(typ EntryType, eventCode, tag uint64,
+	data []byte) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.err != nil || r.closed {
+		return
+	}
+
+	err := r.w.Write(&Entry{
+		Type:      typ,
+		Time:      time.Since(r.start),
+		EventCode: eventCode,
+		Tag:       tag,
+		Data:      data,
+	})
+	if err == nil {
+		// Flush on every entry so recordings survive crashes (which is
+		// usually when they are needed the most).
+		err = r.w.Flush()
+	}
+
+	if err != nil {
+		r.l.Error("Error recording entry. Recording stopped.", "type", typ,
+			"eventCode", eventCode, "error", err)
+		r.err = err
+	}
+}
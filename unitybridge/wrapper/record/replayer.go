@@ -0,0 +1,478 @@
+package record
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/wrapper"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/callback"
+
+	internal_callback "github.com/brunoga/robomaster/unitybridge/wrapper/internal/callback"
+)
+
+// ReplayOptions controls how a recording is played back.
+type ReplayOptions struct {
+	// Speed is the playback speed relative to the recording (1 is real time,
+	// 2 is twice as fast, etc). Zero means as fast as possible.
+	Speed float64
+
+	// MatchTimeout is how long playback waits for the replayed code to send
+	// an event that was sent at the same point in the recording. If it
+	// expires, a warning is logged and playback continues. Zero means a
+	// default of 5 seconds.
+	MatchTimeout time.Duration
+}
+
+// DefaultReplayOptions are the options used when none are given.
+var DefaultReplayOptions = ReplayOptions{
+	Speed:        1,
+	MatchTimeout: 5 * time.Second,
+}
+
+// maxUnmatched is the maximum number of unmatched live events (per event),
+// unanswered tags and unserved outputs (per event) kept by a Replayer. The
+// oldest ones are dropped when the limit is reached so memory usage does not
+// grow with the length of the recording.
+const maxUnmatched = 1024
+
+// matchKey identifies a sent event for matching purposes.
+type matchKey struct {
+	typ       EntryType
+	eventCode uint64
+}
+
+// outputKey identifies the recorded output of a SendEvent call.
+type outputKey struct {
+	eventCode uint64
+	tag       uint64 // Recorded tag.
+}
+
+// pendingSend is an event sent by the code being replayed that was not
+// matched to a recorded one yet.
+type pendingSend struct {
+	tag        uint64 // Live tag.
+	wantOutput bool
+	c          chan []byte
+}
+
+// tagMapping associates a recorded tag with a live one.
+type tagMapping struct {
+	recorded uint64
+	live     uint64
+}
+
+// Replayer is a wrapper.UnityBridge implementation that plays back a
+// recording created with a Recorder. Recorded callbacks are delivered through
+// the callback manager (as the real implementations do) with the recorded
+// timing and recorded sent events are matched against the events sent by the
+// code being debugged, so callbacks are never delivered before the requests
+// that caused them. Recorded tags are replaced by the tags of the matching
+// live events so replies reach the code waiting for them. Data returned by
+// SendEvent (for example, cached key values) is served from the recording.
+//
+// Recordings are read as they are played back so they do not need to fit in
+// memory.
+type Replayer struct {
+	r  *Reader
+	o  ReplayOptions
+	l  *logger.Logger
+	cm *internal_callback.Manager
+
+	m       sync.Mutex
+	pending map[matchKey][]*pendingSend
+	outputs map[outputKey][]chan []byte
+	tags    map[uint64]uint64 // Recorded tag -> live tag.
+	tagFIFO []tagMapping      // Insertion order of tags.
+	signal  chan struct{}
+	started bool
+	quit    chan struct{}
+	done    chan struct{}
+	err     error
+}
+
+var _ wrapper.UnityBridge = (*Replayer)(nil)
+
+// NewReplayer returns a new Replayer that plays back the recording read from
+// the given io.Reader. If o is nil, DefaultReplayOptions is used.
+func NewReplayer(l *logger.Logger, r io.Reader,
+	o *ReplayOptions) (*Replayer, error) {
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	if o == nil {
+		o = &DefaultReplayOptions
+	}
+
+	if o.Speed < 0 {
+		return nil, fmt.Errorf("invalid replay speed: %f", o.Speed)
+	}
+
+	rr, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	options := *o
+	if options.MatchTimeout == 0 {
+		options.MatchTimeout = DefaultReplayOptions.MatchTimeout
+	}
+
+	l = l.WithGroup("replayer")
+
+	return &Replayer{
+		r:       rr,
+		o:       options,
+		l:       l,
+		cm:      internal_callback.NewManager(l),
+		pending: make(map[matchKey][]*pendingSend),
+		outputs: make(map[outputKey][]chan []byte),
+		tags:    make(map[uint64]uint64),
+		signal:  make(chan struct{}),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Create implements wrapper.UnityBridge.
+func (r *Replayer) Create(name string, debuggable bool, logPath string) {
+	r.l.Debug("Create", "name", name, "debuggable", debuggable, "logPath",
+		logPath)
+}
+
+// Initialize implements wrapper.UnityBridge. It starts playback.
+func (r *Replayer) Initialize() bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.started {
+		return false
+	}
+
+	r.started = true
+
+	go r.loop()
+
+	return true
+}
+
+// SetEventCallback implements wrapper.UnityBridge.
+func (r *Replayer) SetEventCallback(eventTypeCode uint64,
+	c callback.Callback) {
+	err := r.cm.Set(eventTypeCode, c)
+	if err != nil {
+		r.l.Warn("Error setting event callback", "eventTypeCode",
+			eventTypeCode, "error", err)
+	}
+}
+
+// SendEvent implements wrapper.UnityBridge. If output is not empty, this
+// blocks until playback reaches the matching recorded event (or the match
+// timeout expires) and copies the recorded output data to it.
+func (r *Replayer) SendEvent(eventCode uint64, output []byte, tag uint64) {
+	c := r.sent(EntryTypeSendEvent, eventCode, tag, len(output) > 0)
+	if len(output) == 0 {
+		return
+	}
+
+	select {
+	case data := <-c:
+		if len(data) >= len(output) {
+			r.l.Warn("Recorded output does not fit output buffer",
+				"eventCode", eventCode, "size", len(data))
+			return
+		}
+
+		n := copy(output, data)
+		output[n] = 0
+	case <-time.After(r.o.MatchTimeout):
+		r.l.Warn("No recorded output for event", "eventCode", eventCode)
+	case <-r.done:
+	}
+}
+
+// SendEventWithString implements wrapper.UnityBridge.
+func (r *Replayer) SendEventWithString(eventCode uint64, data string,
+	tag uint64) {
+	r.sent(EntryTypeSendEventWithString, eventCode, tag, false)
+}
+
+// SendEventWithNumber implements wrapper.UnityBridge.
+func (r *Replayer) SendEventWithNumber(eventCode uint64, data,
+	tag uint64) {
+	r.sent(EntryTypeSendEventWithNumber, eventCode, tag, false)
+}
+
+// GetSecurityKeyByKeyChainIndex implements wrapper.UnityBridge.
+func (r *Replayer) GetSecurityKeyByKeyChainIndex(index int) string {
+	return ""
+}
+
+// Uninitialize implements wrapper.UnityBridge. It stops playback.
+func (r *Replayer) Uninitialize() {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if !r.started {
+		return
+	}
+
+	select {
+	case <-r.quit:
+	default:
+		close(r.quit)
+	}
+}
+
+// Destroy implements wrapper.UnityBridge.
+func (r *Replayer) Destroy() {
+	r.l.Debug("Destroy")
+}
+
+// Done returns a channel that is closed when playback ends (either because
+// the end of the recording was reached, an error happened or the Replayer was
+// uninitialized).
+func (r *Replayer) Done() <-chan struct{} {
+	return r.done
+}
+
+// Err returns the error that stopped playback, if any. It is only meaningful
+// after Done is closed.
+func (r *Replayer) Err() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.err
+}
+
+// sent registers an event sent with the given tag by the code being replayed.
+// If wantOutput is true, the returned channel receives the recorded output
+// data for the matching recorded entry.
+func (r *Replayer) sent(typ EntryType, eventCode, tag uint64,
+	wantOutput bool) <-chan []byte {
+	c := make(chan []byte, 1)
+	k := matchKey{typ, eventCode}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	ps := append(r.pending[k], &pendingSend{
+		tag:        tag,
+		wantOutput: wantOutput,
+		c:          c,
+	})
+	if len(ps) > maxUnmatched {
+		r.l.Debug("Dropping unmatched event", "type", typ, "eventCode",
+			eventCode, "tag", ps[0].tag)
+		ps = ps[1:]
+	}
+	r.pending[k] = ps
+
+	// Wake up playback if it is waiting for a match.
+	close(r.signal)
+	r.signal = make(chan struct{})
+
+	return c
+}
+
+func (r *Replayer) loop() {
+	defer close(r.done)
+
+	start := time.Now()
+
+	for {
+		e, err := r.r.Next()
+		if err != nil {
+			if err != io.EOF {
+				r.l.Error("Error reading recording", "error", err)
+
+				r.m.Lock()
+				r.err = err
+				r.m.Unlock()
+			}
+
+			return
+		}
+
+		if !r.wait(start, e.Time) {
+			return
+		}
+
+		switch e.Type {
+		case EntryTypeCallback:
+			err = r.cm.Run(e.EventCode, e.Data, r.liveTag(e.Tag))
+			if err != nil {
+				r.l.Warn("Unable to deliver recorded event", "eventCode",
+					e.EventCode, "error", err)
+			}
+
+			continue
+		case EntryTypeSendEventOutput:
+			r.output(e)
+
+			continue
+		}
+
+		if !r.match(e) {
+			return
+		}
+	}
+}
+
+// wait waits until it is time to play an entry recorded at the given time. It
+// returns false if playback was stopped.
+func (r *Replayer) wait(start time.Time, t time.Duration) bool {
+	if r.o.Speed == 0 {
+		select {
+		case <-r.quit:
+			return false
+		default:
+			return true
+		}
+	}
+
+	d := time.Until(start.Add(time.Duration(float64(t) / r.o.Speed)))
+	if d <= 0 {
+		d = 0
+	}
+
+	select {
+	case <-r.quit:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// match waits for the code being replayed to send the event in the given
+// recorded entry and hands it the recorded data. It returns false if playback
+// was stopped.
+func (r *Replayer) match(e *Entry) bool {
+	k := matchKey{e.Type, e.EventCode}
+	timeout := time.After(r.o.MatchTimeout)
+
+	for {
+		r.m.Lock()
+		if ps := r.pending[k]; len(ps) > 0 {
+			p := ps[0]
+			if len(ps) == 1 {
+				delete(r.pending, k)
+			} else {
+				r.pending[k] = ps[1:]
+			}
+
+			if e.Tag != 0 {
+				r.addTagLocked(e.Tag, p.tag)
+			}
+
+			if e.Type == EntryTypeSendEvent && r.r.Version() >= Version2 {
+				// Output data comes in its own entry.
+				if p.wantOutput {
+					ok := outputKey{e.EventCode, e.Tag}
+					cs := append(r.outputs[ok], p.c)
+					if len(cs) > maxUnmatched {
+						cs = cs[1:]
+					}
+					r.outputs[ok] = cs
+				}
+
+				r.m.Unlock()
+
+				return true
+			}
+			r.m.Unlock()
+
+			p.c <- e.Data
+
+			return true
+		}
+		signal := r.signal
+		r.m.Unlock()
+
+		select {
+		case <-r.quit:
+			return false
+		case <-signal:
+		case <-timeout:
+			r.l.Warn("Recorded event not sent by replayed code", "type",
+				e.Type, "eventCode", e.EventCode, "tag", e.Tag)
+			return true
+		}
+	}
+}
+
+// output hands the recorded output data in the given entry to the matching
+// SendEvent call waiting for it, if any.
+func (r *Replayer) output(e *Entry) {
+	k := outputKey{e.EventCode, e.Tag}
+
+	r.m.Lock()
+	cs := r.outputs[k]
+	if len(cs) == 0 {
+		r.m.Unlock()
+
+		r.l.Debug("Recorded output not requested by replayed code",
+			"eventCode", e.EventCode, "tag", e.Tag)
+
+		return
+	}
+
+	if len(cs) == 1 {
+		delete(r.outputs, k)
+	} else {
+		r.outputs[k] = cs[1:]
+	}
+	r.m.Unlock()
+
+	cs[0] <- e.Data
+}
+
+// addTagLocked associates the given recorded tag with the given live tag.
+// Only the newest maxUnmatched associations are kept so tags for replies that
+// never arrive are eventually forgotten.
+func (r *Replayer) addTagLocked(recorded, live uint64) {
+	r.tags[recorded] = live
+	r.tagFIFO = append(r.tagFIFO, tagMapping{recorded, live})
+
+	if len(r.tagFIFO) <= maxUnmatched {
+		return
+	}
+
+	oldest := r.tagFIFO[0]
+	r.tagFIFO = r.tagFIFO[1:]
+
+	if current, ok := r.tags[oldest.recorded]; ok && current == oldest.live {
+		r.l.Debug("Dropping unanswered tag", "tag", oldest.recorded)
+		delete(r.tags, oldest.recorded)
+	}
+}
+
+// liveTag returns the live tag associated with the given recorded callback
+// tag. Callback tags carry the data type in their top byte, which is kept in
+// the returned tag. Tags are only used once (for the reply to the event that
+// was sent with them) so the association is removed. Recorded tags that were
+// never matched are returned as is.
+func (r *Replayer) liveTag(tag uint64) uint64 {
+	if tag == 0 {
+		return 0
+	}
+
+	dataType, recorded := event.DataTypeFromTag(tag)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	live, ok := r.tags[recorded]
+	if !ok {
+		return tag
+	}
+
+	delete(r.tags, recorded)
+
+	return uint64(dataType)<<56 | live
+}
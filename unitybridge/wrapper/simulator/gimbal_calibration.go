@@ -0,0 +1,60 @@
+package simulator
+
+import (
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+)
+
+// Gimbal calibration statuses (see gimbal.CalibrationStatus).
+const (
+	gimbalCalibrationStatusIdle = iota
+	gimbalCalibrationStatusCalibrating
+	gimbalCalibrationStatusSucceeded
+)
+
+// Time (in seconds) each type of gimbal calibration takes.
+const (
+	gimbalCalibrationDuration     = 1.0
+	gimbalAutoCalibrationDuration = 0.5
+)
+
+// gimbalCalibration is an in-progress gimbal calibration.
+type gimbalCalibration struct {
+	duration float64 // Seconds.
+	elapsed  float64 // Seconds.
+}
+
+// startGimbalCalibration starts (or restarts) a gimbal calibration that takes
+// the given duration (in seconds).
+func (r *robot) startGimbalCalibration(duration float64,
+	changed map[*key.Key][]byte) {
+	r.abortGimbalMove()
+	r.pitchSpeed, r.gimbalYawSpeed = 0, 0
+
+	r.gimbalCalibration = &gimbalCalibration{duration: duration}
+
+	changed[key.KeyGimbalCalibrationStatus] = valueJSON(
+		gimbalCalibrationStatusCalibrating)
+	changed[key.KeyGimbalCalibrationProgress] = valueJSON(0)
+}
+
+// stepGimbalCalibration advances any in-progress gimbal calibration.
+func (r *robot) stepGimbalCalibration(dt float64,
+	changed map[*key.Key][]byte) {
+	c := r.gimbalCalibration
+	if c == nil {
+		return
+	}
+
+	c.elapsed += dt
+	if c.elapsed >= c.duration {
+		r.gimbalCalibration = nil
+
+		changed[key.KeyGimbalCalibrationProgress] = valueJSON(100)
+		changed[key.KeyGimbalCalibrationStatus] = valueJSON(
+			gimbalCalibrationStatusSucceeded)
+		return
+	}
+
+	changed[key.KeyGimbalCalibrationProgress] = valueJSON(
+		int(100 * c.elapsed / c.duration))
+}
@@ -0,0 +1,116 @@
+package simulator
+
+import (
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+)
+
+// IMU calibration states and fail codes (see robot.IMUCalibrationState and
+// robot.IMUCalibrationError).
+const (
+	imuCalibrationStateIdle = iota
+	imuCalibrationStateCalibrating
+	imuCalibrationStateSucceeded
+	imuCalibrationStateFailed
+
+	imuCalibrationFailCodeMoved = 2
+)
+
+const (
+	// Number of sides the robot must rest on during an IMU calibration.
+	imuCalibrationSides = 6
+
+	// Time (in seconds) each side takes to be calibrated. There is no way to
+	// flip the simulated robot, so it is assumed to be placed on the
+	// requested side right away.
+	imuCalibrationSideDuration = 0.25
+)
+
+// imuCalibration is an in-progress IMU calibration.
+type imuCalibration struct {
+	side    int
+	elapsed float64 // Seconds since the current side started.
+	pose    pose    // Chassis pose when the calibration started.
+}
+
+// percent returns the overall calibration progress.
+func (c *imuCalibration) percent() int {
+	return int(100 * (float64(c.side) + c.elapsed/imuCalibrationSideDuration) /
+		imuCalibrationSides)
+}
+
+// startIMUCalibration starts (or restarts) an IMU calibration.
+func (r *robot) startIMUCalibration(changed map[*key.Key][]byte) {
+	r.imuCalibration = &imuCalibration{pose: r.pose()}
+
+	changed[key.KeyRobomasterMainControllerIMUCalibrationState] = valueJSON(
+		imuCalibrationStateCalibrating)
+	changed[key.KeyRobomasterMainControllerIMUCalibrationCurrSide] = valueJSON(0)
+	changed[key.KeyRobomasterMainControllerIMUCalibrationProgress] = valueJSON(0)
+	changed[key.KeyRobomasterMainControllerIMUCalibrationFailCode] = valueJSON(0)
+	changed[key.KeyRobomasterMainControllerIMUCalibrationFinishFlag] = valueJSON(
+		false)
+}
+
+// stopIMUCalibration stops any in-progress IMU calibration.
+func (r *robot) stopIMUCalibration(changed map[*key.Key][]byte) {
+	if r.imuCalibration == nil {
+		return
+	}
+
+	r.imuCalibration = nil
+
+	changed[key.KeyRobomasterMainControllerIMUCalibrationState] = valueJSON(
+		imuCalibrationStateIdle)
+}
+
+// stepIMUCalibration advances any in-progress IMU calibration. The calibration
+// fails if the chassis moves.
+func (r *robot) stepIMUCalibration(dt float64, changed map[*key.Key][]byte) {
+	c := r.imuCalibration
+	if c == nil {
+		return
+	}
+
+	if r.pose() != c.pose {
+		r.finishIMUCalibration(imuCalibrationFailCodeMoved, changed)
+		return
+	}
+
+	c.elapsed += dt
+	if c.elapsed >= imuCalibrationSideDuration {
+		c.elapsed = 0
+		c.side++
+
+		if c.side == imuCalibrationSides {
+			changed[key.KeyRobomasterMainControllerIMUCalibrationProgress] =
+				valueJSON(100)
+			r.finishIMUCalibration(0, changed)
+			return
+		}
+
+		changed[key.KeyRobomasterMainControllerIMUCalibrationCurrSide] =
+			valueJSON(c.side)
+	}
+
+	changed[key.KeyRobomasterMainControllerIMUCalibrationProgress] = valueJSON(
+		c.percent())
+}
+
+// finishIMUCalibration ends the in-progress IMU calibration with the given
+// fail code (0 for success).
+func (r *robot) finishIMUCalibration(failCode int,
+	changed map[*key.Key][]byte) {
+	r.imuCalibration = nil
+
+	state := imuCalibrationStateSucceeded
+	if failCode != 0 {
+		state = imuCalibrationStateFailed
+	}
+
+	changed[key.KeyRobomasterMainControllerIMUCalibrationState] = valueJSON(
+		state)
+	changed[key.KeyRobomasterMainControllerIMUCalibrationFailCode] = valueJSON(
+		failCode)
+	changed[key.KeyRobomasterMainControllerIMUCalibrationFinishFlag] = valueJSON(
+		true)
+}
@@ -0,0 +1,876 @@
+package simulator
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/control"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+	"github.com/brunoga/robomaster/unitybridge/unity/task"
+)
+
+const (
+	// Gimbal limits (in degrees, relative to the chassis).
+	gimbalMinPitch = -25.0
+	gimbalMaxPitch = 35.0
+	gimbalMinYaw   = -250.0
+	gimbalMaxYaw   = 250.0
+
+	// Speeds used for position based moves.
+	chassisMoveSpeed   = 0.5   // m/s.
+	chassisRotateSpeed = 90.0  // degrees/s.
+	gimbalResetSpeed   = 180.0 // degrees/s.
+
+	// A gimbal reset always takes at least this number of simulation steps so
+	// its (transient) state can be observed.
+	gimbalResetMinSteps = 10
+
+	// Mecanum chassis geometry (in meters). Wheels are numbered as in
+	// value.WheelSpeed (front right, front left, rear left, rear right).
+	wheelRadius    = 0.05
+	wheelBaseHalfs = 0.2 // Half wheel base plus half track.
+
+	// ESC encoder units per wheel revolution.
+	escAngleUnits = 32768
+
+	// Default chassis limits (m/s and m/s²).
+	defaultMaxSpeed = 3.5
+	defaultSlope    = 5.0
+
+	// Default drive path recording settings (in seconds and milliseconds).
+	defaultRecordDuration       = 60
+	defaultRecordSampleInterval = 100
+
+	// Reported ESC firmware version.
+	escFirmwareVersion = "00.01.00.00"
+
+	// Virtual stick limits.
+	stickMaxChassis     = 1.0   // m/s.
+	stickMaxGimbalSpeed = 180.0 // degrees/s.
+
+	// Reported robot devices (see robot.DeviceType).
+	deviceTypeImageTransmission = 256
+	deviceTypeCamera            = 260
+	deviceTypeChassis           = 768
+	deviceTypeBattery           = 778
+	deviceTypeESC0              = 788
+	deviceTypeESC1              = 789
+	deviceTypeESC2              = 790
+	deviceTypeESC3              = 791
+	deviceTypeGimbal            = 1024
+	deviceTypeWaterGun          = 5888
+)
+
+// gimbalMove is an in-progress gimbal move to a specific target.
+type gimbalMove struct {
+	pitch, yaw float64 // Target (absolute, relative to chassis).
+	pitchSpeed float64 // Degrees/s.
+	yawSpeed   float64 // Degrees/s.
+	movePitch  bool
+	moveYaw    bool
+	resetting  bool
+	minSteps   int // Minimum number of steps before completion.
+
+	startPitch, startYaw float64 // Attitude when the move started.
+}
+
+// taskType returns the task type reported for the move.
+func (m *gimbalMove) taskType() task.Type {
+	if m.resetting {
+		return task.TypeGimbalReset
+	}
+
+	return task.TypeGimbalAngle
+}
+
+// percent returns the move completion percentage for the given attitude.
+func (m *gimbalMove) percent(pitch, yaw float64) float64 {
+	var total, remaining float64
+	if m.movePitch {
+		total += math.Abs(m.pitch - m.startPitch)
+		remaining += math.Abs(m.pitch - pitch)
+	}
+	if m.moveYaw {
+		total += math.Abs(m.yaw - m.startYaw)
+		remaining += math.Abs(m.yaw - yaw)
+	}
+
+	if total == 0 {
+		return 100
+	}
+
+	return 100 * (1 - remaining/total)
+}
+
+// chassisMove is an in-progress chassis move to a specific target.
+type chassisMove struct {
+	x, y, yaw float64 // Target.
+	distance  float64 // Total translation.
+	rotation  float64 // Total rotation.
+}
+
+// robot holds the state of the simulated robot hardware. It is not safe for
+// concurrent use (it is protected by the UnityBridge mutex).
+type robot struct {
+	// Chassis pose.
+	x, y, yaw float64
+
+	// Chassis speeds (m/s for x and y, degrees/s for yaw).
+	speedX, speedY, speedYaw float64
+
+	// Whether the current chassis speeds came from a wheel speed command.
+	// Wheel speeds are not affected by the chassis speed mode disable word
+	// and must be zeroed explicitly.
+	wheelSpeedControl bool
+
+	// Chassis speed limits (m/s).
+	maxSpeedForward, maxSpeedBackward, maxSpeedLateral float64
+
+	// Gimbal attitude relative to the chassis (in degrees).
+	pitch, gimbalYaw float64
+
+	// Gimbal speeds (in degrees/s).
+	pitchSpeed, gimbalYawSpeed float64
+
+	speedRotationEnabled bool
+	attitudeUpdates      bool
+	chassisSpeedUpdates  bool
+	gimbalControlMode    uint64
+	recording            bool
+
+	// Drive path recording and playback.
+	recordSetting  value.RecordSetting
+	playSetting    value.PlayRecordSetting
+	routes         map[uint8]*route
+	routeRecording *routeRecording
+	playback       *routePlayback
+
+	imuCalibration    *imuCalibration
+	gimbalCalibration *gimbalCalibration
+
+	// Wheel encoder positions (in revolutions) and ESC clock (in ms).
+	wheelAngles  [4]float64
+	escTimeStamp float64
+
+	gimbalMove  *gimbalMove
+	chassisMove *chassisMove
+
+	// Task status pushes pending delivery. These are kept separately from
+	// the changed keys as more than one status might be reported per step.
+	taskStatuses []value.TaskStatus
+}
+
+func newRobot() *robot {
+	return &robot{
+		maxSpeedForward:  defaultMaxSpeed,
+		maxSpeedBackward: defaultMaxSpeed,
+		maxSpeedLateral:  defaultMaxSpeed,
+		recordSetting: value.RecordSetting{
+			MaxDuration:    defaultRecordDuration,
+			SampleInterval: defaultRecordSampleInterval,
+		},
+		playSetting: value.PlayRecordSetting{
+			Speed: 1,
+		},
+		routes: make(map[uint8]*route),
+	}
+}
+
+// chassisLimitKeys maps chassis limit configuration keys to the keys that
+// report the limits in effect.
+var chassisLimitKeys = map[*key.Key]*key.Key{
+	key.KeyMainControllerMaxSpeedForwardConfig:  key.KeyMainControllerMaxSpeedForward,
+	key.KeyMainControllerMaxSpeedBackwardConfig: key.KeyMainControllerMaxSpeedBackward,
+	key.KeyMainControllerMaxSpeedLateralConfig:  key.KeyMainControllerMaxSpeedLateral,
+	key.KeyMainControllerSlopSpeedXConfig:       key.KeyMainControllerSlopeX,
+	key.KeyMainControllerSlopSpeedYConfig:       key.KeyMainControllerSlopeY,
+	key.KeyMainControllerSlopBreakXConfig:       key.KeyMainControllerSlopeBreakX,
+	key.KeyMainControllerSlopBreakYConfig:       key.KeyMainControllerSlopeBreakY,
+}
+
+// initialValues returns the values reported by the robot right after a
+// connection is established.
+func (r *robot) initialValues() map[*key.Key][]byte {
+	values := map[*key.Key][]byte{
+		key.KeyAirLinkSignalQuality:              valueJSON(60),
+		key.KeyRobomasterBatteryPowerPercent:     valueJSON(100),
+		key.KeyRobomasterSystemSpeakerVolumn:     valueJSON(50),
+		key.KeyRobomasterSystemChassisSpeedLevel: valueJSON(1),
+		key.KeyRobomasterSystemWorkingDevices: mustJSON(value.List[uint16]{
+			List: []uint16{
+				deviceTypeImageTransmission,
+				deviceTypeCamera,
+				deviceTypeChassis,
+				deviceTypeBattery,
+				deviceTypeESC0,
+				deviceTypeESC1,
+				deviceTypeESC2,
+				deviceTypeESC3,
+				deviceTypeGimbal,
+				deviceTypeWaterGun,
+			},
+		}),
+		key.KeyGimbalWorkMode:                              valueJSON(0),
+		key.KeyGimbalControlMode:                           valueJSON(r.gimbalControlMode),
+		key.KeyGimbalResetPositionState:                    valueJSON(0),
+		key.KeyCameraMode:                                  valueJSON(1),
+		key.KeyCameraVideoFormat:                           valueJSON(0),
+		key.KeyCameraDigitalZoomFactor:                     valueJSON(1),
+		key.KeyCameraIsRecording:                           valueJSON(false),
+		key.KeyCameraCurrentRecordingTimeInSeconds:         valueJSON(0),
+		key.KeyCameraSDCardIsInserted:                      valueJSON(true),
+		key.KeyCameraSDCardIsFormatting:                    valueJSON(false),
+		key.KeyCameraSDCardIsFull:                          valueJSON(false),
+		key.KeyCameraSDCardHasError:                        valueJSON(false),
+		key.KeyCameraSDCardTotalSpaceInMB:                  valueJSON(30528),
+		key.KeyCameraSDCardRemainingSpaceInMB:              valueJSON(30000),
+		key.KeyCameraSDCardAvailablePhotoCount:             valueJSON(9999),
+		key.KeyCameraSDCardAvailableRecordingTimeInSeconds: valueJSON(36000),
+		key.KeyRobomasterMainControllerRelativePosition:    r.relativePositionJSON(),
+		key.KeyRobomasterSystemAttitudeInfo:                r.attitudeInfoJSON(),
+		key.KeyESCFirmwareVersion1:                         valueJSON(escFirmwareVersion),
+		key.KeyESCFirmwareVersion2:                         valueJSON(escFirmwareVersion),
+		key.KeyESCFirmwareVersion3:                         valueJSON(escFirmwareVersion),
+		key.KeyESCFirmwareVersion4:                         valueJSON(escFirmwareVersion),
+	}
+
+	values[key.KeyMainControllerRecordState] = valueJSON(0)
+	values[key.KeyMainControllerGetRecordSetting] = mustJSON(r.recordSetting)
+	values[key.KeyMainControllerGetPlayRecordSetting] = mustJSON(r.playSetting)
+	values[key.KeyMainControllerPlayRecordAttr] = mustJSON(
+		value.PlayRecordAttr{State: playbackStateIdle})
+
+	values[key.KeyRobomasterMainControllerIMUCalibrationState] = valueJSON(
+		imuCalibrationStateIdle)
+	values[key.KeyRobomasterMainControllerIMUCalibrationCurrSide] = valueJSON(0)
+	values[key.KeyRobomasterMainControllerIMUCalibrationProgress] = valueJSON(0)
+	values[key.KeyRobomasterMainControllerIMUCalibrationFailCode] = valueJSON(0)
+	values[key.KeyRobomasterMainControllerIMUCalibrationFinishFlag] = valueJSON(
+		false)
+
+	values[key.KeyGimbalCalibrationStatus] = valueJSON(
+		gimbalCalibrationStatusIdle)
+	values[key.KeyGimbalCalibrationProgress] = valueJSON(0)
+
+	for configKey, k := range chassisLimitKeys {
+		v := defaultSlope
+		switch configKey {
+		case key.KeyMainControllerMaxSpeedForwardConfig:
+			v = r.maxSpeedForward
+		case key.KeyMainControllerMaxSpeedBackwardConfig:
+			v = r.maxSpeedBackward
+		case key.KeyMainControllerMaxSpeedLateralConfig:
+			v = r.maxSpeedLateral
+		}
+
+		values[configKey] = valueJSON(v)
+		values[k] = valueJSON(v)
+	}
+
+	return values
+}
+
+// setValue handles a value being set for the given key and returns the error
+// code to be reported back and other keys that changed as a result (and their
+// new values).
+func (r *robot) setValue(k *key.Key, data []byte) (int64,
+	map[*key.Key][]byte) {
+	changed := make(map[*key.Key][]byte)
+
+	switch k {
+	case key.KeyMainControllerMaxSpeedForwardConfig,
+		key.KeyMainControllerMaxSpeedBackwardConfig,
+		key.KeyMainControllerMaxSpeedLateralConfig,
+		key.KeyMainControllerSlopSpeedXConfig,
+		key.KeyMainControllerSlopSpeedYConfig,
+		key.KeyMainControllerSlopBreakXConfig,
+		key.KeyMainControllerSlopBreakYConfig:
+		var v value.Float64
+		if err := json.Unmarshal(data, &v); err != nil || v.Value <= 0 {
+			return errorCodeInvalidData, nil
+		}
+
+		switch k {
+		case key.KeyMainControllerMaxSpeedForwardConfig:
+			r.maxSpeedForward = v.Value
+		case key.KeyMainControllerMaxSpeedBackwardConfig:
+			r.maxSpeedBackward = v.Value
+		case key.KeyMainControllerMaxSpeedLateralConfig:
+			r.maxSpeedLateral = v.Value
+		}
+
+		changed[chassisLimitKeys[k]] = valueJSON(v.Value)
+	case key.KeyMainControllerRecordState:
+		var v value.Uint64
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		r.setRecordState(v.Value != 0)
+	case key.KeyMainControllerSetPlayRecordSetting:
+		var v value.PlayRecordSetting
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		r.playSetting = v
+		changed[key.KeyMainControllerGetPlayRecordSetting] = mustJSON(v)
+	case key.KeyMainControllerPlayRecordAttr:
+		var v value.PlayRecordAttr
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		if state := r.setPlayback(v); state != v {
+			changed[key.KeyMainControllerPlayRecordAttr] = mustJSON(state)
+		}
+	case key.KeyMainControllerWheelSpeed:
+		var v value.WheelSpeed
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		r.abortChassisMove()
+
+		r.speedX, r.speedY, r.speedYaw = wheelsToChassis([4]float64{
+			float64(v.FrontRight),
+			float64(v.FrontLeft),
+			float64(v.RearLeft),
+			float64(v.RearRight),
+		})
+		r.wheelSpeedControl = r.speedX != 0 || r.speedY != 0 ||
+			r.speedYaw != 0
+	}
+
+	return 0, changed
+}
+
+// performAction handles an action request for the given key and returns the
+// error code to be reported back and the keys that changed as a result of the
+// action (and their new values).
+func (r *robot) performAction(k *key.Key, data []byte) (int64,
+	map[*key.Key][]byte) {
+	changed := make(map[*key.Key][]byte)
+
+	switch k {
+	case key.KeyGimbalOpenAttitudeUpdates:
+		r.attitudeUpdates = true
+	case key.KeyGimbalCloseAttitudeUpdates:
+		r.attitudeUpdates = false
+	case key.KeyRobomasterOpenChassisSpeedUpdates:
+		r.chassisSpeedUpdates = true
+	case key.KeyRobomasterCloseChassisSpeedUpdates:
+		r.chassisSpeedUpdates = false
+	case key.KeyGimbalSpeedRotationEnabled:
+		var v value.Uint64
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		r.speedRotationEnabled = v.Value != 0
+		if !r.speedRotationEnabled {
+			r.pitchSpeed = 0
+			r.gimbalYawSpeed = 0
+		}
+	case key.KeyGimbalSpeedRotation:
+		var v value.GimbalSpeedRotation
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		if r.speedRotationEnabled {
+			r.abortGimbalMove()
+			r.pitchSpeed = float64(v.Pitch) / 10
+			r.gimbalYawSpeed = float64(v.Yaw) / 10
+		}
+	case key.KeyGimbalAngleIncrementRotation:
+		var v value.GimbalAngleRotation
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		r.startGimbalMove(r.pitch+float64(v.Pitch)/10,
+			r.gimbalYaw+float64(v.Yaw)/10, v.Pitch != 0, v.Yaw != 0, v.Time)
+	case key.KeyGimbalAngleFrontPitchRotation:
+		var v value.GimbalAngleRotation
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		r.startGimbalMove(float64(v.Pitch)/10, r.gimbalYaw, true, false,
+			v.Time)
+	case key.KeyGimbalAngleFrontYawRotation:
+		var v value.GimbalAngleRotation
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		r.startGimbalMove(r.pitch, float64(v.Yaw)/10, false, true, v.Time)
+	case key.KeyGimbalResetPosition:
+		r.pitchSpeed = 0
+		r.gimbalYawSpeed = 0
+		r.replaceGimbalMove(&gimbalMove{
+			movePitch:  true,
+			moveYaw:    true,
+			pitchSpeed: gimbalResetSpeed,
+			yawSpeed:   gimbalResetSpeed,
+			resetting:  true,
+			minSteps:   gimbalResetMinSteps,
+			startPitch: r.pitch,
+			startYaw:   r.gimbalYaw,
+		})
+
+		changed[key.KeyGimbalResetPositionState] = valueJSON(1)
+	case key.KeyMainControllerChassisPosition:
+		var v value.ChassisPosition
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errorCodeInvalidData, nil
+		}
+
+		if v.IsCancel != 0 {
+			r.abortChassisMove()
+			break
+		}
+
+		r.startChassisMove(float64(v.X), float64(v.Y), float64(v.Z))
+	case key.KeyCameraStartRecordVideo:
+		r.recording = true
+		changed[key.KeyCameraIsRecording] = valueJSON(true)
+	case key.KeyCameraStopRecordVideo:
+		r.recording = false
+		changed[key.KeyCameraIsRecording] = valueJSON(false)
+	case key.KeyRobomasterMainControllerStartIMUCalibration:
+		r.startIMUCalibration(changed)
+	case key.KeyRobomasterMainControllerStopIMUCalibration:
+		r.stopIMUCalibration(changed)
+	case key.KeyGimbalCalibration:
+		r.startGimbalCalibration(gimbalCalibrationDuration, changed)
+	case key.KeyGimbalAutoCalibrate:
+		r.startGimbalCalibration(gimbalAutoCalibrationDuration, changed)
+	}
+
+	return 0, changed
+}
+
+// directValue handles a value sent directly (with no reply) for the given
+// key.
+func (r *robot) directValue(k *key.Key, data uint64) {
+	switch k {
+	case key.KeyMainControllerChassisSpeedMode,
+		key.KeyMainControllerChassisFollowMode:
+		var cs control.ChassisSpeed
+		if err := cs.Decode(data); err != nil {
+			return
+		}
+
+		if !cs.Enabled && r.wheelSpeedControl {
+			// Wheel speeds are independent from the chassis speed mode.
+			return
+		}
+
+		r.abortChassisMove()
+		r.wheelSpeedControl = false
+
+		if !cs.Enabled {
+			// Movement disabled.
+			r.speedX, r.speedY, r.speedYaw = 0, 0, 0
+			return
+		}
+
+		r.speedX, r.speedY, r.speedYaw = cs.X, cs.Y, cs.Z
+	case key.KeyMainControllerVirtualStick:
+		var vs control.VirtualStick
+		if err := vs.Decode(data); err != nil {
+			return
+		}
+
+		r.abortChassisMove()
+		r.wheelSpeedControl = false
+
+		if vs.ChassisEnabled {
+			r.speedX = control.NormalizedStickValue(vs.Chassis.Y) *
+				stickMaxChassis
+			r.speedY = control.NormalizedStickValue(vs.Chassis.X) *
+				stickMaxChassis
+		} else {
+			r.speedX, r.speedY = 0, 0
+		}
+
+		if vs.GimbalEnabled {
+			r.abortGimbalMove()
+			r.pitchSpeed = control.NormalizedStickValue(vs.Gimbal.Y) *
+				stickMaxGimbalSpeed
+			r.gimbalYawSpeed = control.NormalizedStickValue(vs.Gimbal.X) *
+				stickMaxGimbalSpeed
+		} else if !r.speedRotationEnabled {
+			r.pitchSpeed, r.gimbalYawSpeed = 0, 0
+		}
+	case key.KeyGimbalControlMode:
+		r.gimbalControlMode = data
+	}
+}
+
+// step advances the simulation by dt seconds and returns the keys that
+// changed (and their new values).
+func (r *robot) step(dt float64) map[*key.Key][]byte {
+	changed := make(map[*key.Key][]byte)
+
+	r.stepChassis(dt, changed)
+	r.stepRecording(dt, changed)
+	r.stepGimbal(dt, changed)
+	r.stepIMUCalibration(dt, changed)
+	r.stepGimbalCalibration(dt, changed)
+
+	if r.attitudeUpdates {
+		changed[key.KeyGimbalAttitude] = mustJSON(value.GimbalAttitude{
+			Pitch:       float32(r.pitch),
+			Yaw:         float32(r.gimbalYaw),
+			YawOpposite: float32(normalizeAngle(r.gimbalYaw + r.yaw)),
+			PitchSpeed:  float32(r.pitchSpeed),
+			YawSpeed:    float32(r.gimbalYawSpeed),
+		})
+	}
+
+	return changed
+}
+
+func (r *robot) stepChassis(dt float64, changed map[*key.Key][]byte) {
+	x, y, yaw := r.x, r.y, r.yaw
+
+	r.moveChassis(dt, changed)
+
+	if r.chassisSpeedUpdates {
+		// Report the actual speed (relative to the chassis heading) as moves
+		// to a specific position do not set the chassis speeds.
+		rad := yaw * math.Pi / 180
+		dx, dy := (r.x-x)/dt, (r.y-y)/dt
+
+		changed[key.KeyRobomasterChassisSpeed] = mustJSON(value.ChassisSpeed{
+			X: float32(dx*math.Cos(rad) + dy*math.Sin(rad)),
+			Y: float32(-dx*math.Sin(rad) + dy*math.Cos(rad)),
+			Z: float32(normalizeAngle(r.yaw-yaw) / dt),
+		})
+	}
+
+	if r.x != x || r.y != y {
+		changed[key.KeyRobomasterMainControllerRelativePosition] =
+			r.relativePositionJSON()
+	}
+
+	if r.yaw != yaw {
+		changed[key.KeyRobomasterMainControllerRelativePosition] =
+			r.relativePositionJSON()
+		changed[key.KeyRobomasterSystemAttitudeInfo] = r.attitudeInfoJSON()
+	}
+
+	r.stepWheels(dt, yaw, x, y, changed)
+}
+
+// escMotorInfoKeys are the ESC motor information keys for each wheel.
+var escMotorInfoKeys = [4]*key.Key{
+	key.KeyESCMotorInfomation1,
+	key.KeyESCMotorInfomation2,
+	key.KeyESCMotorInfomation3,
+	key.KeyESCMotorInfomation4,
+}
+
+// stepWheels derives the wheel speeds from the chassis motion in the last
+// step (which started at the given pose) and reports them.
+func (r *robot) stepWheels(dt, yaw, x, y float64,
+	changed map[*key.Key][]byte) {
+	rad := yaw * math.Pi / 180
+	dx, dy := (r.x-x)/dt, (r.y-y)/dt
+
+	rpms := chassisToWheels(dx*math.Cos(rad)+dy*math.Sin(rad),
+		-dx*math.Sin(rad)+dy*math.Cos(rad), normalizeAngle(r.yaw-yaw)/dt)
+
+	r.escTimeStamp += dt * 1000
+
+	for w, rpm := range rpms {
+		r.wheelAngles[w] = math.Mod(r.wheelAngles[w]+rpm/60*dt, 1)
+		if r.wheelAngles[w] < 0 {
+			r.wheelAngles[w]++
+		}
+
+		changed[escMotorInfoKeys[w]] = mustJSON(value.ESCMotorInfo{
+			Speed:     int16(math.Round(rpm)),
+			Angle:     uint16(r.wheelAngles[w] * (escAngleUnits - 1)),
+			TimeStamp: uint32(r.escTimeStamp),
+		})
+	}
+}
+
+// wheelsToChassis returns the chassis speeds (forward and right in m/s and
+// yaw in degrees/s) resulting from the given wheel speeds (in RPM).
+func wheelsToChassis(rpms [4]float64) (x, y, yaw float64) {
+	var w [4]float64
+	for i, rpm := range rpms {
+		w[i] = rpm * 2 * math.Pi / 60 * wheelRadius
+	}
+
+	fr, fl, rl, rr := w[0], w[1], w[2], w[3]
+
+	x = (fl + fr + rl + rr) / 4
+	y = (fl - fr - rl + rr) / 4
+	yaw = (fl - fr + rl - rr) / (4 * wheelBaseHalfs) * 180 / math.Pi
+
+	return x, y, yaw
+}
+
+// chassisToWheels returns the wheel speeds (in RPM) needed for the given
+// chassis speeds (forward and right in m/s and yaw in degrees/s).
+func chassisToWheels(x, y, yaw float64) [4]float64 {
+	z := yaw * math.Pi / 180 * wheelBaseHalfs
+	k := 60 / (2 * math.Pi * wheelRadius)
+
+	return [4]float64{
+		(x - y - z) * k,
+		(x + y + z) * k,
+		(x - y + z) * k,
+		(x + y - z) * k,
+	}
+}
+
+func (r *robot) moveChassis(dt float64, changed map[*key.Key][]byte) {
+	if r.playback != nil {
+		r.movePlayback(dt, changed)
+		return
+	}
+
+	if m := r.chassisMove; m != nil {
+		dx, dy := m.x-r.x, m.y-r.y
+		dist := math.Hypot(dx, dy)
+		dyaw := normalizeAngle(m.yaw - r.yaw)
+
+		if dist > 0 {
+			step := math.Min(dist, chassisMoveSpeed*dt)
+			r.x += dx / dist * step
+			r.y += dy / dist * step
+		}
+
+		if dyaw != 0 {
+			r.yaw = normalizeAngle(r.yaw +
+				math.Copysign(math.Min(math.Abs(dyaw), chassisRotateSpeed*dt),
+					dyaw))
+		}
+
+		remaining := math.Hypot(m.x-r.x, m.y-r.y)
+		remainingYaw := math.Abs(normalizeAngle(m.yaw - r.yaw))
+
+		status := task.StatusRunning
+		if remaining < 1e-6 && remainingYaw < 1e-6 {
+			status = task.StatusSuccess
+			r.chassisMove = nil
+		}
+
+		percent := 100.0
+		if total := m.distance/chassisMoveSpeed +
+			m.rotation/chassisRotateSpeed; total > 0 {
+			percent = 100 * (1 - (remaining/chassisMoveSpeed+
+				remainingYaw/chassisRotateSpeed)/total)
+		}
+
+		r.pushTaskStatus(task.TypeChassisPosition, percent, status)
+
+		return
+	}
+
+	if r.speedX == 0 && r.speedY == 0 && r.speedYaw == 0 {
+		return
+	}
+
+	// Speeds are relative to the chassis heading and limited by the
+	// configured maximum speeds.
+	speedX := clamp(r.speedX, -r.maxSpeedBackward, r.maxSpeedForward)
+	speedY := clamp(r.speedY, -r.maxSpeedLateral, r.maxSpeedLateral)
+
+	rad := r.yaw * math.Pi / 180
+	r.x += (speedX*math.Cos(rad) - speedY*math.Sin(rad)) * dt
+	r.y += (speedX*math.Sin(rad) + speedY*math.Cos(rad)) * dt
+	r.yaw = normalizeAngle(r.yaw + r.speedYaw*dt)
+}
+
+func (r *robot) relativePositionJSON() []byte {
+	return mustJSON(value.ChassisRelativePosition{
+		X: float32(r.x),
+		Y: float32(r.y),
+		Z: float32(r.yaw),
+	})
+}
+
+func (r *robot) attitudeInfoJSON() []byte {
+	return mustJSON(value.AttitudeInfo{
+		Yaw: float32(r.yaw),
+	})
+}
+
+func (r *robot) stepGimbal(dt float64, changed map[*key.Key][]byte) {
+	m := r.gimbalMove
+	if m == nil {
+		r.pitch = clamp(r.pitch+r.pitchSpeed*dt, gimbalMinPitch,
+			gimbalMaxPitch)
+		r.gimbalYaw = clamp(r.gimbalYaw+r.gimbalYawSpeed*dt, gimbalMinYaw,
+			gimbalMaxYaw)
+
+		return
+	}
+
+	m.minSteps--
+
+	done := m.minSteps <= 0
+
+	if m.movePitch {
+		r.pitch = approach(r.pitch, m.pitch, m.pitchSpeed*dt)
+		done = done && r.pitch == m.pitch
+	}
+
+	if m.moveYaw {
+		r.gimbalYaw = approach(r.gimbalYaw, m.yaw, m.yawSpeed*dt)
+		done = done && r.gimbalYaw == m.yaw
+	}
+
+	if !done {
+		r.pushTaskStatus(m.taskType(), m.percent(r.pitch, r.gimbalYaw),
+			task.StatusRunning)
+		return
+	}
+
+	r.gimbalMove = nil
+
+	r.pushTaskStatus(m.taskType(), 100, task.StatusSuccess)
+
+	if m.resetting {
+		changed[key.KeyGimbalResetPositionState] = valueJSON(0)
+	}
+}
+
+func (r *robot) startGimbalMove(pitch, yaw float64, movePitch, moveYaw bool,
+	durationMs int16) {
+	pitch = clamp(pitch, gimbalMinPitch, gimbalMaxPitch)
+	yaw = clamp(yaw, gimbalMinYaw, gimbalMaxYaw)
+
+	// Instant moves are executed in a single step.
+	duration := math.Max(float64(durationMs)/1000, 1e-3)
+
+	r.pitchSpeed = 0
+	r.gimbalYawSpeed = 0
+	r.replaceGimbalMove(&gimbalMove{
+		pitch:      pitch,
+		yaw:        yaw,
+		pitchSpeed: math.Abs(pitch-r.pitch) / duration,
+		yawSpeed:   math.Abs(yaw-r.gimbalYaw) / duration,
+		movePitch:  movePitch,
+		moveYaw:    moveYaw,
+		startPitch: r.pitch,
+		startYaw:   r.gimbalYaw,
+	})
+}
+
+// replaceGimbalMove starts the given gimbal move. An in-progress move of a
+// different type is reported as failed (one of the same type is simply
+// superseded by the new one).
+func (r *robot) replaceGimbalMove(m *gimbalMove) {
+	if old := r.gimbalMove; old != nil && old.taskType() != m.taskType() {
+		r.abortGimbalMove()
+	}
+
+	r.gimbalMove = m
+}
+
+// abortGimbalMove stops any in-progress gimbal move and reports it as failed.
+func (r *robot) abortGimbalMove() {
+	m := r.gimbalMove
+	if m == nil {
+		return
+	}
+
+	r.gimbalMove = nil
+
+	r.pushTaskStatus(m.taskType(), m.percent(r.pitch, r.gimbalYaw),
+		task.StatusFailure)
+}
+
+// abortChassisMove stops any in-progress chassis move and reports it as
+// failed.
+func (r *robot) abortChassisMove() {
+	m := r.chassisMove
+	if m == nil {
+		return
+	}
+
+	r.chassisMove = nil
+
+	r.pushTaskStatus(task.TypeChassisPosition, 0, task.StatusFailure)
+}
+
+// pushTaskStatus queues a task status push.
+func (r *robot) pushTaskStatus(typ task.Type, percent float64,
+	status task.Status) {
+	r.taskStatuses = append(r.taskStatuses, value.TaskStatus{
+		TaskType: typ,
+		Percent:  percent,
+		Status:   status,
+	})
+}
+
+// takeTaskStatuses returns (and clears) the queued task status pushes.
+func (r *robot) takeTaskStatuses() []value.TaskStatus {
+	ts := r.taskStatuses
+	r.taskStatuses = nil
+
+	return ts
+}
+
+func (r *robot) startChassisMove(x, y, yaw float64) {
+	// A new move aborts (and reports as failed) the one in progress.
+	r.abortChassisMove()
+
+	// Targets are relative to the current chassis pose.
+	rad := r.yaw * math.Pi / 180
+
+	r.speedX, r.speedY, r.speedYaw = 0, 0, 0
+	r.wheelSpeedControl = false
+	r.chassisMove = &chassisMove{
+		x:        r.x + x*math.Cos(rad) - y*math.Sin(rad),
+		y:        r.y + x*math.Sin(rad) + y*math.Cos(rad),
+		yaw:      normalizeAngle(r.yaw + yaw),
+		distance: math.Hypot(x, y),
+		rotation: math.Abs(yaw),
+	}
+}
+
+// approach moves current towards target by at most step.
+func approach(current, target, step float64) float64 {
+	if math.Abs(target-current) <= step {
+		return target
+	}
+
+	return current + math.Copysign(step, target-current)
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
+
+// normalizeAngle returns the given angle (in degrees) in the (-180, 180]
+// range.
+func normalizeAngle(a float64) float64 {
+	a = math.Mod(a, 360)
+	if a > 180 {
+		a -= 360
+	} else if a <= -180 {
+		a += 360
+	}
+
+	return a
+}
+
+func mustJSON(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
@@ -0,0 +1,200 @@
+package simulator
+
+import (
+	"math"
+
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/unity/result/value"
+)
+
+// Route playback states (see value.PlayRecordAttr).
+const (
+	playbackStateIdle = iota
+	playbackStatePlaying
+	playbackStateDone
+	playbackStateFailed
+)
+
+// pose is a chassis pose.
+type pose struct {
+	x, y, yaw float64
+}
+
+// route is a recorded drive path.
+type route struct {
+	poses    []pose
+	interval float64 // Seconds between samples.
+}
+
+// routeRecording is an in-progress drive path recording.
+type routeRecording struct {
+	index          uint8
+	interval       float64 // Seconds between samples.
+	maxDuration    float64 // Seconds.
+	elapsed        float64 // Seconds since the recording started.
+	sinceLastPoint float64 // Seconds since the last sample.
+}
+
+// routePlayback is an in-progress drive path playback.
+type routePlayback struct {
+	index    uint8
+	poses    []pose
+	interval float64 // Seconds between samples.
+	speed    float64 // Speed multiplier.
+	runs     int     // Total number of times to play.
+	run      int     // Current run (starting at 0).
+	t        float64 // Route time in the current run (in seconds).
+	start    pose    // Chassis pose at the start of the current run.
+}
+
+// duration returns the duration of a single run (in route time).
+func (p *routePlayback) duration() float64 {
+	return float64(len(p.poses)-1) * p.interval
+}
+
+// percent returns the overall playback progress.
+func (p *routePlayback) percent() float64 {
+	return 100 * (float64(p.run) + p.t/p.duration()) / float64(p.runs)
+}
+
+// poseAt returns the chassis pose at the given route time of the current run.
+// Recorded poses are replayed relative to the pose at the start of the run.
+func (p *routePlayback) poseAt(t float64) pose {
+	i := int(t / p.interval)
+	if i >= len(p.poses)-1 {
+		i = len(p.poses) - 2
+	}
+
+	a, b := p.poses[i], p.poses[i+1]
+	f := math.Min(1, (t-float64(i)*p.interval)/p.interval)
+
+	first := p.poses[0]
+	x := a.x + (b.x-a.x)*f - first.x
+	y := a.y + (b.y-a.y)*f - first.y
+	yaw := a.yaw + normalizeAngle(b.yaw-a.yaw)*f - first.yaw
+
+	rad := (p.start.yaw - first.yaw) * math.Pi / 180
+
+	return pose{
+		x:   p.start.x + x*math.Cos(rad) - y*math.Sin(rad),
+		y:   p.start.y + x*math.Sin(rad) + y*math.Cos(rad),
+		yaw: normalizeAngle(p.start.yaw + yaw),
+	}
+}
+
+func (r *robot) pose() pose {
+	return pose{x: r.x, y: r.y, yaw: r.yaw}
+}
+
+// setRecordState starts or stops recording the chassis drive path.
+func (r *robot) setRecordState(recording bool) {
+	if !recording {
+		r.routeRecording = nil
+		return
+	}
+
+	s := r.recordSetting
+
+	r.routes[s.Index] = &route{
+		poses:    []pose{r.pose()},
+		interval: float64(s.SampleInterval) / 1000,
+	}
+	r.routeRecording = &routeRecording{
+		index:       s.Index,
+		interval:    float64(s.SampleInterval) / 1000,
+		maxDuration: float64(s.MaxDuration),
+	}
+}
+
+// stepRecording samples the chassis pose if a recording is in progress.
+func (r *robot) stepRecording(dt float64, changed map[*key.Key][]byte) {
+	rr := r.routeRecording
+	if rr == nil {
+		return
+	}
+
+	rr.elapsed += dt
+	rr.sinceLastPoint += dt
+
+	if rr.sinceLastPoint >= rr.interval {
+		rr.sinceLastPoint -= rr.interval
+		rt := r.routes[rr.index]
+		rt.poses = append(rt.poses, r.pose())
+	}
+
+	if rr.elapsed >= rr.maxDuration {
+		r.routeRecording = nil
+		changed[key.KeyMainControllerRecordState] = valueJSON(0)
+	}
+}
+
+// setPlayback starts or stops playing back a recorded drive path. It returns
+// the playback state to report.
+func (r *robot) setPlayback(v value.PlayRecordAttr) value.PlayRecordAttr {
+	if v.State != playbackStatePlaying {
+		r.playback = nil
+		return value.PlayRecordAttr{Index: v.Index, State: playbackStateIdle}
+	}
+
+	rt := r.routes[v.Index]
+	if rt == nil || len(rt.poses) < 2 || r.routeRecording != nil {
+		r.playback = nil
+		return value.PlayRecordAttr{Index: v.Index, State: playbackStateFailed}
+	}
+
+	speed := r.playSetting.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	r.abortChassisMove()
+	r.speedX, r.speedY, r.speedYaw = 0, 0, 0
+	r.wheelSpeedControl = false
+
+	r.playback = &routePlayback{
+		index:    v.Index,
+		poses:    rt.poses,
+		interval: rt.interval,
+		speed:    speed,
+		runs:     int(r.playSetting.Repeat) + 1,
+		start:    r.pose(),
+	}
+
+	return value.PlayRecordAttr{Index: v.Index, State: playbackStatePlaying}
+}
+
+// movePlayback moves the chassis along the drive path being played back.
+func (r *robot) movePlayback(dt float64, changed map[*key.Key][]byte) {
+	p := r.playback
+
+	p.t += dt * p.speed
+	if p.t >= p.duration() {
+		end := p.poseAt(p.duration())
+		r.x, r.y, r.yaw = end.x, end.y, end.yaw
+
+		p.run++
+		p.t = 0
+		p.start = end
+
+		if p.run == p.runs {
+			r.playback = nil
+			changed[key.KeyMainControllerPlayRecordAttr] = mustJSON(
+				value.PlayRecordAttr{
+					Index:   p.index,
+					State:   playbackStateDone,
+					Percent: 100,
+				})
+			return
+		}
+	} else {
+		current := p.poseAt(p.t)
+		r.x, r.y, r.yaw = current.x, current.y, current.yaw
+	}
+
+	changed[key.KeyMainControllerPlayRecordAttr] = mustJSON(
+		value.PlayRecordAttr{
+			Index:   p.index,
+			State:   playbackStatePlaying,
+			Percent: p.percent(),
+		})
+}
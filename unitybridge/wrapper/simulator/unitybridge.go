@@ -0,0 +1,658 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brunoga/robomaster/support/logger"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/wrapper"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/callback"
+
+	internal_callback "github.com/brunoga/robomaster/unitybridge/wrapper/internal/callback"
+)
+
+const (
+	// Connection event sub-types (see the connection module).
+	subTypeConnectionOpen = iota
+	subTypeConnectionClose
+	subTypeConnectionSetIP
+	subTypeConnectionSetPort
+)
+
+const (
+	// Error code reported when a key has no value available.
+	errorCodeValueUnavailable = 1
+
+	// Error code reported when an operation is not allowed by the key access
+	// type.
+	errorCodeAccessDenied = 2
+
+	// Error code reported when the data sent with a request can not be
+	// parsed.
+	errorCodeInvalidData = 3
+
+	// Error code reported when an operation is not possible because the
+	// simulated robot is not connected.
+	errorCodeNotConnected = 4
+)
+
+const (
+	// Simulation step.
+	tickInterval = 20 * time.Millisecond
+
+	// Video frame dimensions and rate (matching what the camera module
+	// expects).
+	videoFrameWidth    = 1280
+	videoFrameHeight   = 720
+	videoFrameInterval = 100 * time.Millisecond
+)
+
+// UnityBridge is a stateful simulated robot that implements the
+// wrapper.UnityBridge interface. It understands the events sent by the high
+// level Unity Bridge API (get/set/perform action/listen for keys, connection
+// and video events) and replies to them as a real robot (through the real
+// Unity Bridge library) would, so everything built on top of it can run with
+// no robot, Wine or DJI library available.
+//
+// Key values are kept as raw JSON (exactly as the Unity Bridge library
+// reports them). Keys related to the robot connection, working devices,
+// battery, gimbal attitude and chassis motion are actively modeled. Any other
+// key simply stores whatever is written to it.
+type UnityBridge struct {
+	l  *logger.Logger
+	cm *internal_callback.Manager
+
+	// sendM serializes the delivery of queued events so they are delivered
+	// in the order they were queued.
+	sendM sync.Mutex
+
+	m           sync.Mutex
+	outbox      []outgoing
+	initialized bool
+	connected   bool
+	ip          string
+	port        uint64
+	values      map[*key.Key][]byte
+	listening   map[*key.Key]struct{}
+	robot       *robot
+	video       bool
+	quit        chan struct{}
+}
+
+var _ wrapper.UnityBridge = (*UnityBridge)(nil)
+
+// outgoing is an event waiting to be delivered to the Unity Bridge API.
+type outgoing struct {
+	e        *event.Event
+	data     []byte
+	dataType event.DataType
+	tag      uint64
+}
+
+// NewUnityBridgeWrapper returns a new simulated robot instance. Each instance
+// has its own event callbacks, so several simulated robots can be used in the
+// same process.
+func NewUnityBridgeWrapper(l *logger.Logger) *UnityBridge {
+	if l == nil {
+		l = logger.New(slog.LevelError)
+	}
+
+	l = l.WithGroup("simulator")
+
+	return &UnityBridge{
+		l:         l,
+		cm:        internal_callback.NewLocalManager(l),
+		values:    make(map[*key.Key][]byte),
+		listening: make(map[*key.Key]struct{}),
+		robot:     newRobot(),
+	}
+}
+
+// Create implements wrapper.UnityBridge.
+func (u *UnityBridge) Create(name string, debuggable bool, logPath string) {
+	u.l.Debug("Create", "name", name, "debuggable", debuggable, "logPath",
+		logPath)
+}
+
+// Initialize implements wrapper.UnityBridge. It starts the simulation loop.
+func (u *UnityBridge) Initialize() bool {
+	u.m.Lock()
+	defer u.unlock()
+
+	if u.initialized {
+		return false
+	}
+
+	u.initialized = true
+	u.quit = make(chan struct{})
+
+	go u.loop(u.quit)
+
+	return true
+}
+
+// SetEventCallback implements wrapper.UnityBridge.
+func (u *UnityBridge) SetEventCallback(eventTypeCode uint64,
+	c callback.Callback) {
+	err := u.cm.Set(eventTypeCode, c)
+	if err != nil {
+		u.l.Warn("Error setting event callback", "eventTypeCode",
+			eventTypeCode, "error", err)
+	}
+}
+
+// SendEvent implements wrapper.UnityBridge.
+func (u *UnityBridge) SendEvent(eventCode uint64, output []byte, tag uint64) {
+	e := event.NewFromCode(eventCode)
+
+	switch e.Type() {
+	case event.TypeGetValue:
+		u.getValue(e, tag)
+	case event.TypeGetAvailableValue:
+		u.getAvailableValue(e, output)
+	case event.TypePerformAction:
+		u.performAction(e, nil, tag)
+	case event.TypeStartListening:
+		u.startListening(e)
+	case event.TypeStopListening:
+		u.stopListening(e)
+	case event.TypeConnection:
+		u.connection(e, nil)
+	case event.TypeStartVideo:
+		u.setVideo(true)
+	case event.TypeStopVideo:
+		u.setVideo(false)
+	default:
+		u.l.Debug("Ignoring event", "event", e, "tag", tag)
+	}
+}
+
+// SendEventWithString implements wrapper.UnityBridge.
+func (u *UnityBridge) SendEventWithString(eventCode uint64, data string,
+	tag uint64) {
+	e := event.NewFromCode(eventCode)
+
+	switch e.Type() {
+	case event.TypeSetValue:
+		u.setValue(e, []byte(data), tag)
+	case event.TypePerformAction:
+		u.performAction(e, []byte(data), tag)
+	case event.TypeConnection:
+		u.connection(e, data)
+	default:
+		u.l.Debug("Ignoring event with string", "event", e, "data", data,
+			"tag", tag)
+	}
+}
+
+// SendEventWithNumber implements wrapper.UnityBridge.
+func (u *UnityBridge) SendEventWithNumber(eventCode uint64, data,
+	tag uint64) {
+	e := event.NewFromCode(eventCode)
+
+	switch e.Type() {
+	case event.TypePerformAction:
+		u.directValue(e, data)
+	case event.TypeConnection:
+		u.connection(e, data)
+	default:
+		u.l.Debug("Ignoring event with number", "event", e, "data", data,
+			"tag", tag)
+	}
+}
+
+// GetSecurityKeyByKeyChainIndex implements wrapper.UnityBridge. The simulator
+// has no security keys.
+func (u *UnityBridge) GetSecurityKeyByKeyChainIndex(index int) string {
+	return ""
+}
+
+// Uninitialize implements wrapper.UnityBridge. It stops the simulation loop
+// and drops the simulated connection.
+func (u *UnityBridge) Uninitialize() {
+	u.m.Lock()
+	defer u.unlock()
+
+	if !u.initialized {
+		return
+	}
+
+	close(u.quit)
+
+	u.initialized = false
+	u.connected = false
+	u.video = false
+	u.listening = make(map[*key.Key]struct{})
+}
+
+// Destroy implements wrapper.UnityBridge.
+func (u *UnityBridge) Destroy() {
+	u.l.Debug("Destroy")
+}
+
+// Connected returns true if a connection to the simulated robot is currently
+// open.
+func (u *UnityBridge) Connected() bool {
+	u.m.Lock()
+	defer u.unlock()
+
+	return u.connected
+}
+
+// KeyValue returns the raw JSON value currently associated with the given key
+// and true or nil and false if there is no value.
+func (u *UnityBridge) KeyValue(k *key.Key) ([]byte, bool) {
+	u.m.Lock()
+	defer u.unlock()
+
+	v, ok := u.values[k]
+
+	return v, ok
+}
+
+// SetKeyValue sets the value associated with the given key (v is marshaled
+// to JSON) and notifies any listeners for it. This can be used to inject state
+// changes (battery level, connected devices, etc) into the simulation.
+func (u *UnityBridge) SetKeyValue(k *key.Key, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	u.m.Lock()
+	defer u.unlock()
+
+	u.updateValueLocked(k, data)
+
+	return nil
+}
+
+// ChassisPosition returns the simulated chassis position (in meters) and
+// heading (in degrees) relative to where the chassis was when the simulation
+// started.
+func (u *UnityBridge) ChassisPosition() (x, y, yaw float64) {
+	u.m.Lock()
+	defer u.unlock()
+
+	return u.robot.x, u.robot.y, u.robot.yaw
+}
+
+// GimbalAngles returns the simulated gimbal pitch and yaw (in degrees,
+// relative to the chassis).
+func (u *UnityBridge) GimbalAngles() (pitch, yaw float64) {
+	u.m.Lock()
+	defer u.unlock()
+
+	return u.robot.pitch, u.robot.gimbalYaw
+}
+
+func (u *UnityBridge) getValue(e *event.Event, tag uint64) {
+	k, err := key.FromEvent(e)
+	if err != nil {
+		u.l.Warn("Get for unknown key", "event", e, "error", err)
+		return
+	}
+
+	u.m.Lock()
+	defer u.unlock()
+
+	if k.AccessType()&key.AccessTypeRead == 0 {
+		u.replyLocked(e, k, tag, errorCodeAccessDenied, nil)
+		return
+	}
+
+	v, ok := u.values[k]
+	if !ok {
+		u.replyLocked(e, k, tag, errorCodeValueUnavailable, nil)
+		return
+	}
+
+	u.replyLocked(e, k, tag, 0, v)
+}
+
+func (u *UnityBridge) getAvailableValue(e *event.Event, output []byte) {
+	k, err := key.FromEvent(e)
+	if err != nil {
+		u.l.Warn("Get available value for unknown key", "event", e, "error",
+			err)
+		return
+	}
+
+	u.m.Lock()
+	v, ok := u.values[k]
+	u.unlock()
+
+	if !ok || len(output) == 0 {
+		return
+	}
+
+	data := resultJSON(k, 0, 0, v)
+	if len(data) >= len(output) {
+		u.l.Warn("Cached value does not fit output buffer", "key", k,
+			"size", len(data))
+		return
+	}
+
+	n := copy(output, data)
+	output[n] = 0
+}
+
+func (u *UnityBridge) setValue(e *event.Event, data []byte, tag uint64) {
+	k, err := key.FromEvent(e)
+	if err != nil {
+		u.l.Warn("Set for unknown key", "event", e, "error", err)
+		return
+	}
+
+	u.m.Lock()
+	defer u.unlock()
+
+	if k.AccessType()&key.AccessTypeWrite == 0 {
+		u.replyLocked(e, k, tag, errorCodeAccessDenied, nil)
+		return
+	}
+
+	if !json.Valid(data) {
+		u.replyLocked(e, k, tag, errorCodeInvalidData, nil)
+		return
+	}
+
+	errorCode, changed := u.robot.setValue(k, data)
+	if errorCode != 0 {
+		u.replyLocked(e, k, tag, errorCode, nil)
+		return
+	}
+
+	u.updateValueLocked(k, data)
+
+	u.replyLocked(e, k, tag, 0, nil)
+
+	for k, v := range changed {
+		u.updateValueLocked(k, v)
+	}
+
+	u.pushTaskStatusesLocked()
+}
+
+func (u *UnityBridge) performAction(e *event.Event, data []byte,
+	tag uint64) {
+	k, err := key.FromEvent(e)
+	if err != nil {
+		u.l.Warn("Action for unknown key", "event", e, "error", err)
+		return
+	}
+
+	u.m.Lock()
+	defer u.unlock()
+
+	if k.AccessType()&key.AccessTypeAction == 0 {
+		u.replyLocked(e, k, tag, errorCodeAccessDenied, nil)
+		return
+	}
+
+	if !u.connected {
+		u.replyLocked(e, k, tag, errorCodeNotConnected, nil)
+		return
+	}
+
+	if len(data) != 0 && !json.Valid(data) {
+		u.replyLocked(e, k, tag, errorCodeInvalidData, nil)
+		return
+	}
+
+	errorCode, changed := u.robot.performAction(k, data)
+
+	u.replyLocked(e, k, tag, errorCode, nil)
+
+	for k, v := range changed {
+		u.updateValueLocked(k, v)
+	}
+
+	u.pushTaskStatusesLocked()
+}
+
+func (u *UnityBridge) directValue(e *event.Event, data uint64) {
+	k, err := key.FromEvent(e)
+	if err != nil {
+		u.l.Warn("Direct value for unknown key", "event", e, "error", err)
+		return
+	}
+
+	u.m.Lock()
+	defer u.unlock()
+
+	if !u.connected {
+		return
+	}
+
+	// Direct values never get a reply.
+	u.robot.directValue(k, data)
+
+	u.pushTaskStatusesLocked()
+}
+
+func (u *UnityBridge) startListening(e *event.Event) {
+	k, err := key.FromEvent(e)
+	if err != nil {
+		u.l.Warn("Start listening for unknown key", "event", e, "error", err)
+		return
+	}
+
+	u.m.Lock()
+	u.listening[k] = struct{}{}
+	u.unlock()
+}
+
+func (u *UnityBridge) stopListening(e *event.Event) {
+	k, err := key.FromEvent(e)
+	if err != nil {
+		u.l.Warn("Stop listening for unknown key", "event", e, "error", err)
+		return
+	}
+
+	u.m.Lock()
+	delete(u.listening, k)
+	u.unlock()
+}
+
+func (u *UnityBridge) connection(e *event.Event, data any) {
+	u.m.Lock()
+	defer u.unlock()
+
+	switch e.SubType() {
+	case subTypeConnectionSetIP:
+		u.ip, _ = data.(string)
+	case subTypeConnectionSetPort:
+		u.port, _ = data.(uint64)
+	case subTypeConnectionOpen:
+		if u.connected {
+			return
+		}
+
+		u.l.Debug("Connection opened", "ip", u.ip, "port", u.port)
+
+		u.connected = true
+		u.setConnectedLocked(true)
+	case subTypeConnectionClose:
+		if !u.connected {
+			return
+		}
+
+		u.l.Debug("Connection closed")
+
+		u.setConnectedLocked(false)
+		u.connected = false
+		u.video = false
+	}
+}
+
+func (u *UnityBridge) setConnectedLocked(connected bool) {
+	connectionKeys := []*key.Key{
+		key.KeyAirLinkConnection,
+		key.KeyRobomasterSystemConnection,
+		key.KeyMainControllerConnection,
+		key.KeyGimbalConnection,
+		key.KeyCameraConnection,
+	}
+
+	for _, k := range connectionKeys {
+		u.updateValueLocked(k, valueJSON(connected))
+	}
+
+	if !connected {
+		return
+	}
+
+	// There is no game pad attached to the simulated robot.
+	u.updateValueLocked(key.KeyRobomasterGamePadConnection, valueJSON(false))
+
+	for k, v := range u.robot.initialValues() {
+		if _, ok := u.values[k]; !ok {
+			u.updateValueLocked(k, v)
+		}
+	}
+}
+
+func (u *UnityBridge) setVideo(enabled bool) {
+	u.m.Lock()
+	defer u.unlock()
+
+	u.video = enabled && u.connected
+}
+
+// loop runs the simulation until quit is closed.
+func (u *UnityBridge) loop(quit <-chan struct{}) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	frame := make([]byte, videoFrameWidth*videoFrameHeight*3)
+	lastFrame := time.Now()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case now := <-ticker.C:
+			u.m.Lock()
+
+			if !u.connected {
+				u.unlock()
+				continue
+			}
+
+			for k, v := range u.robot.step(tickInterval.Seconds()) {
+				u.updateValueLocked(k, v)
+			}
+
+			u.pushTaskStatusesLocked()
+
+			sendFrame := u.video && now.Sub(lastFrame) >= videoFrameInterval
+
+			u.unlock()
+
+			if sendFrame {
+				lastFrame = now
+				u.run(event.NewFromType(event.TypeVideoDataRecv), frame,
+					event.DataTypeString, 0)
+			}
+		}
+	}
+}
+
+// updateValueLocked sets the value for the given key and notifies listeners
+// (if any). The mutex must be locked when this is called.
+func (u *UnityBridge) updateValueLocked(k *key.Key, data []byte) {
+	u.values[k] = data
+
+	if _, ok := u.listening[k]; !ok {
+		return
+	}
+
+	e := event.NewFromTypeAndSubType(event.TypeStartListening, k.SubType())
+
+	u.queueLocked(e, resultJSON(k, 0, 0, data), event.DataTypeString, 0)
+}
+
+// pushTaskStatusesLocked delivers any task status pushes queued by the
+// simulated robot. The mutex must be locked when this is called.
+func (u *UnityBridge) pushTaskStatusesLocked() {
+	for _, ts := range u.robot.takeTaskStatuses() {
+		u.updateValueLocked(key.KeyRobomasterSystemTaskStatus, mustJSON(ts))
+	}
+}
+
+// replyLocked sends the reply to a request back to the Unity Bridge API. The
+// mutex must be locked when this is called.
+func (u *UnityBridge) replyLocked(e *event.Event, k *key.Key, tag uint64,
+	errorCode int64, data []byte) {
+	u.queueLocked(e, resultJSON(k, tag, errorCode, data),
+		event.DataTypeString, tag)
+}
+
+// queueLocked queues the given event to be delivered to the Unity Bridge API
+// when the mutex is unlocked (see unlock). Events are never delivered with the
+// mutex locked as event callbacks might call back into the simulator. The
+// mutex must be locked when this is called.
+func (u *UnityBridge) queueLocked(e *event.Event, data []byte,
+	dataType event.DataType, tag uint64) {
+	u.outbox = append(u.outbox, outgoing{e, data, dataType, tag})
+}
+
+// unlock unlocks the mutex and delivers any events queued while it was
+// locked.
+func (u *UnityBridge) unlock() {
+	pending := u.outbox
+	u.outbox = nil
+
+	// Acquired before unlocking so events queued later by other goroutines
+	// are only delivered after these ones.
+	u.sendM.Lock()
+	defer u.sendM.Unlock()
+
+	u.m.Unlock()
+
+	for _, o := range pending {
+		u.run(o.e, o.data, o.dataType, o.tag)
+	}
+}
+
+func (u *UnityBridge) run(e *event.Event, data []byte,
+	dataType event.DataType, tag uint64) {
+	err := u.cm.Run(e.Code(), data, uint64(dataType)<<56|tag)
+	if err != nil {
+		u.l.Debug("Unable to deliver event", "event", e, "error", err)
+	}
+}
+
+// resultJSON returns the JSON representation of a result for the given key,
+// tag, error code and value (as the Unity Bridge library generates it).
+func resultJSON(k *key.Key, tag uint64, errorCode int64, data []byte) []byte {
+	if len(data) == 0 {
+		// Results with no value use an empty string.
+		data = []byte(`""`)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `{"key":%d,"tag":%d,"error":%d,"value":%s}`,
+		k.SubType(), tag, errorCode, data)
+
+	return []byte(b.String())
+}
+
+// valueJSON returns the JSON representation of a simple (single field) value.
+func valueJSON(v any) []byte {
+	data, err := json.Marshal(struct {
+		Value any `json:"value"`
+	}{v})
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
@@ -0,0 +1,146 @@
+package simulator_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/brunoga/robomaster"
+	"github.com/brunoga/robomaster/module"
+	"github.com/brunoga/robomaster/module/chassis"
+	"github.com/brunoga/robomaster/module/connection"
+	"github.com/brunoga/robomaster/module/gimbal"
+	"github.com/brunoga/robomaster/module/robot"
+	"github.com/brunoga/robomaster/unitybridge/unity/event"
+	"github.com/brunoga/robomaster/unitybridge/unity/key"
+	"github.com/brunoga/robomaster/unitybridge/wrapper/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	sim := simulator.NewUnityBridgeWrapper(nil)
+
+	c, err := robomaster.NewWithWrapper(nil, 0, connection.TypeWiFiDirect,
+		module.TypeConnection|module.TypeRobot|module.TypeChassis|
+			module.TypeGimbal, sim)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Start())
+	defer func() {
+		assert.NoError(t, c.Stop())
+		assert.False(t, sim.Connected())
+	}()
+
+	assert.True(t, sim.Connected())
+
+	// Values reported after connecting.
+	assert.True(t, c.Robot().WaitForDevices(time.Second))
+	assert.True(t, c.Robot().HasDevice(robot.DeviceTypeGimbal))
+	assert.Eventually(t, func() bool {
+		return c.Robot().BatteryPowerPercent() == 100
+	}, time.Second, 10*time.Millisecond)
+
+	// Read/write keys.
+	require.NoError(t, c.Robot().SetSpeakerVolume(20))
+	volume, err := c.Robot().SpeakerVolume()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(20), volume)
+
+	// Injected values.
+	require.NoError(t, sim.SetKeyValue(key.KeyRobomasterBatteryPowerPercent,
+		map[string]any{"value": 42}))
+	assert.Eventually(t, func() bool {
+		return c.Robot().BatteryPowerPercent() == 42
+	}, time.Second, 10*time.Millisecond)
+
+	// Chassis position moves.
+	require.NoError(t, c.Chassis().SetPosition(chassis.ModeAngularVelocity,
+		0.2, 0, 0))
+	assert.Eventually(t, func() bool {
+		x, y, _ := sim.ChassisPosition()
+		return math.Abs(x-0.2) < 1e-6 && math.Abs(y) < 1e-6
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Chassis speed control.
+	require.NoError(t, c.Chassis().SetSpeed(chassis.ModeAngularVelocity,
+		0, 0, 90))
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, c.Chassis().StopMovement(chassis.ModeAngularVelocity))
+	_, _, yaw := sim.ChassisPosition()
+	assert.Greater(t, yaw, 0.0)
+
+	// Wheel speed control and ESC motor information.
+	startX, _, _ := sim.ChassisPosition()
+	require.NoError(t, c.Chassis().SetWheelSpeed(100, 100, 100, 100))
+	assert.Eventually(t, func() bool {
+		for _, mi := range c.Chassis().Motors() {
+			if mi.Speed != 100 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, c.Chassis().SetWheelSpeed(0, 0, 0, 0))
+	x, _, _ := sim.ChassisPosition()
+	assert.Greater(t, x, startX)
+
+	versions, err := c.Chassis().ESCFirmwareVersions()
+	require.NoError(t, err)
+	assert.NotEmpty(t, versions[chassis.WheelRearRight])
+
+	// Gimbal angle rotation and reset.
+	require.NoError(t, c.Gimbal().SetAbsoluteAngleRotation(10,
+		gimbal.AxisPitch, 100*time.Millisecond))
+	assert.Eventually(t, func() bool {
+		pitch, _ := sim.GimbalAngles()
+		return pitch == 10
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, c.Gimbal().ResetPosition())
+	pitch, gimbalYaw := sim.GimbalAngles()
+	assert.Equal(t, 0.0, pitch)
+	assert.Equal(t, 0.0, gimbalYaw)
+}
+
+func TestSeparateCallbacks(t *testing.T) {
+	sims := []*simulator.UnityBridge{
+		simulator.NewUnityBridgeWrapper(nil),
+		simulator.NewUnityBridgeWrapper(nil),
+	}
+
+	code := event.NewFromType(event.TypeGetValue).Code()
+
+	tags := make([]chan uint64, len(sims))
+	for i, sim := range sims {
+		tags[i] = make(chan uint64, 1)
+		c := tags[i]
+		sim.SetEventCallback(code, func(_ uint64, _ []byte, tag uint64) {
+			c <- tag
+		})
+		defer sim.SetEventCallback(code, nil)
+
+		require.True(t, sim.Initialize())
+		defer sim.Uninitialize()
+	}
+
+	ev := event.NewFromTypeAndSubType(event.TypeGetValue,
+		key.KeyRobomasterSystemSpeakerVolumn.SubType())
+
+	for i, sim := range sims {
+		sim.SendEvent(ev.Code(), nil, uint64(i))
+
+		select {
+		case tag := <-tags[i]:
+			assert.Equal(t, uint64(i), tag)
+		case <-time.After(time.Second):
+			t.Fatalf("Callback for simulator %d not called", i)
+		}
+
+		select {
+		case tag := <-tags[1-i]:
+			t.Fatalf("Unexpected callback from other simulator: %d", tag)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}